@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/SimonSchneider/chore-tracker/internal/core"
 	"os"
+	_ "time/tzdata"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
@@ -1,14 +1,71 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.27.0
+//   sqlc v1.29.0
 // source: auth.sql
 
 package cdb
 
 import (
 	"context"
+	"database/sql"
 )
 
+const createAPIToken = `-- name: CreateAPIToken :one
+INSERT INTO api_token
+    (id, user_id, name, token_hash, write, all_lists, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id, user_id, name, token_hash, write, all_lists, created_at, last_used_at
+`
+
+type CreateAPITokenParams struct {
+	ID        string
+	UserID    string
+	Name      string
+	TokenHash string
+	Write     int64
+	AllLists  int64
+	CreatedAt int64
+}
+
+func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, createAPIToken,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.TokenHash,
+		arg.Write,
+		arg.AllLists,
+		arg.CreatedAt,
+	)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Write,
+		&i.AllLists,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const createAPITokenChoreList = `-- name: CreateAPITokenChoreList :exec
+INSERT INTO api_token_chore_list
+    (api_token_id, chore_list_id)
+VALUES (?, ?)
+`
+
+type CreateAPITokenChoreListParams struct {
+	ApiTokenID  string
+	ChoreListID string
+}
+
+func (q *Queries) CreateAPITokenChoreList(ctx context.Context, arg CreateAPITokenChoreListParams) error {
+	_, err := q.db.ExecContext(ctx, createAPITokenChoreList, arg.ApiTokenID, arg.ChoreListID)
+	return err
+}
+
 const createToken = `-- name: CreateToken :exec
 INSERT INTO tokens
     (user_id, token, expires_at)
@@ -26,6 +83,26 @@ func (q *Queries) CreateToken(ctx context.Context, arg CreateTokenParams) error
 	return err
 }
 
+const deleteAPIToken = `-- name: DeleteAPIToken :execrows
+DELETE
+FROM api_token
+WHERE id = ?
+  AND user_id = ?
+`
+
+type DeleteAPITokenParams struct {
+	ID     string
+	UserID string
+}
+
+func (q *Queries) DeleteAPIToken(ctx context.Context, arg DeleteAPITokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAPIToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteTokensByUserId = `-- name: DeleteTokensByUserId :exec
 DELETE
 FROM tokens
@@ -37,6 +114,135 @@ func (q *Queries) DeleteTokensByUserId(ctx context.Context, userID string) error
 	return err
 }
 
+const getAPITokenByHash = `-- name: GetAPITokenByHash :one
+SELECT id, user_id, name, token_hash, write, all_lists, created_at, last_used_at
+FROM api_token
+WHERE token_hash = ?
+`
+
+func (q *Queries) GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, getAPITokenByHash, tokenHash)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Write,
+		&i.AllLists,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getAPITokenChoreListIDs = `-- name: GetAPITokenChoreListIDs :many
+SELECT chore_list_id
+FROM api_token_chore_list
+WHERE api_token_id = ?
+ORDER BY chore_list_id
+`
+
+func (q *Queries) GetAPITokenChoreListIDs(ctx context.Context, apiTokenID string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getAPITokenChoreListIDs, apiTokenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var chore_list_id string
+		if err := rows.Scan(&chore_list_id); err != nil {
+			return nil, err
+		}
+		items = append(items, chore_list_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAPITokenChoreLists = `-- name: GetAPITokenChoreLists :many
+SELECT atcl.api_token_id, cl.id, cl.name
+FROM api_token_chore_list atcl
+         JOIN api_token at ON atcl.api_token_id = at.id
+         JOIN chore_list cl ON atcl.chore_list_id = cl.id
+WHERE at.user_id = ?
+ORDER BY cl.name, cl.id
+`
+
+type GetAPITokenChoreListsRow struct {
+	ApiTokenID string
+	ID         string
+	Name       string
+}
+
+func (q *Queries) GetAPITokenChoreLists(ctx context.Context, userID string) ([]GetAPITokenChoreListsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAPITokenChoreLists, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAPITokenChoreListsRow
+	for rows.Next() {
+		var i GetAPITokenChoreListsRow
+		if err := rows.Scan(&i.ApiTokenID, &i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAPITokensByUser = `-- name: GetAPITokensByUser :many
+SELECT id, user_id, name, token_hash, write, all_lists, created_at, last_used_at
+FROM api_token
+WHERE user_id = ?
+ORDER BY created_at DESC, id
+`
+
+func (q *Queries) GetAPITokensByUser(ctx context.Context, userID string) ([]ApiToken, error) {
+	rows, err := q.db.QueryContext(ctx, getAPITokensByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiToken
+	for rows.Next() {
+		var i ApiToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.TokenHash,
+			&i.Write,
+			&i.AllLists,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getToken = `-- name: GetToken :one
 SELECT user_id, token, expires_at
 FROM tokens
@@ -84,3 +290,19 @@ func (q *Queries) GetTokensByUser(ctx context.Context, userID string) ([]Token,
 	}
 	return items, nil
 }
+
+const touchAPIToken = `-- name: TouchAPIToken :exec
+UPDATE api_token
+SET last_used_at = ?
+WHERE id = ?
+`
+
+type TouchAPITokenParams struct {
+	LastUsedAt sql.NullInt64
+	ID         string
+}
+
+func (q *Queries) TouchAPIToken(ctx context.Context, arg TouchAPITokenParams) error {
+	_, err := q.db.ExecContext(ctx, touchAPIToken, arg.LastUsedAt, arg.ID)
+	return err
+}
@@ -1,6 +1,6 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.27.0
+//   sqlc v1.29.0
 
 package cdb
 
@@ -8,6 +8,22 @@ import (
 	"database/sql"
 )
 
+type ApiToken struct {
+	ID         string
+	UserID     string
+	Name       string
+	TokenHash  string
+	Write      int64
+	AllLists   int64
+	CreatedAt  int64
+	LastUsedAt sql.NullInt64
+}
+
+type ApiTokenChoreList struct {
+	ApiTokenID  string
+	ChoreListID string
+}
+
 type Chore struct {
 	ID             string
 	Name           string
@@ -20,14 +36,37 @@ type Chore struct {
 	RepeatsLeft    int64
 	ChoreType      string
 	Link           sql.NullString
+	Recurrence     string
+	Version        int64
+	AssignedTo     sql.NullString
+	Assignment     string
+	AutoComplete   int64
+	Effort         int64
+}
+
+type ChoreChecklistItem struct {
+	ID        string
+	ChoreID   string
+	Position  int64
+	Name      string
+	Checked   int64
+	CheckedBy sql.NullString
+}
+
+type ChoreDependency struct {
+	ChoreID     string
+	DependsOnID string
 }
 
 type ChoreEvent struct {
-	ID         string
-	ChoreID    string
-	OccurredAt int64
-	EventType  string
-	CreatedBy  string
+	ID                 string
+	ChoreID            string
+	OccurredAt         int64
+	EventType          string
+	CreatedBy          string
+	Duration           int64
+	AuthorName         sql.NullString
+	PreviousCompletion sql.NullInt64
 }
 
 type ChoreList struct {
@@ -35,6 +74,14 @@ type ChoreList struct {
 	CreatedAt int64
 	UpdatedAt int64
 	Name      string
+	Timezone  string
+}
+
+type ChoreListFeed struct {
+	Token       string
+	ChoreListID string
+	UserID      string
+	CreatedAt   int64
 }
 
 type ChoreListMember struct {
@@ -42,6 +89,30 @@ type ChoreListMember struct {
 	UserID      string
 }
 
+type ChoreState struct {
+	ChoreID     string
+	State       string
+	DueOn       int64
+	EvaluatedOn int64
+}
+
+type ChoreTransition struct {
+	ID          string
+	ChoreID     string
+	ChoreListID string
+	FromState   string
+	ToState     string
+	DueOn       int64
+	OccurredOn  int64
+	CreatedAt   int64
+}
+
+type ChoreTransitionPending struct {
+	TransitionID string
+	Notifier     string
+	CreatedAt    int64
+}
+
 type Invitation struct {
 	ID          string
 	CreatedAt   int64
@@ -56,6 +127,16 @@ type PasswordAuth struct {
 	Hash     string
 }
 
+type PushSubscription struct {
+	ID        string
+	UserID    string
+	Endpoint  string
+	P256dh    string
+	Auth      string
+	UserAgent string
+	CreatedAt int64
+}
+
 type Token struct {
 	UserID    string
 	Token     string
@@ -68,3 +149,49 @@ type User struct {
 	CreatedAt   int64
 	UpdatedAt   int64
 }
+
+type UserDigest struct {
+	UserID     string
+	Enabled    int64
+	Email      string
+	SendAt     int64
+	Timezone   string
+	LastSentOn int64
+	UpdatedAt  int64
+}
+
+type UserFeed struct {
+	Token     string
+	UserID    string
+	CreatedAt int64
+}
+
+type VapidKey struct {
+	ID         int64
+	PrivateKey string
+	CreatedAt  int64
+}
+
+type Webhook struct {
+	ID          string
+	ChoreListID string
+	Url         string
+	Secret      string
+	Events      string
+	CreatedBy   string
+	CreatedAt   int64
+}
+
+type WebhookDelivery struct {
+	ID             string
+	WebhookID      string
+	Event          string
+	Payload        string
+	Status         string
+	Attempts       int64
+	NextAttemptAt  int64
+	LastAttemptAt  sql.NullInt64
+	ResponseStatus sql.NullInt64
+	Error          sql.NullString
+	CreatedAt      int64
+}
@@ -1,6 +1,6 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.27.0
+//   sqlc v1.29.0
 // source: query.sql
 
 package cdb
@@ -26,29 +26,99 @@ func (q *Queries) AddUserToChoreList(ctx context.Context, arg AddUserToChoreList
 	return err
 }
 
-const completeChore = `-- name: CompleteChore :exec
+const assignChore = `-- name: AssignChore :exec
+UPDATE chore
+SET assigned_to = ?
+WHERE id = ?
+`
+
+type AssignChoreParams struct {
+	AssignedTo sql.NullString
+	ID         string
+}
+
+func (q *Queries) AssignChore(ctx context.Context, arg AssignChoreParams) error {
+	_, err := q.db.ExecContext(ctx, assignChore, arg.AssignedTo, arg.ID)
+	return err
+}
+
+const checkChoreChecklistItem = `-- name: CheckChoreChecklistItem :one
+UPDATE chore_checklist_item
+SET checked    = ?,
+    checked_by = ?
+WHERE id = ?
+  AND chore_id = ? RETURNING id, chore_id, position, name, checked, checked_by
+`
+
+type CheckChoreChecklistItemParams struct {
+	Checked   int64
+	CheckedBy sql.NullString
+	ID        string
+	ChoreID   string
+}
+
+func (q *Queries) CheckChoreChecklistItem(ctx context.Context, arg CheckChoreChecklistItemParams) (ChoreChecklistItem, error) {
+	row := q.db.QueryRowContext(ctx, checkChoreChecklistItem,
+		arg.Checked,
+		arg.CheckedBy,
+		arg.ID,
+		arg.ChoreID,
+	)
+	var i ChoreChecklistItem
+	err := row.Scan(
+		&i.ID,
+		&i.ChoreID,
+		&i.Position,
+		&i.Name,
+		&i.Checked,
+		&i.CheckedBy,
+	)
+	return i, err
+}
+
+const completeChore = `-- name: CompleteChore :execrows
 UPDATE chore
 SET last_completion = ?,
-    repeats_left    = max(-1, repeats_left - 1),
-    snoozed_for     = 0
+    repeats_left    = CASE WHEN repeats_left > 0 THEN repeats_left - 1 ELSE repeats_left END,
+    snoozed_for     = 0,
+    version         = version + 1
 WHERE id = ?
+  AND version = ?
 `
 
 type CompleteChoreParams struct {
 	LastCompletion int64
 	ID             string
+	Version        int64
 }
 
-func (q *Queries) CompleteChore(ctx context.Context, arg CompleteChoreParams) error {
-	_, err := q.db.ExecContext(ctx, completeChore, arg.LastCompletion, arg.ID)
-	return err
+func (q *Queries) CompleteChore(ctx context.Context, arg CompleteChoreParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, completeChore, arg.LastCompletion, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countUncheckedChoreChecklistItems = `-- name: CountUncheckedChoreChecklistItems :one
+SELECT COUNT(*)
+FROM chore_checklist_item
+WHERE chore_id = ?
+  AND checked = 0
+`
+
+func (q *Queries) CountUncheckedChoreChecklistItems(ctx context.Context, choreID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUncheckedChoreChecklistItems, choreID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
 const createChore = `-- name: CreateChore :one
 INSERT INTO chore
 (id, name, interval, created_at, last_completion, snoozed_for, repeats_left, chore_list_id, created_by, chore_type,
- link)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, name, interval, last_completion, snoozed_for, created_at, chore_list_id, created_by, repeats_left, chore_type, link
+ link, recurrence, assigned_to, assignment, auto_complete, effort)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, name, interval, last_completion, snoozed_for, created_at, chore_list_id, created_by, repeats_left, chore_type, link, recurrence, version, assigned_to, assignment, auto_complete, effort
 `
 
 type CreateChoreParams struct {
@@ -63,6 +133,11 @@ type CreateChoreParams struct {
 	CreatedBy      string
 	ChoreType      string
 	Link           sql.NullString
+	Recurrence     string
+	AssignedTo     sql.NullString
+	Assignment     string
+	AutoComplete   int64
+	Effort         int64
 }
 
 func (q *Queries) CreateChore(ctx context.Context, arg CreateChoreParams) (Chore, error) {
@@ -78,6 +153,11 @@ func (q *Queries) CreateChore(ctx context.Context, arg CreateChoreParams) (Chore
 		arg.CreatedBy,
 		arg.ChoreType,
 		arg.Link,
+		arg.Recurrence,
+		arg.AssignedTo,
+		arg.Assignment,
+		arg.AutoComplete,
+		arg.Effort,
 	)
 	var i Chore
 	err := row.Scan(
@@ -92,22 +172,74 @@ func (q *Queries) CreateChore(ctx context.Context, arg CreateChoreParams) (Chore
 		&i.RepeatsLeft,
 		&i.ChoreType,
 		&i.Link,
+		&i.Recurrence,
+		&i.Version,
+		&i.AssignedTo,
+		&i.Assignment,
+		&i.AutoComplete,
+		&i.Effort,
 	)
 	return i, err
 }
 
+const createChoreChecklistItem = `-- name: CreateChoreChecklistItem :exec
+INSERT INTO chore_checklist_item
+    (id, chore_id, position, name, checked, checked_by)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateChoreChecklistItemParams struct {
+	ID        string
+	ChoreID   string
+	Position  int64
+	Name      string
+	Checked   int64
+	CheckedBy sql.NullString
+}
+
+func (q *Queries) CreateChoreChecklistItem(ctx context.Context, arg CreateChoreChecklistItemParams) error {
+	_, err := q.db.ExecContext(ctx, createChoreChecklistItem,
+		arg.ID,
+		arg.ChoreID,
+		arg.Position,
+		arg.Name,
+		arg.Checked,
+		arg.CheckedBy,
+	)
+	return err
+}
+
+const createChoreDependency = `-- name: CreateChoreDependency :exec
+INSERT INTO chore_dependency
+    (chore_id, depends_on_id)
+VALUES (?, ?)
+`
+
+type CreateChoreDependencyParams struct {
+	ChoreID     string
+	DependsOnID string
+}
+
+func (q *Queries) CreateChoreDependency(ctx context.Context, arg CreateChoreDependencyParams) error {
+	_, err := q.db.ExecContext(ctx, createChoreDependency, arg.ChoreID, arg.DependsOnID)
+	return err
+}
+
 const createChoreEvent = `-- name: CreateChoreEvent :exec
 INSERT INTO chore_event
-    (id, chore_id, event_type, created_by, occurred_at)
-VALUES (?, ?, ?, ?, ?)
+    (id, chore_id, event_type, created_by, occurred_at, duration, author_name, previous_completion)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateChoreEventParams struct {
-	ID         string
-	ChoreID    string
-	EventType  string
-	CreatedBy  string
-	OccurredAt int64
+	ID                 string
+	ChoreID            string
+	EventType          string
+	CreatedBy          string
+	OccurredAt         int64
+	Duration           int64
+	AuthorName         sql.NullString
+	PreviousCompletion sql.NullInt64
 }
 
 func (q *Queries) CreateChoreEvent(ctx context.Context, arg CreateChoreEventParams) error {
@@ -117,6 +249,9 @@ func (q *Queries) CreateChoreEvent(ctx context.Context, arg CreateChoreEventPara
 		arg.EventType,
 		arg.CreatedBy,
 		arg.OccurredAt,
+		arg.Duration,
+		arg.AuthorName,
+		arg.PreviousCompletion,
 	)
 	return err
 }
@@ -124,7 +259,7 @@ func (q *Queries) CreateChoreEvent(ctx context.Context, arg CreateChoreEventPara
 const createChoreList = `-- name: CreateChoreList :one
 INSERT INTO chore_list
     (id, name, created_at, updated_at)
-VALUES (?, ?, ?, ?) RETURNING id, created_at, updated_at, name
+VALUES (?, ?, ?, ?) RETURNING id, created_at, updated_at, name, timezone
 `
 
 type CreateChoreListParams struct {
@@ -147,6 +282,7 @@ func (q *Queries) CreateChoreList(ctx context.Context, arg CreateChoreListParams
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.Timezone,
 	)
 	return i, err
 }
@@ -162,8 +298,87 @@ func (q *Queries) DeleteChore(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteChoreChecklist = `-- name: DeleteChoreChecklist :exec
+DELETE
+FROM chore_checklist_item
+WHERE chore_id = ?
+`
+
+func (q *Queries) DeleteChoreChecklist(ctx context.Context, choreID string) error {
+	_, err := q.db.ExecContext(ctx, deleteChoreChecklist, choreID)
+	return err
+}
+
+const deleteChoreCompletion = `-- name: DeleteChoreCompletion :one
+DELETE
+FROM chore_event
+WHERE id = ?
+  AND chore_id = ?
+  AND event_type = 'complete' RETURNING id, chore_id, occurred_at, event_type, created_by, duration, author_name, previous_completion
+`
+
+type DeleteChoreCompletionParams struct {
+	ID      string
+	ChoreID string
+}
+
+func (q *Queries) DeleteChoreCompletion(ctx context.Context, arg DeleteChoreCompletionParams) (ChoreEvent, error) {
+	row := q.db.QueryRowContext(ctx, deleteChoreCompletion, arg.ID, arg.ChoreID)
+	var i ChoreEvent
+	err := row.Scan(
+		&i.ID,
+		&i.ChoreID,
+		&i.OccurredAt,
+		&i.EventType,
+		&i.CreatedBy,
+		&i.Duration,
+		&i.AuthorName,
+		&i.PreviousCompletion,
+	)
+	return i, err
+}
+
+const deleteChoreDependencies = `-- name: DeleteChoreDependencies :exec
+DELETE
+FROM chore_dependency
+WHERE chore_id = ?
+`
+
+func (q *Queries) DeleteChoreDependencies(ctx context.Context, choreID string) error {
+	_, err := q.db.ExecContext(ctx, deleteChoreDependencies, choreID)
+	return err
+}
+
+const deleteChoreListFeed = `-- name: DeleteChoreListFeed :exec
+DELETE
+FROM chore_list_feed
+WHERE chore_list_id = ?
+  AND user_id = ?
+`
+
+type DeleteChoreListFeedParams struct {
+	ChoreListID string
+	UserID      string
+}
+
+func (q *Queries) DeleteChoreListFeed(ctx context.Context, arg DeleteChoreListFeedParams) error {
+	_, err := q.db.ExecContext(ctx, deleteChoreListFeed, arg.ChoreListID, arg.UserID)
+	return err
+}
+
+const deleteUserFeed = `-- name: DeleteUserFeed :exec
+DELETE
+FROM user_feed
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserFeed(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteUserFeed, userID)
+	return err
+}
+
 const getChore = `-- name: GetChore :one
-SELECT chore.id, chore.name, chore.interval, chore.last_completion, chore.snoozed_for, chore.created_at, chore.chore_list_id, chore.created_by, chore.repeats_left, chore.chore_type, chore.link
+SELECT chore.id, chore.name, chore.interval, chore.last_completion, chore.snoozed_for, chore.created_at, chore.chore_list_id, chore.created_by, chore.repeats_left, chore.chore_type, chore.link, chore.recurrence, chore.version, chore.assigned_to, chore.assignment, chore.auto_complete, chore.effort
 FROM chore
          JOIN chore_list cl ON chore.chore_list_id = cl.id
          JOIN chore_list_members ON cl.id = chore_list_members.chore_list_id
@@ -191,12 +406,151 @@ func (q *Queries) GetChore(ctx context.Context, arg GetChoreParams) (Chore, erro
 		&i.RepeatsLeft,
 		&i.ChoreType,
 		&i.Link,
+		&i.Recurrence,
+		&i.Version,
+		&i.AssignedTo,
+		&i.Assignment,
+		&i.AutoComplete,
+		&i.Effort,
 	)
 	return i, err
 }
 
+const getChoreAssignmentCandidates = `-- name: GetChoreAssignmentCandidates :many
+SELECT clm.user_id, CAST(COALESCE(MAX(ce.occurred_at), 0) AS INTEGER) AS last_completion
+FROM chore_list_members clm
+         LEFT JOIN chore_event ce
+                   ON ce.created_by = clm.user_id AND ce.chore_id = ? AND ce.event_type = 'complete' AND ce.author_name IS NULL
+WHERE clm.chore_list_id = ?
+GROUP BY clm.user_id
+ORDER BY clm.user_id
+`
+
+type GetChoreAssignmentCandidatesParams struct {
+	ChoreID     string
+	ChoreListID string
+}
+
+type GetChoreAssignmentCandidatesRow struct {
+	UserID         string
+	LastCompletion int64
+}
+
+func (q *Queries) GetChoreAssignmentCandidates(ctx context.Context, arg GetChoreAssignmentCandidatesParams) ([]GetChoreAssignmentCandidatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreAssignmentCandidates, arg.ChoreID, arg.ChoreListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChoreAssignmentCandidatesRow
+	for rows.Next() {
+		var i GetChoreAssignmentCandidatesRow
+		if err := rows.Scan(&i.UserID, &i.LastCompletion); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChoreChecklist = `-- name: GetChoreChecklist :many
+SELECT id, chore_id, position, name, checked, checked_by
+FROM chore_checklist_item
+WHERE chore_id = ?
+ORDER BY position
+`
+
+func (q *Queries) GetChoreChecklist(ctx context.Context, choreID string) ([]ChoreChecklistItem, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreChecklist, choreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChoreChecklistItem
+	for rows.Next() {
+		var i ChoreChecklistItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChoreID,
+			&i.Position,
+			&i.Name,
+			&i.Checked,
+			&i.CheckedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChoreEvents = `-- name: GetChoreEvents :many
+SELECT ce.id, ce.chore_id, ce.occurred_at, ce.event_type, ce.created_by, ce.duration, ce.author_name, ce.previous_completion, CAST(COALESCE(ce.author_name, u.display_name) AS TEXT) AS created_by_name
+FROM chore_event ce
+         JOIN user u ON ce.created_by = u.id
+WHERE ce.chore_id = ?
+ORDER BY ce.occurred_at DESC, ce.id DESC
+`
+
+type GetChoreEventsRow struct {
+	ID                 string
+	ChoreID            string
+	OccurredAt         int64
+	EventType          string
+	CreatedBy          string
+	Duration           int64
+	AuthorName         sql.NullString
+	PreviousCompletion sql.NullInt64
+	CreatedByName      string
+}
+
+func (q *Queries) GetChoreEvents(ctx context.Context, choreID string) ([]GetChoreEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreEvents, choreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChoreEventsRow
+	for rows.Next() {
+		var i GetChoreEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChoreID,
+			&i.OccurredAt,
+			&i.EventType,
+			&i.CreatedBy,
+			&i.Duration,
+			&i.AuthorName,
+			&i.PreviousCompletion,
+			&i.CreatedByName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChoreListByUser = `-- name: GetChoreListByUser :one
-SELECT cl.id, cl.created_at, cl.updated_at, cl.name
+SELECT cl.id, cl.created_at, cl.updated_at, cl.name, cl.timezone
 FROM chore_list cl
          JOIN chore_list_members clm ON cl.id = clm.chore_list_id
 WHERE clm.user_id = ?
@@ -216,11 +570,12 @@ func (q *Queries) GetChoreListByUser(ctx context.Context, arg GetChoreListByUser
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.Timezone,
 	)
 	return i, err
 }
 
-const getChoreListCalendarCompletionData = `-- name: GetChoreListCalendarCompletionData :many
+const getChoreListCalendarEventData = `-- name: GetChoreListCalendarEventData :many
 SELECT ce.occurred_at, COUNT(*) AS count
 FROM chore_event ce
     JOIN chore c
@@ -228,29 +583,31 @@ ON ce.chore_id = c.id
     JOIN chore_list_members clm ON c.chore_list_id = clm.chore_list_id
 WHERE clm.user_id = ?
   AND c.chore_list_id = ?
+  AND ce.event_type = ?
 GROUP BY 1
 ORDER BY 1
 `
 
-type GetChoreListCalendarCompletionDataParams struct {
+type GetChoreListCalendarEventDataParams struct {
 	UserID      string
 	ChoreListID string
+	EventType   string
 }
 
-type GetChoreListCalendarCompletionDataRow struct {
+type GetChoreListCalendarEventDataRow struct {
 	OccurredAt int64
 	Count      int64
 }
 
-func (q *Queries) GetChoreListCalendarCompletionData(ctx context.Context, arg GetChoreListCalendarCompletionDataParams) ([]GetChoreListCalendarCompletionDataRow, error) {
-	rows, err := q.db.QueryContext(ctx, getChoreListCalendarCompletionData, arg.UserID, arg.ChoreListID)
+func (q *Queries) GetChoreListCalendarEventData(ctx context.Context, arg GetChoreListCalendarEventDataParams) ([]GetChoreListCalendarEventDataRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreListCalendarEventData, arg.UserID, arg.ChoreListID, arg.EventType)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetChoreListCalendarCompletionDataRow
+	var items []GetChoreListCalendarEventDataRow
 	for rows.Next() {
-		var i GetChoreListCalendarCompletionDataRow
+		var i GetChoreListCalendarEventDataRow
 		if err := rows.Scan(&i.OccurredAt, &i.Count); err != nil {
 			return nil, err
 		}
@@ -265,11 +622,324 @@ func (q *Queries) GetChoreListCalendarCompletionData(ctx context.Context, arg Ge
 	return items, nil
 }
 
+const getChoreListChecklists = `-- name: GetChoreListChecklists :many
+SELECT cci.id, cci.chore_id, cci.position, cci.name, cci.checked, cci.checked_by
+FROM chore_checklist_item cci
+         JOIN chore c ON cci.chore_id = c.id
+WHERE c.chore_list_id = ?
+ORDER BY cci.chore_id, cci.position
+`
+
+func (q *Queries) GetChoreListChecklists(ctx context.Context, choreListID string) ([]ChoreChecklistItem, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreListChecklists, choreListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChoreChecklistItem
+	for rows.Next() {
+		var i ChoreChecklistItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChoreID,
+			&i.Position,
+			&i.Name,
+			&i.Checked,
+			&i.CheckedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChoreListCompletionsByMember = `-- name: GetChoreListCompletionsByMember :many
+SELECT u.id, CAST(COALESCE(ce.author_name, u.display_name) AS TEXT) AS display_name, COUNT(*) AS count
+FROM chore_event ce
+    JOIN chore c
+ON ce.chore_id = c.id
+    JOIN user u ON ce.created_by = u.id
+    JOIN chore_list_members clm ON c.chore_list_id = clm.chore_list_id
+WHERE clm.user_id = ?
+  AND c.chore_list_id = ?
+  AND ce.event_type = 'complete'
+GROUP BY u.id, ce.author_name
+ORDER BY count DESC, display_name, u.id
+`
+
+type GetChoreListCompletionsByMemberParams struct {
+	UserID      string
+	ChoreListID string
+}
+
+type GetChoreListCompletionsByMemberRow struct {
+	ID          string
+	DisplayName string
+	Count       int64
+}
+
+func (q *Queries) GetChoreListCompletionsByMember(ctx context.Context, arg GetChoreListCompletionsByMemberParams) ([]GetChoreListCompletionsByMemberRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreListCompletionsByMember, arg.UserID, arg.ChoreListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChoreListCompletionsByMemberRow
+	for rows.Next() {
+		var i GetChoreListCompletionsByMemberRow
+		if err := rows.Scan(&i.ID, &i.DisplayName, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChoreListDependencies = `-- name: GetChoreListDependencies :many
+SELECT cd.chore_id, cd.depends_on_id
+FROM chore_dependency cd
+         JOIN chore c ON cd.chore_id = c.id
+WHERE c.chore_list_id = ?
+ORDER BY cd.chore_id, cd.depends_on_id
+`
+
+func (q *Queries) GetChoreListDependencies(ctx context.Context, choreListID string) ([]ChoreDependency, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreListDependencies, choreListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChoreDependency
+	for rows.Next() {
+		var i ChoreDependency
+		if err := rows.Scan(&i.ChoreID, &i.DependsOnID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChoreListEvents = `-- name: GetChoreListEvents :many
+SELECT ce.id, ce.chore_id, ce.occurred_at, ce.event_type, ce.created_by, ce.duration, ce.author_name, ce.previous_completion, CAST(COALESCE(ce.author_name, u.display_name) AS TEXT) AS created_by_name
+FROM chore_event ce
+    JOIN chore c
+ON ce.chore_id = c.id
+    JOIN user u ON ce.created_by = u.id
+    JOIN chore_list_members clm ON c.chore_list_id = clm.chore_list_id
+WHERE clm.user_id = ?
+  AND c.chore_list_id = ?
+ORDER BY ce.occurred_at, ce.rowid
+`
+
+type GetChoreListEventsParams struct {
+	UserID      string
+	ChoreListID string
+}
+
+type GetChoreListEventsRow struct {
+	ID                 string
+	ChoreID            string
+	OccurredAt         int64
+	EventType          string
+	CreatedBy          string
+	Duration           int64
+	AuthorName         sql.NullString
+	PreviousCompletion sql.NullInt64
+	CreatedByName      string
+}
+
+func (q *Queries) GetChoreListEvents(ctx context.Context, arg GetChoreListEventsParams) ([]GetChoreListEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreListEvents, arg.UserID, arg.ChoreListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChoreListEventsRow
+	for rows.Next() {
+		var i GetChoreListEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChoreID,
+			&i.OccurredAt,
+			&i.EventType,
+			&i.CreatedBy,
+			&i.Duration,
+			&i.AuthorName,
+			&i.PreviousCompletion,
+			&i.CreatedByName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChoreListFeed = `-- name: GetChoreListFeed :one
+SELECT token, chore_list_id, user_id, created_at
+FROM chore_list_feed
+WHERE chore_list_id = ?
+  AND user_id = ?
+`
+
+type GetChoreListFeedParams struct {
+	ChoreListID string
+	UserID      string
+}
+
+func (q *Queries) GetChoreListFeed(ctx context.Context, arg GetChoreListFeedParams) (ChoreListFeed, error) {
+	row := q.db.QueryRowContext(ctx, getChoreListFeed, arg.ChoreListID, arg.UserID)
+	var i ChoreListFeed
+	err := row.Scan(
+		&i.Token,
+		&i.ChoreListID,
+		&i.UserID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getChoreListFeedByToken = `-- name: GetChoreListFeedByToken :one
+SELECT clf.token, clf.chore_list_id, clf.user_id, clf.created_at
+FROM chore_list_feed clf
+         JOIN chore_list_members clm ON clf.chore_list_id = clm.chore_list_id AND clf.user_id = clm.user_id
+WHERE clf.token = ?
+`
+
+func (q *Queries) GetChoreListFeedByToken(ctx context.Context, token string) (ChoreListFeed, error) {
+	row := q.db.QueryRowContext(ctx, getChoreListFeedByToken, token)
+	var i ChoreListFeed
+	err := row.Scan(
+		&i.Token,
+		&i.ChoreListID,
+		&i.UserID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getChoreListLastCompletions = `-- name: GetChoreListLastCompletions :many
+SELECT ce.chore_id, CAST(MAX(ce.occurred_at) AS INTEGER) AS occurred_at
+FROM chore_event ce
+         JOIN chore c ON ce.chore_id = c.id
+WHERE c.chore_list_id = ?
+  AND ce.event_type = 'complete'
+GROUP BY ce.chore_id
+`
+
+type GetChoreListLastCompletionsRow struct {
+	ChoreID    string
+	OccurredAt int64
+}
+
+func (q *Queries) GetChoreListLastCompletions(ctx context.Context, choreListID string) ([]GetChoreListLastCompletionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreListLastCompletions, choreListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChoreListLastCompletionsRow
+	for rows.Next() {
+		var i GetChoreListLastCompletionsRow
+		if err := rows.Scan(&i.ChoreID, &i.OccurredAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChoreListLeaderboard = `-- name: GetChoreListLeaderboard :many
+SELECT u.id, u.display_name, CAST(TOTAL(c.effort) AS INTEGER) AS points, COUNT(c.id) AS completions
+FROM chore_list_members clm
+         JOIN user u ON u.id = clm.user_id
+         LEFT JOIN chore_event ce
+                   ON ce.created_by = u.id AND ce.event_type = 'complete' AND ce.author_name IS NULL AND
+                      ce.occurred_at >= ?
+         LEFT JOIN chore c ON ce.chore_id = c.id AND c.chore_list_id = clm.chore_list_id
+WHERE clm.chore_list_id = ?
+GROUP BY u.id, u.display_name
+ORDER BY points DESC, u.display_name, u.id
+`
+
+type GetChoreListLeaderboardParams struct {
+	OccurredAt  int64
+	ChoreListID string
+}
+
+type GetChoreListLeaderboardRow struct {
+	ID          string
+	DisplayName string
+	Points      int64
+	Completions int64
+}
+
+func (q *Queries) GetChoreListLeaderboard(ctx context.Context, arg GetChoreListLeaderboardParams) ([]GetChoreListLeaderboardRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChoreListLeaderboard, arg.OccurredAt, arg.ChoreListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChoreListLeaderboardRow
+	for rows.Next() {
+		var i GetChoreListLeaderboardRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DisplayName,
+			&i.Points,
+			&i.Completions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChoreListMembers = `-- name: GetChoreListMembers :many
 SELECT u.id, u.display_name
 FROM user u
          JOIN chore_list_members clm ON u.id = clm.user_id
 WHERE clm.chore_list_id = ?
+ORDER BY u.display_name, u.id
 `
 
 type GetChoreListMembersRow struct {
@@ -301,7 +971,7 @@ func (q *Queries) GetChoreListMembers(ctx context.Context, choreListID string) (
 }
 
 const getChoreListWithoutUser = `-- name: GetChoreListWithoutUser :one
-SELECT cl.id, cl.created_at, cl.updated_at, cl.name
+SELECT cl.id, cl.created_at, cl.updated_at, cl.name, cl.timezone
 FROM chore_list cl
 WHERE cl.id = ?
 `
@@ -314,12 +984,13 @@ func (q *Queries) GetChoreListWithoutUser(ctx context.Context, id string) (Chore
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const getChoreListsByUser = `-- name: GetChoreListsByUser :many
-SELECT cl.id, cl.created_at, cl.updated_at, cl.name,
+SELECT cl.id, cl.created_at, cl.updated_at, cl.name, cl.timezone,
        (SELECT COUNT(*) FROM chore WHERE chore_list_id = cl.id AND NOT repeats_left = 0) AS chore_count,
        (SELECT COUNT(*) FROM chore_list_members WHERE chore_list_id = cl.id)             AS member_count
 FROM chore_list cl
@@ -333,6 +1004,7 @@ type GetChoreListsByUserRow struct {
 	CreatedAt   int64
 	UpdatedAt   int64
 	Name        string
+	Timezone    string
 	ChoreCount  int64
 	MemberCount int64
 }
@@ -351,6 +1023,7 @@ func (q *Queries) GetChoreListsByUser(ctx context.Context, userID string) ([]Get
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.Timezone,
 			&i.ChoreCount,
 			&i.MemberCount,
 		); err != nil {
@@ -368,10 +1041,9 @@ func (q *Queries) GetChoreListsByUser(ctx context.Context, userID string) ([]Get
 }
 
 const getChoresByList = `-- name: GetChoresByList :many
-SELECT id, name, interval, last_completion, snoozed_for, created_at, chore_list_id, created_by, repeats_left, chore_type, link
+SELECT id, name, interval, last_completion, snoozed_for, created_at, chore_list_id, created_by, repeats_left, chore_type, link, recurrence, version, assigned_to, assignment, auto_complete, effort
 FROM chore
 WHERE chore_list_id = ?
-  AND NOT repeats_left = 0
 ORDER BY name, id
 `
 
@@ -396,6 +1068,12 @@ func (q *Queries) GetChoresByList(ctx context.Context, choreListID string) ([]Ch
 			&i.RepeatsLeft,
 			&i.ChoreType,
 			&i.Link,
+			&i.Recurrence,
+			&i.Version,
+			&i.AssignedTo,
+			&i.Assignment,
+			&i.AutoComplete,
+			&i.Effort,
 		); err != nil {
 			return nil, err
 		}
@@ -410,6 +1088,66 @@ func (q *Queries) GetChoresByList(ctx context.Context, choreListID string) ([]Ch
 	return items, nil
 }
 
+const getLastChoreCompletion = `-- name: GetLastChoreCompletion :one
+SELECT CAST(COALESCE(MAX(occurred_at), 0) AS INTEGER) AS last_completion
+FROM chore_event
+WHERE chore_id = ?
+  AND event_type = 'complete'
+`
+
+func (q *Queries) GetLastChoreCompletion(ctx context.Context, choreID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLastChoreCompletion, choreID)
+	var last_completion int64
+	err := row.Scan(&last_completion)
+	return last_completion, err
+}
+
+const getUserFeed = `-- name: GetUserFeed :one
+SELECT token, user_id, created_at
+FROM user_feed
+WHERE user_id = ?
+`
+
+func (q *Queries) GetUserFeed(ctx context.Context, userID string) (UserFeed, error) {
+	row := q.db.QueryRowContext(ctx, getUserFeed, userID)
+	var i UserFeed
+	err := row.Scan(&i.Token, &i.UserID, &i.CreatedAt)
+	return i, err
+}
+
+const getUserFeedByToken = `-- name: GetUserFeedByToken :one
+SELECT token, user_id, created_at
+FROM user_feed
+WHERE token = ?
+`
+
+func (q *Queries) GetUserFeedByToken(ctx context.Context, token string) (UserFeed, error) {
+	row := q.db.QueryRowContext(ctx, getUserFeedByToken, token)
+	var i UserFeed
+	err := row.Scan(&i.Token, &i.UserID, &i.CreatedAt)
+	return i, err
+}
+
+const hasChoreCompletionOn = `-- name: HasChoreCompletionOn :one
+SELECT EXISTS(SELECT 1
+              FROM chore_event
+              WHERE chore_id = ?
+                AND event_type = 'complete'
+                AND occurred_at = ?) AS completed
+`
+
+type HasChoreCompletionOnParams struct {
+	ChoreID    string
+	OccurredAt int64
+}
+
+func (q *Queries) HasChoreCompletionOn(ctx context.Context, arg HasChoreCompletionOnParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, hasChoreCompletionOn, arg.ChoreID, arg.OccurredAt)
+	var completed int64
+	err := row.Scan(&completed)
+	return completed, err
+}
+
 const removeUserFromChoreList = `-- name: RemoveUserFromChoreList :exec
 DELETE
 FROM chore_list_members
@@ -427,19 +1165,72 @@ func (q *Queries) RemoveUserFromChoreList(ctx context.Context, arg RemoveUserFro
 	return err
 }
 
-const snoozeChore = `-- name: SnoozeChore :exec
+const resetChoreChecklist = `-- name: ResetChoreChecklist :exec
+UPDATE chore_checklist_item
+SET checked    = 0,
+    checked_by = NULL
+WHERE chore_id = ?
+`
+
+func (q *Queries) ResetChoreChecklist(ctx context.Context, choreID string) error {
+	_, err := q.db.ExecContext(ctx, resetChoreChecklist, choreID)
+	return err
+}
+
+const snoozeChore = `-- name: SnoozeChore :execrows
 UPDATE chore
-SET snoozed_for = ?
+SET snoozed_for = ?,
+    version     = version + 1
 WHERE id = ?
+  AND version = ?
 `
 
 type SnoozeChoreParams struct {
 	SnoozedFor int64
 	ID         string
+	Version    int64
+}
+
+func (q *Queries) SnoozeChore(ctx context.Context, arg SnoozeChoreParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, snoozeChore, arg.SnoozedFor, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unassignChoreListMember = `-- name: UnassignChoreListMember :exec
+UPDATE chore
+SET assigned_to = NULL
+WHERE chore_list_id = ?
+  AND assigned_to = ?
+`
+
+type UnassignChoreListMemberParams struct {
+	ChoreListID string
+	AssignedTo  sql.NullString
+}
+
+func (q *Queries) UnassignChoreListMember(ctx context.Context, arg UnassignChoreListMemberParams) error {
+	_, err := q.db.ExecContext(ctx, unassignChoreListMember, arg.ChoreListID, arg.AssignedTo)
+	return err
+}
+
+const uncompleteChore = `-- name: UncompleteChore :exec
+UPDATE chore
+SET last_completion = ?,
+    repeats_left    = CASE WHEN repeats_left >= 0 THEN repeats_left + 1 ELSE repeats_left END,
+    version         = version + 1
+WHERE id = ?
+`
+
+type UncompleteChoreParams struct {
+	LastCompletion int64
+	ID             string
 }
 
-func (q *Queries) SnoozeChore(ctx context.Context, arg SnoozeChoreParams) error {
-	_, err := q.db.ExecContext(ctx, snoozeChore, arg.SnoozedFor, arg.ID)
+func (q *Queries) UncompleteChore(ctx context.Context, arg UncompleteChoreParams) error {
+	_, err := q.db.ExecContext(ctx, uncompleteChore, arg.LastCompletion, arg.ID)
 	return err
 }
 
@@ -450,8 +1241,15 @@ SET name            = ?,
     repeats_left    = ?,
     snoozed_for     = ?,
     last_completion = ?,
-    link            = ?
-WHERE id = ? RETURNING id, name, interval, last_completion, snoozed_for, created_at, chore_list_id, created_by, repeats_left, chore_type, link
+    link            = ?,
+    recurrence      = ?,
+    assigned_to     = ?,
+    assignment      = ?,
+    auto_complete   = ?,
+    effort          = ?,
+    version         = version + 1
+WHERE id = ?
+  AND version = ? RETURNING id, name, interval, last_completion, snoozed_for, created_at, chore_list_id, created_by, repeats_left, chore_type, link, recurrence, version, assigned_to, assignment, auto_complete, effort
 `
 
 type UpdateChoreParams struct {
@@ -461,7 +1259,13 @@ type UpdateChoreParams struct {
 	SnoozedFor     int64
 	LastCompletion int64
 	Link           sql.NullString
+	Recurrence     string
+	AssignedTo     sql.NullString
+	Assignment     string
+	AutoComplete   int64
+	Effort         int64
 	ID             string
+	Version        int64
 }
 
 func (q *Queries) UpdateChore(ctx context.Context, arg UpdateChoreParams) (Chore, error) {
@@ -472,7 +1276,13 @@ func (q *Queries) UpdateChore(ctx context.Context, arg UpdateChoreParams) (Chore
 		arg.SnoozedFor,
 		arg.LastCompletion,
 		arg.Link,
+		arg.Recurrence,
+		arg.AssignedTo,
+		arg.Assignment,
+		arg.AutoComplete,
+		arg.Effort,
 		arg.ID,
+		arg.Version,
 	)
 	var i Chore
 	err := row.Scan(
@@ -487,6 +1297,12 @@ func (q *Queries) UpdateChore(ctx context.Context, arg UpdateChoreParams) (Chore
 		&i.RepeatsLeft,
 		&i.ChoreType,
 		&i.Link,
+		&i.Recurrence,
+		&i.Version,
+		&i.AssignedTo,
+		&i.Assignment,
+		&i.AutoComplete,
+		&i.Effort,
 	)
 	return i, err
 }
@@ -496,7 +1312,7 @@ UPDATE chore_list
 SET name       = ?,
     updated_at = ?
 WHERE id = ?
-  AND id IN (SELECT chore_list_id FROM chore_list_members WHERE user_id = ?) RETURNING id, created_at, updated_at, name
+  AND id IN (SELECT chore_list_id FROM chore_list_members WHERE user_id = ?) RETURNING id, created_at, updated_at, name, timezone
 `
 
 type UpdateChoreListParams struct {
@@ -519,6 +1335,62 @@ func (q *Queries) UpdateChoreList(ctx context.Context, arg UpdateChoreListParams
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const upsertChoreListFeed = `-- name: UpsertChoreListFeed :one
+INSERT INTO chore_list_feed
+    (token, chore_list_id, user_id, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (chore_list_id, user_id) DO UPDATE SET token      = excluded.token,
+                                                   created_at = excluded.created_at
+RETURNING token, chore_list_id, user_id, created_at
+`
+
+type UpsertChoreListFeedParams struct {
+	Token       string
+	ChoreListID string
+	UserID      string
+	CreatedAt   int64
+}
+
+func (q *Queries) UpsertChoreListFeed(ctx context.Context, arg UpsertChoreListFeedParams) (ChoreListFeed, error) {
+	row := q.db.QueryRowContext(ctx, upsertChoreListFeed,
+		arg.Token,
+		arg.ChoreListID,
+		arg.UserID,
+		arg.CreatedAt,
 	)
+	var i ChoreListFeed
+	err := row.Scan(
+		&i.Token,
+		&i.ChoreListID,
+		&i.UserID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertUserFeed = `-- name: UpsertUserFeed :one
+INSERT INTO user_feed
+    (token, user_id, created_at)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET token      = excluded.token,
+                                    created_at = excluded.created_at
+RETURNING token, user_id, created_at
+`
+
+type UpsertUserFeedParams struct {
+	Token     string
+	UserID    string
+	CreatedAt int64
+}
+
+func (q *Queries) UpsertUserFeed(ctx context.Context, arg UpsertUserFeedParams) (UserFeed, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserFeed, arg.Token, arg.UserID, arg.CreatedAt)
+	var i UserFeed
+	err := row.Scan(&i.Token, &i.UserID, &i.CreatedAt)
 	return i, err
 }
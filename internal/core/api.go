@@ -0,0 +1,519 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+type APIChecklistItem struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Checked bool   `json:"checked"`
+}
+
+// APIChore is the JSON representation of a chore, durations are formatted like the interval of the Input.
+type APIChore struct {
+	ID             string             `json:"id"`
+	ChoreListID    string             `json:"choreListID"`
+	Name           string             `json:"name"`
+	ChoreType      string             `json:"choreType"`
+	Link           string             `json:"link"`
+	CreatedAt      date.Date          `json:"createdAt"`
+	Interval       string             `json:"interval"`
+	Recurrence     string             `json:"recurrence"`
+	RepeatsLeft    int64              `json:"repeatsLeft"`
+	LastCompletion *date.Date         `json:"lastCompletion"`
+	NextCompletion date.Date          `json:"nextCompletion"`
+	SnoozedFor     string             `json:"snoozedFor"`
+	AssignedTo     string             `json:"assignedTo"`
+	Assignment     string             `json:"assignment"`
+	Checklist      []APIChecklistItem `json:"checklist"`
+	AutoComplete   bool               `json:"autoComplete"`
+	Prerequisites  []string           `json:"prerequisites"`
+	Effort         int64              `json:"effort"`
+	Finished       bool               `json:"finished"`
+	Version        int64              `json:"version"`
+}
+
+func NewAPIChore(c Chore) APIChore {
+	checklist := make([]APIChecklistItem, len(c.Checklist))
+	for i, item := range c.Checklist {
+		checklist[i] = APIChecklistItem{ID: item.ID, Name: item.Name, Checked: item.Checked}
+	}
+	chore := APIChore{
+		ID:             c.ID,
+		ChoreListID:    c.ChoreListID,
+		Name:           c.Name,
+		ChoreType:      c.ChoreType,
+		Link:           c.Link,
+		CreatedAt:      c.CreatedAt,
+		Interval:       c.Interval.String(),
+		Recurrence:     c.Recurrence.String(),
+		RepeatsLeft:    c.RepeatsLeft,
+		NextCompletion: c.NextCompletion(),
+		SnoozedFor:     c.SnoozedFor.String(),
+		AssignedTo:     c.AssignedTo,
+		Assignment:     c.Assignment,
+		Checklist:      checklist,
+		AutoComplete:   c.AutoComplete,
+		Prerequisites:  c.Prerequisites,
+		Effort:         c.Effort,
+		Finished:       c.IsFinished(),
+		Version:        c.Version,
+	}
+	if chore.Prerequisites == nil {
+		chore.Prerequisites = []string{}
+	}
+	if !c.LastCompletion.IsZero() {
+		chore.LastCompletion = &c.LastCompletion
+	}
+	return chore
+}
+
+func NewAPIChores(chores []Chore) []APIChore {
+	apiChores := make([]APIChore, len(chores))
+	for i, c := range chores {
+		apiChores[i] = NewAPIChore(c)
+	}
+	return apiChores
+}
+
+type APIMember struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+func NewAPIMembers(rows []cdb.GetChoreListMembersRow) []APIMember {
+	members := make([]APIMember, len(rows))
+	for i, m := range rows {
+		members[i] = APIMember{ID: m.ID, DisplayName: m.DisplayName}
+	}
+	return members
+}
+
+type APIChoreList struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Timezone    string      `json:"timezone,omitempty"`
+	ChoreCount  int64       `json:"choreCount,omitempty"`
+	MemberCount int64       `json:"memberCount,omitempty"`
+	Members     []APIMember `json:"members,omitempty"`
+	Chores      []APIChore  `json:"chores,omitempty"`
+}
+
+type APIChoreListInput struct {
+	Name     string `json:"name"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+func (i *APIChoreListInput) FromForm(r *http.Request) error {
+	i.Name = r.FormValue("name")
+	i.Timezone = r.FormValue("timezone")
+	return nil
+}
+
+// APIEvent is a completion of a chore.
+type APIEvent struct {
+	ID         string    `json:"id"`
+	EventType  string    `json:"eventType"`
+	OccurredAt date.Date `json:"occurredAt"`
+	CreatedBy  string    `json:"createdBy"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate, private")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// apiVersion is the version a write through the API is based on, an If-Match header takes precedence over the version
+// in the body.
+func apiVersion(r *http.Request, body int64) (int64, error) {
+	version, err := RequestVersion(r)
+	if err != nil || version != 0 {
+		return version, err
+	}
+	return body, nil
+}
+
+// getScopedChore gets the chore if the session may access its list.
+func getScopedChore(ctx context.Context, db *sql.DB, userID, id string) (*Chore, error) {
+	chore, err := Get(ctx, db, userID, id)
+	if err != nil {
+		return nil, srvu.Err(http.StatusNotFound, err)
+	}
+	if err := authorizeList(ctx, chore.ChoreListID); err != nil {
+		return nil, err
+	}
+	return chore, nil
+}
+
+// getScopedList gets the list if the user is a member and the session may access it.
+func getScopedList(ctx context.Context, db *sql.DB, userID, choreListID string) (cdb.ChoreList, error) {
+	if err := authorizeList(ctx, choreListID); err != nil {
+		return cdb.ChoreList{}, err
+	}
+	cl, err := cdb.New(db).GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: choreListID, UserID: userID})
+	if err != nil {
+		return cdb.ChoreList{}, srvu.Err(http.StatusNotFound, fmt.Errorf("getting chore list %s: %w", choreListID, err))
+	}
+	return cl, nil
+}
+
+// getAPIChore gets the chore with its checklist and prerequisites.
+func getAPIChore(ctx context.Context, db *sql.DB, userID, id string) (*Chore, error) {
+	chore, err := getScopedChore(ctx, db, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	chores, err := GetListChores(ctx, db, chore.ChoreListID)
+	if err != nil {
+		return nil, srvu.Err(http.StatusInternalServerError, err)
+	}
+	for _, c := range chores {
+		if c.ID == id {
+			return &c, nil
+		}
+	}
+	return nil, srvu.Err(http.StatusNotFound, fmt.Errorf("chore %s not found", id))
+}
+
+func writeAPIChore(ctx context.Context, w http.ResponseWriter, db *sql.DB, status int, userID, id string) error {
+	chore, err := getAPIChore(ctx, db, userID, id)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", chore.ETag())
+	return writeJSON(w, status, NewAPIChore(*chore))
+}
+
+func APIChoreListsHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		session := auth.MustGetSession(ctx)
+		rows, err := cdb.New(db).GetChoreListsByUser(ctx, session.UserID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		lists := make([]APIChoreList, 0, len(rows))
+		for _, row := range rows {
+			if session.Scope.AllowsList(row.ID) {
+				lists = append(lists, APIChoreList{ID: row.ID, Name: row.Name, Timezone: row.Timezone, ChoreCount: row.ChoreCount, MemberCount: row.MemberCount})
+			}
+		}
+		return writeJSON(w, http.StatusOK, lists)
+	})
+}
+
+func APIChoreListHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		choreListID := r.PathValue("choreListID")
+		cl, err := getScopedList(ctx, db, userID, choreListID)
+		if err != nil {
+			return err
+		}
+		members, err := cdb.New(db).GetChoreListMembers(ctx, choreListID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		chores, err := GetListChores(ctx, db, choreListID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		return writeJSON(w, http.StatusOK, APIChoreList{
+			ID:       cl.ID,
+			Name:     cl.Name,
+			Timezone: cl.Timezone,
+			Members:  NewAPIMembers(members),
+			Chores:   NewAPIChores(chores),
+		})
+	})
+}
+
+func APIChoreListCreateHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		session := auth.MustGetSession(ctx)
+		if !session.Scope.AllowsAllLists() {
+			return srvu.Err(http.StatusForbidden, fmt.Errorf("token is restricted to specific chore lists"))
+		}
+		var inp APIChoreListInput
+		if err := srvu.Decode(r, &inp, false); err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
+		}
+		cl, err := CreateChoreList(ctx, db, session.UserID, inp.Name, inp.Timezone)
+		if err != nil {
+			return writeErr(err, "creating the chore list")
+		}
+		w.Header().Set("Location", fmt.Sprintf("/api/chore-lists/%s", cl.ID))
+		return writeJSON(w, http.StatusCreated, APIChoreList{ID: cl.ID, Name: cl.Name, Timezone: cl.Timezone})
+	})
+}
+
+func APIChoreListUpdateHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		var inp APIChoreListInput
+		if err := srvu.Decode(r, &inp, false); err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
+		}
+		if err := authorizeList(ctx, r.PathValue("choreListID")); err != nil {
+			return err
+		}
+		cl, err := UpdateChoreList(ctx, db, userID, r.PathValue("choreListID"), inp.Name, inp.Timezone)
+		if errors.Is(err, sql.ErrNoRows) {
+			return srvu.Err(http.StatusNotFound, err)
+		} else if err != nil {
+			return writeErr(err, "updating the chore list")
+		}
+		return writeJSON(w, http.StatusOK, APIChoreList{ID: cl.ID, Name: cl.Name, Timezone: cl.Timezone})
+	})
+}
+
+func APIChoreListMembersHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		choreListID := r.PathValue("choreListID")
+		if _, err := getScopedList(ctx, db, userID, choreListID); err != nil {
+			return err
+		}
+		members, err := cdb.New(db).GetChoreListMembers(ctx, choreListID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		return writeJSON(w, http.StatusOK, NewAPIMembers(members))
+	})
+}
+
+// APIChoreListLeaveHandler removes a member from the list, members can only remove themselves.
+func APIChoreListLeaveHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		if r.PathValue("userID") != userID {
+			return srvu.Err(http.StatusForbidden, fmt.Errorf("members can only remove themselves"))
+		}
+		if err := authorizeList(ctx, r.PathValue("choreListID")); err != nil {
+			return err
+		}
+		if err := LeaveChoreList(ctx, db, userID, r.PathValue("choreListID")); err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+func APIChoreListChoresHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		choreListID := r.PathValue("choreListID")
+		if _, err := getScopedList(ctx, db, userID, choreListID); err != nil {
+			return err
+		}
+		chores, err := GetListChores(ctx, db, choreListID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		return writeJSON(w, http.StatusOK, NewAPIChores(chores))
+	})
+}
+
+func APIChoreHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		return writeAPIChore(ctx, w, db, http.StatusOK, userID, r.PathValue("id"))
+	})
+}
+
+func APIChoreCreateHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		var inp Input
+		if err := srvu.Decode(r, &inp, false); err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
+		}
+		if _, err := getScopedList(ctx, db, userID, inp.ChoreListID); err != nil {
+			return err
+		}
+		chore, err := Create(ctx, db, date.Today(), userID, inp)
+		if err != nil {
+			return writeErr(err, "creating the chore")
+		}
+		w.Header().Set("Location", fmt.Sprintf("/api/chores/%s", chore.ID))
+		return writeAPIChore(ctx, w, db, http.StatusCreated, userID, chore.ID)
+	})
+}
+
+func APIChoreUpdateHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		var inp Input
+		if err := srvu.Decode(r, &inp, false); err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
+		}
+		version, err := apiVersion(r, inp.Version)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		inp.Version = version
+		chore, err := getScopedChore(ctx, db, userID, r.PathValue("id"))
+		if err != nil {
+			return err
+		}
+		if _, err := Update(ctx, db, chore, inp); err != nil {
+			return writeErr(err, "updating the chore")
+		}
+		return writeAPIChore(ctx, w, db, http.StatusOK, userID, chore.ID)
+	})
+}
+
+func APIChoreDeleteHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		chore, err := getScopedChore(ctx, db, userID, r.PathValue("id"))
+		if err != nil {
+			return err
+		}
+		if err := Delete(ctx, db, chore.ID); err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+func APIChoreCompletionsHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		if _, err := getScopedChore(ctx, db, userID, r.PathValue("id")); err != nil {
+			return err
+		}
+		events, err := GetEvents(ctx, db, userID, r.PathValue("id"))
+		if err != nil {
+			return srvu.Err(http.StatusNotFound, err)
+		}
+		completions := make([]APIEvent, 0, len(events))
+		for _, e := range events {
+			if e.IsComplete() {
+				completions = append(completions, APIEvent{ID: e.ID, EventType: e.EventType, OccurredAt: e.OccurredAt, CreatedBy: e.CreatedBy})
+			}
+		}
+		return writeJSON(w, http.StatusOK, completions)
+	})
+}
+
+func APIChoreCompleteHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		id := r.PathValue("id")
+		var inp CompletionInput
+		if err := srvu.Decode(r, &inp, true); err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
+		}
+		version, err := RequestVersion(r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		if _, err := getScopedChore(ctx, db, userID, id); err != nil {
+			return err
+		}
+		if err := Complete(ctx, db, userID, id, inp.CompletedAt, version); err != nil {
+			return writeErr(err, "completing the chore")
+		}
+		return writeAPIChore(ctx, w, db, http.StatusOK, userID, id)
+	})
+}
+
+func APIChoreUncompleteHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		id := r.PathValue("id")
+		if _, err := getScopedChore(ctx, db, userID, id); err != nil {
+			return err
+		}
+		if err := Uncomplete(ctx, db, userID, id, r.PathValue("eventID")); errors.Is(err, sql.ErrNoRows) {
+			return srvu.Err(http.StatusNotFound, err)
+		} else if err != nil {
+			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("undoing completion: %w", err))
+		}
+		return writeAPIChore(ctx, w, db, http.StatusOK, userID, id)
+	})
+}
+
+func APIChoreSnoozeHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		id := r.PathValue("id")
+		today := date.Today()
+		var inp SnoozeInput
+		if err := srvu.Decode(r, &inp, true); err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
+		}
+		snoozeFor, err := inp.SnoozeFor(today)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		version, err := RequestVersion(r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		if _, err := getScopedChore(ctx, db, userID, id); err != nil {
+			return err
+		}
+		if err := Snooze(ctx, db, today, userID, id, snoozeFor, version); err != nil {
+			return writeErr(err, "snoozing the chore")
+		}
+		return writeAPIChore(ctx, w, db, http.StatusOK, userID, id)
+	})
+}
+
+func APIChoreExpediteHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		id := r.PathValue("id")
+		version, err := RequestVersion(r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		if _, err := getScopedChore(ctx, db, userID, id); err != nil {
+			return err
+		}
+		if err := Expedite(ctx, db, date.Today(), userID, id, version); err != nil {
+			return writeErr(err, "expediting the chore")
+		}
+		return writeAPIChore(ctx, w, db, http.StatusOK, userID, id)
+	})
+}
+
+// APIMux is the JSON API, it is authenticated by the session or an API token.
+func APIMux(db *sql.DB) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/chore-lists", APIChoreListsHandler(db))
+	mux.Handle("POST /api/chore-lists", APIChoreListCreateHandler(db))
+	mux.Handle("GET /api/chore-lists/{choreListID}", APIChoreListHandler(db))
+	mux.Handle("PUT /api/chore-lists/{choreListID}", APIChoreListUpdateHandler(db))
+	mux.Handle("GET /api/chore-lists/{choreListID}/export", APIChoreListExportHandler(db))
+	mux.Handle("POST /api/chore-lists/import", APIChoreListImportHandler(db))
+	mux.Handle("GET /api/chore-lists/{choreListID}/members", APIChoreListMembersHandler(db))
+	mux.Handle("DELETE /api/chore-lists/{choreListID}/members/{userID}", APIChoreListLeaveHandler(db))
+	mux.Handle("GET /api/chore-lists/{choreListID}/chores", APIChoreListChoresHandler(db))
+	mux.Handle("POST /api/chores", APIChoreCreateHandler(db))
+	mux.Handle("GET /api/chores/{id}", APIChoreHandler(db))
+	mux.Handle("PUT /api/chores/{id}", APIChoreUpdateHandler(db))
+	mux.Handle("DELETE /api/chores/{id}", APIChoreDeleteHandler(db))
+	mux.Handle("GET /api/chores/{id}/completions", APIChoreCompletionsHandler(db))
+	mux.Handle("POST /api/chores/{id}/completions", APIChoreCompleteHandler(db))
+	mux.Handle("DELETE /api/chores/{id}/completions/{eventID}", APIChoreUncompleteHandler(db))
+	mux.Handle("POST /api/chores/{id}/snoozes", APIChoreSnoozeHandler(db))
+	mux.Handle("POST /api/chores/{id}/expedites", APIChoreExpediteHandler(db))
+	return mux
+}
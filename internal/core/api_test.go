@@ -0,0 +1,136 @@
+package core_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func apiReq(ctx context.Context, client *Client, tok *ClientToken, method, uri string, body any) *ChoreReq {
+	var req *ChoreReq
+	if body == nil {
+		req = NewChoreReq(ctx, client).Auth(tok).Method(method, uri, nil)
+	} else {
+		req = NewChoreReq(ctx, client).Auth(tok).Method(method, uri, strings.NewReader(string(Must(json.Marshal(body)))))
+	}
+	return req.Header("Content-Type", "application/json")
+}
+
+func decodeJSON[T any](res *http.Response, err error) (T, error) {
+	var v T
+	if err != nil {
+		return v, err
+	}
+	defer res.Body.Close()
+	return v, json.NewDecoder(res.Body).Decode(&v)
+}
+
+func TestAPI(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	if _, err := apiReq(ctx, client, nil, "GET", "/api/chore-lists", nil).DoAndExp(http.StatusUnauthorized); err != nil {
+		t.Fatalf("expected unauthenticated requests to be rejected: %s", err)
+	}
+	cl := Must(decodeJSON[core.APIChoreList](apiReq(ctx, client, tok, "POST", "/api/chore-lists", map[string]string{"name": "home"}).DoAndExp(http.StatusCreated)))
+	if cl.ID == "" || cl.Name != "home" {
+		t.Fatalf("unexpected chore list: %+v", cl)
+	}
+	if _, err := apiReq(ctx, client, tok, "POST", "/api/chore-lists", map[string]string{"name": ""}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected an empty name to be rejected: %s", err)
+	}
+	renamed := Must(decodeJSON[core.APIChoreList](apiReq(ctx, client, tok, "PUT", "/api/chore-lists/"+cl.ID, map[string]string{"name": "house"}).DoAndExp(http.StatusOK)))
+	if renamed.Name != "house" {
+		t.Fatalf("expected the list to be renamed, got %+v", renamed)
+	}
+	lists := Must(decodeJSON[[]core.APIChoreList](apiReq(ctx, client, tok, "GET", "/api/chore-lists", nil).DoAndExp(http.StatusOK)))
+	if len(lists) != 1 || lists[0].ID != cl.ID || lists[0].MemberCount != 1 {
+		t.Fatalf("unexpected chore lists: %+v", lists)
+	}
+	away := Must(decodeJSON[core.APIChoreList](apiReq(ctx, client, tok, "POST", "/api/chore-lists", map[string]string{"name": "away", "timezone": "Pacific/Auckland"}).DoAndExp(http.StatusCreated)))
+	if away.Timezone != "Pacific/Auckland" {
+		t.Fatalf("expected the list to be created in the time zone, got %+v", away)
+	}
+	if _, err := apiReq(ctx, client, tok, "POST", "/api/chore-lists", map[string]string{"name": "mars", "timezone": "Mars/Olympus"}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected unknown time zones to be rejected: %s", err)
+	}
+
+	chore := Must(decodeJSON[core.APIChore](apiReq(ctx, client, tok, "POST", "/api/chores", map[string]any{
+		"name":        "water plants",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.ID,
+		"interval":    "3d",
+		"checklist":   []string{"kitchen", "balcony"},
+		"effort":      3,
+	}).DoAndExp(http.StatusCreated)))
+	if chore.ID == "" || chore.Interval != "3d" || len(chore.Checklist) != 2 || chore.Effort != 3 || chore.LastCompletion != nil {
+		t.Fatalf("unexpected chore: %+v", chore)
+	}
+	if _, err := apiReq(ctx, client, tok, "POST", "/api/chores", map[string]any{
+		"name":        "invalid",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.ID,
+	}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected an interval chore without interval to be rejected: %s", err)
+	}
+
+	today := date.Today()
+	snoozed := Must(decodeJSON[core.APIChore](apiReq(ctx, client, tok, "POST", fmt.Sprintf("/api/chores/%s/snoozes", chore.ID), map[string]any{
+		"duration": "2d",
+	}).DoAndExp(http.StatusOK)))
+	if snoozed.NextCompletion != today.Add(2*date.Day) || snoozed.SnoozedFor != "2d" {
+		t.Fatalf("expected the chore to be snoozed, got %+v", snoozed)
+	}
+	completed := Must(decodeJSON[core.APIChore](apiReq(ctx, client, tok, "POST", fmt.Sprintf("/api/chores/%s/completions", chore.ID), map[string]any{
+		"completed_at": today,
+	}).DoAndExp(http.StatusOK)))
+	if completed.LastCompletion == nil || *completed.LastCompletion != today || completed.NextCompletion != today.Add(3*date.Day) {
+		t.Fatalf("expected the chore to be completed today, got %+v", completed)
+	}
+	completions := Must(decodeJSON[[]core.APIEvent](apiReq(ctx, client, tok, "GET", fmt.Sprintf("/api/chores/%s/completions", chore.ID), nil).DoAndExp(http.StatusOK)))
+	if len(completions) != 1 || completions[0].OccurredAt != today {
+		t.Fatalf("unexpected completions: %+v", completions)
+	}
+
+	if _, err := apiReq(ctx, client, tok, "PUT", "/api/chores/"+chore.ID, map[string]any{
+		"name":      "water all plants",
+		"choreType": core.ChoreTypeInterval,
+		"interval":  "1w",
+	}).Header("If-Match", strconv.Quote(strconv.FormatInt(chore.Version, 10))).DoAndExp(http.StatusConflict); err != nil {
+		t.Fatalf("expected a stale version to be rejected: %s", err)
+	}
+	updated := Must(decodeJSON[core.APIChore](apiReq(ctx, client, tok, "PUT", "/api/chores/"+chore.ID, map[string]any{
+		"name":      "water all plants",
+		"choreType": core.ChoreTypeInterval,
+		"interval":  "1w",
+		"version":   completed.Version,
+	}).DoAndExp(http.StatusOK)))
+	if updated.Name != "water all plants" || updated.Interval != "1w" || updated.LastCompletion == nil {
+		t.Fatalf("unexpected updated chore: %+v", updated)
+	}
+
+	if _, err := apiReq(ctx, client, tok, "DELETE", fmt.Sprintf("/api/chores/%s/completions/%s", chore.ID, completions[0].ID), nil).DoAndExp(http.StatusOK); err != nil {
+		t.Fatalf("undoing the completion: %s", err)
+	}
+	chores := Must(decodeJSON[[]core.APIChore](apiReq(ctx, client, tok, "GET", fmt.Sprintf("/api/chore-lists/%s/chores", cl.ID), nil).DoAndExp(http.StatusOK)))
+	if len(chores) != 1 || chores[0].LastCompletion != nil {
+		t.Fatalf("expected the completion to be undone, got %+v", chores)
+	}
+
+	if _, err := apiReq(ctx, client, tok, "DELETE", fmt.Sprintf("/api/chore-lists/%s/members/other", cl.ID), nil).DoAndExp(http.StatusForbidden); err != nil {
+		t.Fatalf("expected removing another member to be forbidden: %s", err)
+	}
+	if _, err := apiReq(ctx, client, tok, "DELETE", "/api/chores/"+chore.ID, nil).DoAndExp(http.StatusNoContent); err != nil {
+		t.Fatalf("deleting the chore: %s", err)
+	}
+	if _, err := apiReq(ctx, client, tok, "GET", "/api/chores/"+chore.ID, nil).DoAndExp(http.StatusNotFound); err != nil {
+		t.Fatalf("expected the deleted chore to be gone: %s", err)
+	}
+}
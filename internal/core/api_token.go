@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/chore-tracker/pkg/httpu"
+	"github.com/SimonSchneider/goslu/sid"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+// apiTokenPrefix makes tokens recognisable, e.g. by secret scanners.
+const apiTokenPrefix = "chore_"
+
+const apiTokenLength = 40
+
+// HashAPIToken is how tokens are stored, only the hash of a token is persisted so a leaked database doesn't leak
+// usable tokens.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type APITokenChoreList struct {
+	ID   string
+	Name string
+}
+
+type APIToken struct {
+	ID         string
+	Name       string
+	Write      bool
+	AllLists   bool
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ChoreLists []APITokenChoreList
+}
+
+func (t APIToken) Permission() string {
+	if t.Write {
+		return "read & write"
+	}
+	return "read only"
+}
+
+type APITokenInput struct {
+	Name         string
+	ChoreListIDs []string
+	Write        bool
+}
+
+func (i *APITokenInput) FromForm(r *http.Request) error {
+	i.Name = strings.TrimSpace(r.FormValue("name"))
+	i.Write = r.FormValue("permission") == "write"
+	i.ChoreListIDs = nil
+	for _, id := range r.Form["choreListIDs"] {
+		if id != "" && !contains(i.ChoreListIDs, id) {
+			i.ChoreListIDs = append(i.ChoreListIDs, id)
+		}
+	}
+	return nil
+}
+
+// CreateAPIToken creates a token for the user, the returned token is the only time it is available in plain text.
+func CreateAPIToken(ctx context.Context, db *sql.DB, userID string, inp APITokenInput) (string, error) {
+	if inp.Name == "" {
+		return "", fmt.Errorf("%w: missing name", ErrInvalidInput)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	for _, id := range inp.ChoreListIDs {
+		if _, err := q.GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: id, UserID: userID}); err != nil {
+			return "", fmt.Errorf("%w: chore list %s is not one of the user's lists", ErrInvalidInput, id)
+		}
+	}
+	secret, err := sid.NewString(apiTokenLength)
+	if err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	token := apiTokenPrefix + secret
+	t, err := q.CreateAPIToken(ctx, cdb.CreateAPITokenParams{
+		ID:        NewId(),
+		UserID:    userID,
+		Name:      inp.Name,
+		TokenHash: HashAPIToken(token),
+		Write:     boolToInt(inp.Write),
+		AllLists:  boolToInt(len(inp.ChoreListIDs) == 0),
+		CreatedAt: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating token: %w", err)
+	}
+	for _, id := range inp.ChoreListIDs {
+		if err := q.CreateAPITokenChoreList(ctx, cdb.CreateAPITokenChoreListParams{ApiTokenID: t.ID, ChoreListID: id}); err != nil {
+			return "", fmt.Errorf("scoping token to chore list %s: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("committing tx: %w", err)
+	}
+	return token, nil
+}
+
+// RevokeAPIToken deletes the token, its chore lists are deleted with it by the foreign key.
+func RevokeAPIToken(ctx context.Context, db *sql.DB, userID, id string) error {
+	n, err := cdb.New(db).DeleteAPIToken(ctx, cdb.DeleteAPITokenParams{ID: id, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("deleting token: %w", err)
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func GetAPITokens(ctx context.Context, db cdb.DBTX, userID string) ([]APIToken, error) {
+	q := cdb.New(db)
+	rows, err := q.GetAPITokensByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting tokens: %w", err)
+	}
+	lists, err := q.GetAPITokenChoreLists(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting token chore lists: %w", err)
+	}
+	byToken := make(map[string][]APITokenChoreList)
+	for _, l := range lists {
+		byToken[l.ApiTokenID] = append(byToken[l.ApiTokenID], APITokenChoreList{ID: l.ID, Name: l.Name})
+	}
+	tokens := make([]APIToken, len(rows))
+	for i, row := range rows {
+		tokens[i] = APIToken{
+			ID:         row.ID,
+			Name:       row.Name,
+			Write:      row.Write != 0,
+			AllLists:   row.AllLists != 0,
+			CreatedAt:  time.UnixMilli(row.CreatedAt),
+			ChoreLists: byToken[row.ID],
+		}
+		if row.LastUsedAt.Valid {
+			tokens[i].LastUsedAt = time.UnixMilli(row.LastUsedAt.Int64)
+		}
+	}
+	return tokens, nil
+}
+
+// APITokenStore verifies API tokens and records when they were last used.
+type APITokenStore struct {
+	DB *sql.DB
+}
+
+func (s *APITokenStore) VerifyAPIToken(ctx context.Context, token string, now time.Time) (auth.Session, bool, error) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return auth.Session{}, false, nil
+	}
+	q := cdb.New(s.DB)
+	t, err := q.GetAPITokenByHash(ctx, HashAPIToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.Session{}, false, nil
+	} else if err != nil {
+		return auth.Session{}, false, fmt.Errorf("getting token: %w", err)
+	}
+	lists, err := q.GetAPITokenChoreListIDs(ctx, t.ID)
+	if err != nil {
+		return auth.Session{}, false, fmt.Errorf("getting token chore lists: %w", err)
+	}
+	scope := &auth.Scope{TokenID: t.ID, AllLists: t.AllLists != 0, ChoreListIDs: lists, Write: t.Write != 0}
+	if err := q.TouchAPIToken(ctx, cdb.TouchAPITokenParams{LastUsedAt: sql.NullInt64{Int64: now.UnixMilli(), Valid: true}, ID: t.ID}); err != nil {
+		return auth.Session{}, false, fmt.Errorf("updating last used: %w", err)
+	}
+	return auth.Session{UserID: t.UserID, Token: t.ID, Scope: scope}, true, nil
+}
+
+// authorizeList checks that the session may access the list, sessions of API tokens can be restricted to some lists.
+func authorizeList(ctx context.Context, choreListID string) error {
+	if !auth.MustGetSession(ctx).Scope.AllowsList(choreListID) {
+		return srvu.Err(http.StatusForbidden, fmt.Errorf("token is not allowed to access chore list %s", choreListID))
+	}
+	return nil
+}
+
+func APITokenCreateHandler(view *View, db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		var inp APITokenInput
+		if err := srvu.Decode(r, &inp, false); err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
+		}
+		token, err := CreateAPIToken(ctx, db, userID, inp)
+		if err != nil {
+			return writeErr(err, "creating the token")
+		}
+		return renderSettingsPage(ctx, w, r, view, db, userID, token)
+	})
+}
+
+func APITokenRevokeHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		if err := RevokeAPIToken(ctx, db, userID, r.PathValue("tokenID")); errors.Is(err, sql.ErrNoRows) {
+			return srvu.Err(http.StatusNotFound, err)
+		} else if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		httpu.RedirectToNext(w, r, "/settings")
+		return nil
+	})
+}
@@ -0,0 +1,98 @@
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+)
+
+func createAPIToken(ctx context.Context, client *Client, tok *ClientToken, form map[string]string) string {
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", "/settings/tokens/", form).DoAndExp(http.StatusOK))
+	return GetTpl[core.SettingsView](client.tmpl, "settings.page.gohtml").CreatedToken
+}
+
+func bearerReq(ctx context.Context, client *Client, token, method, uri string, body any) *ChoreReq {
+	return apiReq(ctx, client, nil, method, uri, body).Header("Authorization", "Bearer "+token)
+}
+
+func TestAPITokens(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	home := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	work := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "work"}))
+	readToken := createAPIToken(ctx, client, tok, map[string]string{"name": "calendar", "choreListIDs": home.List.ID})
+	writeToken := createAPIToken(ctx, client, tok, map[string]string{"name": "automation", "permission": "write"})
+	if readToken == "" || writeToken == "" || readToken == writeToken {
+		t.Fatalf("expected two distinct tokens, got %q and %q", readToken, writeToken)
+	}
+	if _, err := client.DBQuery().GetAPITokenByHash(ctx, core.HashAPIToken(readToken)); err != nil {
+		t.Fatalf("expected the token to be stored hashed: %s", err)
+	}
+
+	lists := Must(decodeJSON[[]core.APIChoreList](bearerReq(ctx, client, readToken, "GET", "/api/chore-lists", nil).DoAndExp(http.StatusOK)))
+	if len(lists) != 1 || lists[0].ID != home.List.ID {
+		t.Fatalf("expected the read token to only see its list, got %+v", lists)
+	}
+	if _, err := bearerReq(ctx, client, readToken, "GET", fmt.Sprintf("/api/chore-lists/%s", work.List.ID), nil).DoAndExp(http.StatusForbidden); err != nil {
+		t.Fatalf("expected other lists to be forbidden: %s", err)
+	}
+	if _, err := bearerReq(ctx, client, readToken, "POST", "/api/chores", map[string]any{
+		"name": "dishes", "choreType": core.ChoreTypeInterval, "choreListID": home.List.ID, "interval": "1d",
+	}).DoAndExp(http.StatusForbidden); err != nil {
+		t.Fatalf("expected writes with a read token to be forbidden: %s", err)
+	}
+	chore := Must(decodeJSON[core.APIChore](bearerReq(ctx, client, writeToken, "POST", "/api/chores", map[string]any{
+		"name": "dishes", "choreType": core.ChoreTypeInterval, "choreListID": work.List.ID, "interval": "1d",
+	}).DoAndExp(http.StatusCreated)))
+	if _, err := bearerReq(ctx, client, readToken, "GET", "/api/chores/"+chore.ID, nil).DoAndExp(http.StatusForbidden); err != nil {
+		t.Fatalf("expected chores of other lists to be forbidden: %s", err)
+	}
+	if _, err := bearerReq(ctx, client, "chore_invalid", "GET", "/api/chore-lists", nil).DoAndExp(http.StatusUnauthorized); err != nil {
+		t.Fatalf("expected an invalid token to be rejected: %s", err)
+	}
+
+	if _, err := NewChoreReq(ctx, client).Get(fmt.Sprintf("/api/chore-lists/%s/ics?token=%s", home.List.ID, readToken)).DoAndExp(http.StatusOK); err != nil {
+		t.Fatalf("expected the calendar to be served with a token: %s", err)
+	}
+	if _, err := NewChoreReq(ctx, client).Get(fmt.Sprintf("/api/chore-lists/%s/ics?token=%s", work.List.ID, readToken)).DoAndExp(http.StatusForbidden); err != nil {
+		t.Fatalf("expected the calendar of other lists to be forbidden: %s", err)
+	}
+	if _, err := NewChoreReq(ctx, client).Get(fmt.Sprintf("/api/chore-lists/%s/ics?token=%s", home.List.ID, home.List.ID)).DoAndExp(http.StatusUnauthorized); err != nil {
+		t.Fatalf("expected the calendar to require a valid token: %s", err)
+	}
+
+	Must(NewChoreReq(ctx, client).Auth(tok).Get("/settings").DoAndExp(http.StatusOK))
+	settings := GetTpl[core.SettingsView](client.tmpl, "settings.page.gohtml")
+	if len(settings.APITokens) != 2 || settings.CreatedToken != "" {
+		t.Fatalf("unexpected tokens: %+v", settings.APITokens)
+	}
+	for _, token := range settings.APITokens {
+		if token.LastUsedAt.IsZero() {
+			t.Fatalf("expected the last use of %s to be recorded", token.Name)
+		}
+		if token.Name == "calendar" && (token.Write || len(token.ChoreLists) != 1 || token.ChoreLists[0].Name != "home") {
+			t.Fatalf("unexpected read token: %+v", token)
+		}
+	}
+	revoked := *findInSlice(settings.APITokens, func(t core.APIToken) bool { return t.Name == "calendar" })
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/settings/tokens/%s/delete", revoked.ID), nil).DoAndExp(http.StatusSeeOther))
+	if _, err := bearerReq(ctx, client, readToken, "GET", "/api/chore-lists", nil).DoAndExp(http.StatusUnauthorized); err != nil {
+		t.Fatalf("expected a revoked token to be rejected: %s", err)
+	}
+	if scope := Must(client.DBQuery().GetAPITokenChoreListIDs(ctx, revoked.ID)); len(scope) != 0 {
+		t.Fatalf("expected the chore lists of a revoked token to be deleted, got %v", scope)
+	}
+
+	workToken := createAPIToken(ctx, client, tok, map[string]string{"name": "work", "choreListIDs": work.List.ID})
+	Must(client.db.ExecContext(ctx, "DELETE FROM api_token_chore_list WHERE chore_list_id = ?", work.List.ID))
+	if lists := Must(decodeJSON[[]core.APIChoreList](bearerReq(ctx, client, workToken, "GET", "/api/chore-lists", nil).DoAndExp(http.StatusOK))); len(lists) != 0 {
+		t.Fatalf("expected a token without its lists to not see any list, got %+v", lists)
+	}
+	if _, err := bearerReq(ctx, client, workToken, "GET", fmt.Sprintf("/api/chore-lists/%s", home.List.ID), nil).DoAndExp(http.StatusForbidden); err != nil {
+		t.Fatalf("expected a token without its lists to not become unrestricted: %s", err)
+	}
+}
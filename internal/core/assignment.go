@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/goslu/sqlu"
+)
+
+// Assignment strategies decide who a chore is assigned to after it is completed.
+const (
+	AssignmentNone        = ""
+	AssignmentFixed       = "fixed"
+	AssignmentRoundRobin  = "round-robin"
+	AssignmentLeastRecent = "least-recent"
+)
+
+func IsAssignment(assignment string) bool {
+	switch assignment {
+	case AssignmentNone, AssignmentFixed, AssignmentRoundRobin, AssignmentLeastRecent:
+		return true
+	default:
+		return false
+	}
+}
+
+// AssignmentCandidate is a member of the chore's list and the last time they completed the chore.
+type AssignmentCandidate struct {
+	UserID         string
+	LastCompletion int64
+}
+
+// NextAssignee is who the chore should be assigned to after being completed. Round-robin passes the chore on to the
+// member after the current assignee, least-recent to the member who completed it longest ago, preferring members who
+// have never completed it.
+func NextAssignee(assignment, assignedTo string, candidates []AssignmentCandidate) string {
+	if len(candidates) == 0 {
+		return assignedTo
+	}
+	switch assignment {
+	case AssignmentRoundRobin:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].UserID < candidates[j].UserID
+		})
+		for i, c := range candidates {
+			if c.UserID == assignedTo {
+				return candidates[(i+1)%len(candidates)].UserID
+			}
+		}
+		return candidates[0].UserID
+	case AssignmentLeastRecent:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].LastCompletion != candidates[j].LastCompletion {
+				return candidates[i].LastCompletion < candidates[j].LastCompletion
+			}
+			return candidates[i].UserID < candidates[j].UserID
+		})
+		return candidates[0].UserID
+	default:
+		return assignedTo
+	}
+}
+
+// rotateAssignee moves the completed chore on to its next assignee.
+func rotateAssignee(ctx context.Context, q *cdb.Queries, chore *Chore) error {
+	if chore.Assignment != AssignmentRoundRobin && chore.Assignment != AssignmentLeastRecent {
+		return nil
+	}
+	rows, err := q.GetChoreAssignmentCandidates(ctx, cdb.GetChoreAssignmentCandidatesParams{
+		ChoreID:     chore.ID,
+		ChoreListID: chore.ChoreListID,
+	})
+	if err != nil {
+		return fmt.Errorf("getting assignment candidates: %w", err)
+	}
+	candidates := make([]AssignmentCandidate, len(rows))
+	for i, row := range rows {
+		candidates[i] = AssignmentCandidate{UserID: row.UserID, LastCompletion: row.LastCompletion}
+	}
+	next := NextAssignee(chore.Assignment, chore.AssignedTo, candidates)
+	if next == chore.AssignedTo {
+		return nil
+	}
+	if err := q.AssignChore(ctx, cdb.AssignChoreParams{ID: chore.ID, AssignedTo: sqlu.NullString(next)}); err != nil {
+		return fmt.Errorf("assigning chore to %s: %w", next, err)
+	}
+	return nil
+}
+
+// validateAssignee checks that the chore is assigned to a member of its list.
+func validateAssignee(ctx context.Context, q *cdb.Queries, choreListID, assignedTo string) error {
+	if assignedTo == "" {
+		return nil
+	}
+	members, err := q.GetChoreListMembers(ctx, choreListID)
+	if err != nil {
+		return fmt.Errorf("getting members: %w", err)
+	}
+	for _, m := range members {
+		if m.ID == assignedTo {
+			return nil
+		}
+	}
+	return fmt.Errorf("assignee %s is not a member of the chore list", assignedTo)
+}
+
+// FilterAssignedTo returns the chores assigned to the user.
+func FilterAssignedTo(chores []Chore, userID string) []Chore {
+	filtered := make([]Chore, 0, len(chores))
+	for _, c := range chores {
+		if c.AssignedTo == userID {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
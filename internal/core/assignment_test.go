@@ -0,0 +1,82 @@
+package core_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func TestNextAssignee(t *testing.T) {
+	candidates := []core.AssignmentCandidate{
+		{UserID: "c", LastCompletion: 10},
+		{UserID: "a", LastCompletion: 30},
+		{UserID: "b", LastCompletion: 0},
+	}
+	tests := []struct {
+		assignment string
+		assignedTo string
+		exp        string
+	}{
+		{assignment: core.AssignmentFixed, assignedTo: "a", exp: "a"},
+		{assignment: core.AssignmentRoundRobin, assignedTo: "a", exp: "b"},
+		{assignment: core.AssignmentRoundRobin, assignedTo: "c", exp: "a"},
+		{assignment: core.AssignmentRoundRobin, assignedTo: "gone", exp: "a"},
+		{assignment: core.AssignmentLeastRecent, assignedTo: "a", exp: "b"},
+	}
+	for _, test := range tests {
+		t.Run(test.assignment+" from "+test.assignedTo, func(t *testing.T) {
+			next := core.NextAssignee(test.assignment, test.assignedTo, append([]core.AssignmentCandidate(nil), candidates...))
+			if next != test.exp {
+				t.Fatalf("expected %s, got %s", test.exp, next)
+			}
+		})
+	}
+}
+
+func TestChoreAssignment(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	Panic(client.AddMember(ctx, cl.List.ID, "other"))
+	rotating := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "rotating",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1d",
+		"assignedTo":  "test",
+		"assignment":  core.AssignmentRoundRobin,
+	}))
+	Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "unassigned",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1d",
+	}))
+	today := date.Today()
+	for i, exp := range []string{"other", "test"} {
+		Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", rotating.ID), map[string]string{
+			"completed_at": today.Add(date.Duration(i-1) * date.Day).String(),
+		}).DoAndFollow(http.StatusSeeOther))
+		if ch := Must(GetChore(ctx, client, tok, cl.List.ID, rotating.ID)); ch.AssignedTo != exp {
+			t.Fatalf("expected chore to be assigned to %s, got %s", exp, ch.AssignedTo)
+		}
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s?mine=1", cl.List.ID)).DoAndExp(http.StatusOK))
+	mine := GetTpl[core.ChoreListView](client.tmpl, "chore_list.page.gohtml")
+	if !mine.Mine || len(mine.Chores.Chores) != 1 || mine.Chores.Chores[0].ID != rotating.ID {
+		t.Fatalf("expected only the assigned chore, got %+v", mine.Chores.Chores)
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", "/chores/", map[string]string{
+		"name":        "invalid",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1d",
+		"assignedTo":  "stranger",
+	}).DoAndExp(http.StatusSeeOther); err == nil {
+		t.Fatalf("expected assigning a non-member to fail")
+	}
+}
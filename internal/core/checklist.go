@@ -0,0 +1,184 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/chore-tracker/pkg/httpu"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/sqlu"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+// ChecklistItem is a step of a chore, the ticks are shared by the members of the list and reset when the chore is
+// completed.
+type ChecklistItem struct {
+	ID        string
+	ChoreID   string
+	Name      string
+	Checked   bool
+	CheckedBy string
+}
+
+func ChecklistItemFromDb(row cdb.ChoreChecklistItem) ChecklistItem {
+	return ChecklistItem{
+		ID:        row.ID,
+		ChoreID:   row.ChoreID,
+		Name:      row.Name,
+		Checked:   row.Checked != 0,
+		CheckedBy: row.CheckedBy.String,
+	}
+}
+
+func ChecklistFromDb(rows []cdb.ChoreChecklistItem) []ChecklistItem {
+	items := make([]ChecklistItem, len(rows))
+	for i, row := range rows {
+		items[i] = ChecklistItemFromDb(row)
+	}
+	return items
+}
+
+// ParseChecklist reads a checklist written as one item per line.
+func ParseChecklist(str string) []string {
+	var items []string
+	for _, line := range strings.Split(str, "\n") {
+		if item := strings.TrimSpace(line); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// setChecklist replaces the checklist of the chore, items that keep their name also keep their tick.
+func setChecklist(ctx context.Context, q *cdb.Queries, choreID string, names []string) ([]ChecklistItem, error) {
+	rows, err := q.GetChoreChecklist(ctx, choreID)
+	if err != nil {
+		return nil, fmt.Errorf("getting checklist: %w", err)
+	}
+	prev := make(map[string]cdb.ChoreChecklistItem, len(rows))
+	for _, row := range rows {
+		prev[row.Name] = row
+	}
+	if err := q.DeleteChoreChecklist(ctx, choreID); err != nil {
+		return nil, fmt.Errorf("deleting checklist: %w", err)
+	}
+	items := make([]ChecklistItem, len(names))
+	for i, name := range names {
+		p := prev[name]
+		item := cdb.CreateChoreChecklistItemParams{
+			ID:        Coalesce(p.ID, NewId()),
+			ChoreID:   choreID,
+			Position:  int64(i),
+			Name:      name,
+			Checked:   p.Checked,
+			CheckedBy: p.CheckedBy,
+		}
+		delete(prev, name)
+		if err := q.CreateChoreChecklistItem(ctx, item); err != nil {
+			return nil, fmt.Errorf("creating checklist item %s: %w", name, err)
+		}
+		items[i] = ChecklistItemFromDb(cdb.ChoreChecklistItem(item))
+	}
+	return items, nil
+}
+
+// withChecklists attaches the checklists of the list's chores.
+func withChecklists(ctx context.Context, q *cdb.Queries, choreListID string, chores []Chore) error {
+	rows, err := q.GetChoreListChecklists(ctx, choreListID)
+	if err != nil {
+		return fmt.Errorf("getting checklists: %w", err)
+	}
+	byChore := make(map[string][]ChecklistItem)
+	for _, row := range rows {
+		byChore[row.ChoreID] = append(byChore[row.ChoreID], ChecklistItemFromDb(row))
+	}
+	for i := range chores {
+		chores[i].Checklist = byChore[chores[i].ID]
+	}
+	return nil
+}
+
+// ToggleChecklistItem ticks or unticks the item. Ticking the last item of a chore that completes automatically
+// completes it on today.
+func ToggleChecklistItem(ctx context.Context, db *sql.DB, today date.Date, userID, choreID, itemID string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	chore, err := get(ctx, q, userID, choreID)
+	if err != nil {
+		return fmt.Errorf("getting chore: %w", err)
+	}
+	rows, err := q.GetChoreChecklist(ctx, choreID)
+	if err != nil {
+		return fmt.Errorf("getting checklist: %w", err)
+	}
+	var item *cdb.ChoreChecklistItem
+	for i := range rows {
+		if rows[i].ID == itemID {
+			item = &rows[i]
+		}
+	}
+	if item == nil {
+		return fmt.Errorf("checklist item %s: %w", itemID, sql.ErrNoRows)
+	}
+	checked := item.Checked == 0
+	params := cdb.CheckChoreChecklistItemParams{ID: itemID, ChoreID: choreID}
+	if checked {
+		params.Checked, params.CheckedBy = 1, sqlu.NullString(userID)
+	}
+	if _, err := q.CheckChoreChecklistItem(ctx, params); err != nil {
+		return fmt.Errorf("checking item %s: %w", itemID, err)
+	}
+	if checked && chore.AutoComplete {
+		unchecked, err := q.CountUncheckedChoreChecklistItems(ctx, choreID)
+		if err != nil {
+			return fmt.Errorf("counting unchecked items: %w", err)
+		}
+		// finished chores can't be completed, their checklist is only ticked
+		if unchecked == 0 && !chore.IsFinished() {
+			if err := complete(ctx, q, userID, chore, today, 0); err != nil {
+				return fmt.Errorf("completing chore: %w", err)
+			}
+			// a completion de-duplicated with an earlier one today doesn't reset the checklist itself
+			if err := q.ResetChoreChecklist(ctx, choreID); err != nil {
+				return fmt.Errorf("resetting checklist: %w", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing tx: %w", err)
+	}
+	return nil
+}
+
+func ChoreChecklistToggleHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := r.PathValue("id")
+		itemID := r.PathValue("itemID")
+		userID := auth.MustGetSession(ctx).UserID
+		if id == "" || itemID == "" {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing id"))
+		}
+		chore, err := Get(ctx, db, userID, id)
+		if err != nil {
+			return srvu.Err(http.StatusNotFound, fmt.Errorf("getting chore from request: %w", err))
+		}
+		if err := ToggleChecklistItem(ctx, db, date.Today(), userID, id, itemID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return srvu.Err(http.StatusNotFound, err)
+			}
+			return writeErr(err, "toggling checklist item")
+		}
+		httpu.RedirectToNext(w, r, fmt.Sprintf("/chore-lists/%s", chore.ChoreListID))
+		return nil
+	})
+}
@@ -0,0 +1,96 @@
+package core_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func TestChoreChecklist(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	ch := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":         "bathroom",
+		"choreType":    core.ChoreTypeInterval,
+		"choreListID":  cl.List.ID,
+		"interval":     "1w",
+		"checklist":    "sink\n toilet \n\nfloor",
+		"autoComplete": "true",
+	}))
+	toggle := func(name string) *core.Chore {
+		t.Helper()
+		chore := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID))
+		for _, item := range chore.Checklist {
+			if item.Name == name {
+				Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/checklist/%s/toggle", ch.ID, item.ID), nil).DoAndFollow(http.StatusSeeOther))
+				return Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID))
+			}
+		}
+		t.Fatalf("no checklist item %s in %+v", name, chore.Checklist)
+		return nil
+	}
+	created := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID))
+	if created.ChecklistText() != "sink\ntoilet\nfloor" || created.ChecklistDone() != 0 {
+		t.Fatalf("unexpected checklist: %+v", created.Checklist)
+	}
+	toggle("sink")
+	toggle("toilet")
+	if c := toggle("sink"); c.ChecklistDone() != 1 {
+		t.Fatalf("expected unticking to leave 1 ticked item, got %d", c.ChecklistDone())
+	}
+	toggle("sink")
+	completed := toggle("floor")
+	if completed.LastCompletion != date.Today() {
+		t.Fatalf("expected ticking the last item to complete the chore, last completion %s", completed.LastCompletion)
+	}
+	if completed.ChecklistDone() != 0 {
+		t.Fatalf("expected the checklist to reset on completion, got %d ticked", completed.ChecklistDone())
+	}
+	toggle("sink")
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s", ch.ID), map[string]string{
+		"name":         "bathroom",
+		"interval":     "1w",
+		"checklist":    "sink\nmirror",
+		"autoComplete": "true",
+	}).DoAndFollow(http.StatusSeeOther))
+	updated := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID))
+	if updated.ChecklistText() != "sink\nmirror" || !updated.Checklist[0].Checked || updated.Checklist[1].Checked {
+		t.Fatalf("expected the kept item to stay ticked, got %+v", updated.Checklist)
+	}
+	if c := toggle("mirror"); c.ChecklistDone() != 0 {
+		t.Fatalf("expected the checklist to reset when already completed today, got %d ticked", c.ChecklistDone())
+	}
+	if events := Must(client.DBQuery().GetChoreEvents(ctx, ch.ID)); len(events) != 1 {
+		t.Fatalf("expected one completion today, got %+v", events)
+	}
+
+	once := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":         "move",
+		"choreType":    core.ChoreTypeDate,
+		"choreListID":  cl.List.ID,
+		"date":         date.Today().Add(-date.Day).String(),
+		"repeats":      "1",
+		"checklist":    "boxes",
+		"autoComplete": "true",
+	}))
+	Panic(core.Complete(ctx, client.db, "test", once.ID, date.Today().Add(-date.Day), 0))
+	finished := Must(GetChore(ctx, client, tok, cl.List.ID, once.ID))
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/checklist/%s/toggle", once.ID, finished.Checklist[0].ID), nil).DoAndFollow(http.StatusSeeOther))
+	if c := Must(GetChore(ctx, client, tok, cl.List.ID, once.ID)); c.ChecklistDone() != 1 || c.LastCompletion != date.Today().Add(-date.Day) {
+		t.Fatalf("expected finished chores to only tick the item, got %+v", c)
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", "/chores/", map[string]string{
+		"name":         "invalid",
+		"choreType":    core.ChoreTypeInterval,
+		"choreListID":  cl.List.ID,
+		"interval":     "1w",
+		"autoComplete": "true",
+	}).DoAndExp(http.StatusSeeOther); err == nil {
+		t.Fatalf("expected auto completing without a checklist to fail")
+	}
+}
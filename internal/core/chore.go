@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/SimonSchneider/chore-tracker/internal/cdb"
 	"github.com/SimonSchneider/chore-tracker/pkg/auth"
@@ -10,6 +11,8 @@ import (
 	"github.com/SimonSchneider/goslu/date"
 	"github.com/SimonSchneider/goslu/srvu"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -19,6 +22,9 @@ const (
 	ChoreTypeDateRepeating = "date-repeating"
 )
 
+// MaxEffort is the most points a chore can be worth.
+const MaxEffort = 100
+
 type Chore struct {
 	ID             string
 	Name           string
@@ -30,12 +36,56 @@ type Chore struct {
 	SnoozedFor     date.Duration
 	ChoreListID    string
 	RepeatsLeft    int64 // -1 means infinite
+	Recurrence     Recurrence
+	AssignedTo     string
+	Assignment     string
+	Checklist      []ChecklistItem
+	AutoComplete   bool // complete the chore when the last checklist item is ticked
+	Prerequisites  []string
+	Effort         int64 // points earned by completing the chore
+	Version        int64
+	// LastCompletedOn is the day of the latest completion, unlike LastCompletion it isn't the due date of date chores or
+	// the occurrence used up by date repeating chores. It is only loaded with the list's prerequisites.
+	LastCompletedOn date.Date
 }
 
 func (c *Chore) Repeats() bool {
 	return c.RepeatsLeft > 0
 }
 
+// IsFinished reports whether the chore has used up all its repeats, or for date repeating chores its occurrences,
+// finished chores are kept for their history.
+func (c *Chore) IsFinished() bool {
+	if c.RepeatsLeft == 0 {
+		return true
+	}
+	if c.IsDateRepeating() {
+		_, ok := c.nextOccurrence()
+		return !ok
+	}
+	return false
+}
+
+// ChecklistDone is the number of ticked checklist items.
+func (c Chore) ChecklistDone() int {
+	done := 0
+	for _, item := range c.Checklist {
+		if item.Checked {
+			done++
+		}
+	}
+	return done
+}
+
+// ChecklistText is the checklist written as one item per line.
+func (c Chore) ChecklistText() string {
+	names := make([]string, len(c.Checklist))
+	for i, item := range c.Checklist {
+		names[i] = item.Name
+	}
+	return strings.Join(names, "\n")
+}
+
 func (c Chore) IsOneshot() bool {
 	return c.ChoreType == ChoreTypeOneshot
 }
@@ -52,13 +102,99 @@ func (c Chore) IsDateRepeating() bool {
 	return c.ChoreType == ChoreTypeDateRepeating
 }
 
+// nextOccurrence is the pending occurrence of a date repeating chore, false if the recurrence has none left.
+func (c *Chore) nextOccurrence() (date.Date, bool) {
+	// the first occurrence may fall on the day the chore was created, afterward it is the first one after the last
+	// completion.
+	after := c.CreatedAt.Add(-1 * date.Day)
+	if !c.LastCompletion.IsZero() {
+		after = c.LastCompletion
+	}
+	return c.Recurrence.Next(after, c.CreatedAt)
+}
+
+// completion is the last completion the chore keeps when completed on day. Completing a date repeating chore before
+// its pending occurrence uses that occurrence up, so the next one is counted from it rather than from the day.
+func (c *Chore) completion(day date.Date) date.Date {
+	if c.IsDateRepeating() {
+		if next, ok := c.nextOccurrence(); ok && next.After(day) {
+			return next
+		}
+	}
+	return day
+}
+
 func (c *Chore) NextCompletion() date.Date {
+	if c.IsDateRepeating() {
+		next, ok := c.nextOccurrence()
+		if !ok {
+			// finished, like other finished chores it stays at its last completion.
+			return c.LastCompletion
+		}
+		return next.Add(c.SnoozedFor)
+	}
 	if c.LastCompletion.IsZero() {
 		return c.CreatedAt.Add(c.SnoozedFor)
 	}
 	return c.LastCompletion.Add(c.Interval + c.SnoozedFor)
 }
 
+// RRule is the RFC 5545 RRULE of the chore's repetitions starting at its next completion, or empty if it doesn't
+// repeat. Interval chores are repeated from their last completion so the rule only holds until they are completed late
+// or early.
+func (c *Chore) RRule() string {
+	var rule string
+	switch {
+	case c.IsFinished():
+		return ""
+	case c.IsDateRepeating():
+		rule = c.Recurrence.RRule()
+	case c.IsInterval() && c.Interval%date.Week == 0:
+		rule = Recurrence{Freq: FreqWeekly, Every: int(c.Interval / date.Week)}.RRule()
+	case c.IsInterval():
+		rule = Recurrence{Freq: FreqDaily, Every: int(c.Interval)}.RRule()
+	default:
+		return ""
+	}
+	if c.RepeatsLeft > 0 {
+		rule += fmt.Sprintf(";COUNT=%d", c.RepeatsLeft)
+	}
+	return rule
+}
+
+// ETag is the chore's version as an HTTP entity tag, writes sending it back in If-Match fail if the chore has changed.
+func (c *Chore) ETag() string {
+	return strconv.Quote(strconv.FormatInt(c.Version, 10))
+}
+
+// RequestVersion is the chore version a write is based on, from the version form value or an If-Match header with the
+// chore's ETag. Zero means the write isn't conditional.
+func RequestVersion(r *http.Request) (int64, error) {
+	val := r.FormValue("version")
+	if val == "" {
+		val = strings.Trim(strings.TrimPrefix(r.Header.Get("If-Match"), "W/"), `"`)
+	}
+	if val == "" || val == "*" {
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("illegal version '%s': %w", val, err)
+	}
+	return version, nil
+}
+
+// writeErr maps the errors of writes to a chore to a status, using msg to describe what failed.
+func writeErr(err error, msg string) error {
+	if errors.Is(err, ErrInvalidInput) {
+		return srvu.Err(http.StatusBadRequest, err)
+	}
+	if errors.Is(err, ErrFinished) || errors.Is(err, ErrStale) {
+		return srvu.Err(http.StatusConflict, err)
+	}
+	return srvu.Err(http.StatusInternalServerError, fmt.Errorf("%s: %w", msg, err))
+}
+
 func (c *Chore) DurationToNextFrom(today date.Date) date.Duration {
 	return c.NextCompletion().Sub(today)
 }
@@ -68,6 +204,8 @@ func (c *Chore) DurationToNext() date.Duration {
 }
 
 func ChoreFromDb(row cdb.Chore) Chore {
+	// only validated recurrences are stored, so the error can be ignored
+	recurrence, _ := ParseRecurrence(row.Recurrence)
 	return Chore{
 		ID:             row.ID,
 		Name:           row.Name,
@@ -79,6 +217,12 @@ func ChoreFromDb(row cdb.Chore) Chore {
 		SnoozedFor:     date.Duration(row.SnoozedFor),
 		ChoreListID:    row.ChoreListID,
 		RepeatsLeft:    row.RepeatsLeft,
+		Recurrence:     recurrence,
+		AssignedTo:     row.AssignedTo.String,
+		Assignment:     row.Assignment,
+		AutoComplete:   row.AutoComplete != 0,
+		Effort:         row.Effort,
+		Version:        row.Version,
 	}
 }
 
@@ -91,7 +235,7 @@ func ChoreAddHandler(db *sql.DB, view *View) http.Handler {
 		}
 		chore, err := Create(ctx, db, date.Today(), userID, inp)
 		if err != nil {
-			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("creating the chore: %w", err))
+			return writeErr(err, "creating the chore")
 		}
 		httpu.RedirectToNext(w, r, fmt.Sprintf("/chore-lists/%s", chore.ChoreListID))
 		return nil
@@ -109,9 +253,39 @@ func ChoreEditPage(db *sql.DB, view *View) http.Handler {
 		if err != nil {
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("getting chore from request: %w", err))
 		}
+		members, err := cdb.New(db).GetChoreListMembers(ctx, ch.ChoreListID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("getting members: %w", err))
+		}
+		checklist, err := cdb.New(db).GetChoreChecklist(ctx, ch.ID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("getting checklist: %w", err))
+		}
+		ch.Checklist = ChecklistFromDb(checklist)
+		rows, err := cdb.New(db).GetChoresByList(ctx, ch.ChoreListID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("getting chores of list: %w", err))
+		}
+		listChores := ChoresFromDb(rows)
+		if err := withPrerequisites(ctx, cdb.New(db), ch.ChoreListID, listChores); err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		for _, c := range listChores {
+			if c.ID == ch.ID {
+				ch.Prerequisites = c.Prerequisites
+			}
+		}
+		suggestion, err := GetIntervalSuggestion(ctx, db, userID, *ch)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("suggesting an interval: %w", err))
+		}
+		w.Header().Set("ETag", ch.ETag())
 		return view.ChoreEditPage(w, r, ChoreEditView{
-			Chore:     *ch,
-			ChoreType: Coalesce(r.FormValue("chore-type"), ch.ChoreType),
+			Chore:      *ch,
+			ChoreType:  Coalesce(r.FormValue("chore-type"), ch.ChoreType),
+			Members:    members,
+			ListChores: listChores,
+			Suggestion: suggestion,
 		})
 	})
 }
@@ -132,6 +306,40 @@ func (c *CompletionInput) FromForm(r *http.Request) (err error) {
 	return nil
 }
 
+type SnoozeInput struct {
+	Duration date.Duration `json:"duration"`
+	Until    date.Date     `json:"until"`
+}
+
+func (s *SnoozeInput) FromForm(r *http.Request) error {
+	if err := parse(&s.Duration, date.ParseDuration, r.FormValue("duration"), 0); err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	if err := parse(&s.Until, date.ParseDate, r.FormValue("until"), 0); err != nil {
+		return fmt.Errorf("invalid until: %w", err)
+	}
+	return nil
+}
+
+// SnoozeFor is how long from today the chore should be snoozed, a day unless a duration or a date is given.
+func (s *SnoozeInput) SnoozeFor(today date.Date) (date.Duration, error) {
+	switch {
+	case s.Duration != 0 && !s.Until.IsZero():
+		return 0, fmt.Errorf("can't snooze both for a duration and until a date")
+	case !s.Until.IsZero():
+		if !s.Until.After(today) {
+			return 0, fmt.Errorf("can't snooze until %s, it is not after today", s.Until)
+		}
+		return s.Until.Sub(today), nil
+	case s.Duration < 0:
+		return 0, fmt.Errorf("can't snooze for a negative duration: %s", s.Duration)
+	case s.Duration == 0:
+		return 1 * date.Day, nil
+	default:
+		return s.Duration, nil
+	}
+}
+
 func ChoreCompleteHandler(db *sql.DB, view *View) http.Handler {
 	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		id := r.PathValue("id")
@@ -143,12 +351,16 @@ func ChoreCompleteHandler(db *sql.DB, view *View) http.Handler {
 		if err := srvu.Decode(r, &inp, false); err != nil {
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
 		}
+		version, err := RequestVersion(r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
 		chore, err := Get(ctx, db, userID, id)
 		if err != nil {
 			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("getting chore from request: %w", err))
 		}
-		if err := Complete(ctx, db, userID, id, inp.CompletedAt); err != nil {
-			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("completing the chore: %w", err))
+		if err := Complete(ctx, db, userID, id, inp.CompletedAt, version); err != nil {
+			return writeErr(err, "completing the chore")
 		}
 		httpu.RedirectToNext(w, r, fmt.Sprintf("/chore-lists/%s", chore.ChoreListID))
 		return nil
@@ -163,12 +375,24 @@ func ChoreSnoozeHandler(db *sql.DB, view *View) http.Handler {
 		if id == "" {
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing id"))
 		}
+		var inp SnoozeInput
+		if err := srvu.Decode(r, &inp, false); err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
+		}
+		snoozeFor, err := inp.SnoozeFor(today)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		version, err := RequestVersion(r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
 		chore, err := Get(ctx, db, userID, id)
 		if err != nil {
 			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("getting chore from request: %w", err))
 		}
-		if err := Snooze(ctx, db, today, userID, id, 1*date.Day); err != nil {
-			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("snoozing the chore: %w", err))
+		if err := Snooze(ctx, db, today, userID, id, snoozeFor, version); err != nil {
+			return writeErr(err, "snoozing the chore")
 		}
 		httpu.RedirectToNext(w, r, fmt.Sprintf("/chore-lists/%s", chore.ChoreListID))
 		return nil
@@ -183,12 +407,16 @@ func ChoreExpediteHandler(db *sql.DB, view *View) http.Handler {
 		if id == "" {
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing id"))
 		}
+		version, err := RequestVersion(r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
 		chore, err := Get(ctx, db, userID, id)
 		if err != nil {
 			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("getting chore from request: %w", err))
 		}
-		if err := Expedite(ctx, db, today, userID, id); err != nil {
-			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("snoozing the chore: %w", err))
+		if err := Expedite(ctx, db, today, userID, id, version); err != nil {
+			return writeErr(err, "expediting the chore")
 		}
 		httpu.RedirectToNext(w, r, fmt.Sprintf("/chore-lists/%s", chore.ChoreListID))
 		return nil
@@ -206,12 +434,17 @@ func ChoreUpdateHandler(db *sql.DB, view *View) http.Handler {
 		if err := srvu.Decode(r, &inp, false); err != nil {
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("decoding input: %w", err))
 		}
+		version, err := RequestVersion(r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		inp.Version = version
 		chore, err := Get(ctx, db, userID, id)
 		if err != nil {
 			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("getting chore from request: %w", err))
 		}
 		if _, err := Update(ctx, db, chore, inp); err != nil {
-			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("updating the chore: %w", err))
+			return writeErr(err, "updating the chore")
 		}
 		httpu.RedirectToNext(w, r, fmt.Sprintf("/chore-lists/%s", chore.ChoreListID))
 		return nil
@@ -240,8 +473,13 @@ func ChoreDeleteHandler(db *sql.DB) http.Handler {
 func ChoreMux(db *sql.DB, view *View) http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle("GET /chores/{id}/edit", ChoreEditPage(db, view))
+	mux.Handle("GET /chores/{id}/history", ChoreHistoryPage(db, view))
+	mux.Handle("GET /chores/{id}/insights", ChoreInsightsPage(db, view))
+	mux.Handle("POST /chores/{id}/events/{eventID}/delete", ChoreEventDeleteHandler(db))
 	mux.Handle("POST /chores/{id}/complete", ChoreCompleteHandler(db, view))
+	mux.Handle("POST /chores/{id}/checklist/{itemID}/toggle", ChoreChecklistToggleHandler(db))
 	mux.Handle("POST /chores/{id}/snooze", ChoreSnoozeHandler(db, view))
+	mux.Handle("POST /chores/{id}/interval", ChoreIntervalAcceptHandler(db))
 	mux.Handle("POST /chores/{id}/expedite", ChoreExpediteHandler(db, view))
 	mux.Handle("POST /chores/{id}/delete", ChoreDeleteHandler(db))
 	mux.Handle("POST /chores/{$}", ChoreAddHandler(db, view))
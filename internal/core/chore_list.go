@@ -17,15 +17,72 @@ import (
 )
 
 type ListView struct {
-	Chores []Chore
-	Today  date.Date
+	Chores   []Chore
+	Blocked  []Chore
+	Finished []Chore
+	Today    date.Date
+}
+
+type SnoozePreset struct {
+	Label    string
+	Duration date.Duration
+	Until    date.Date
+}
+
+// SnoozePresets are the common snoozes offered next to due chores.
+func (v *ListView) SnoozePresets() []SnoozePreset {
+	return []SnoozePreset{
+		{Label: "3 days", Duration: 3 * date.Day},
+		{Label: "Weekend", Until: nextWeekday(v.Today, time.Saturday)},
+		{Label: "Next week", Until: nextWeekday(v.Today, time.Monday)},
+		{Label: "2 weeks", Duration: 2 * date.Week},
+	}
+}
+
+func (v *ListView) Tomorrow() date.Date {
+	return v.Today.Add(date.Day)
+}
+
+// nextWeekday is the first date after today that falls on the weekday.
+func nextWeekday(today date.Date, weekday time.Weekday) date.Date {
+	days := (int(weekday)-int(today.ToStdTime().UTC().Weekday())+6)%7 + 1
+	return today.Add(date.Duration(days) * date.Day)
 }
 
 func NewListView(today date.Date, chores []Chore) *ListView {
-	sort.SliceStable(chores, func(i, j int) bool {
-		return chores[i].NextCompletion().Before(chores[j].NextCompletion())
+	v := &ListView{Today: today}
+	byID := make(map[string]*Chore, len(chores))
+	for i := range chores {
+		byID[chores[i].ID] = &chores[i]
+	}
+	for _, c := range chores {
+		if c.IsFinished() {
+			v.Finished = append(v.Finished, c)
+		} else if c.IsBlocked(byID) {
+			v.Blocked = append(v.Blocked, c)
+		} else {
+			v.Chores = append(v.Chores, c)
+		}
+	}
+	sort.SliceStable(v.Chores, func(i, j int) bool {
+		return v.Chores[i].NextCompletion().Before(v.Chores[j].NextCompletion())
+	})
+	sort.SliceStable(v.Blocked, func(i, j int) bool {
+		return v.Blocked[i].NextCompletion().Before(v.Blocked[j].NextCompletion())
+	})
+	sort.SliceStable(v.Finished, func(i, j int) bool {
+		return v.Finished[i].LastCompletion.After(v.Finished[j].LastCompletion)
 	})
-	return &ListView{Chores: chores, Today: today}
+	return v
+}
+
+// AssignedTo keeps the chores assigned to the user. The view is categorized with all the list's chores first, so a
+// prerequisite assigned to someone else still blocks the user's chore.
+func (v *ListView) AssignedTo(userID string) *ListView {
+	v.Chores = FilterAssignedTo(v.Chores, userID)
+	v.Blocked = FilterAssignedTo(v.Blocked, userID)
+	v.Finished = FilterAssignedTo(v.Finished, userID)
+	return v
 }
 
 func (v *ListView) Sections() []Section {
@@ -47,6 +104,9 @@ func (v *ListView) Sections() []Section {
 			}
 		}
 	}
+	if len(v.Blocked) > 0 {
+		sections = append(sections, Section{Title: "Blocked", LatestCompletion: date.Max, Chores: v.Blocked})
+	}
 	return sections
 }
 
@@ -70,6 +130,124 @@ func ChoreListNewPage(view *View) http.Handler {
 	})
 }
 
+// CreateChoreList creates a chore list with the user as its only member, in the time zone unless it is empty.
+func CreateChoreList(ctx context.Context, db *sql.DB, userID, name, timezone string) (cdb.ChoreList, error) {
+	if name == "" {
+		return cdb.ChoreList{}, fmt.Errorf("%w: missing name", ErrInvalidInput)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	cl, err := createChoreList(ctx, cdb.New(tx), userID, name, time.Now())
+	if err != nil {
+		return cdb.ChoreList{}, err
+	}
+	if timezone != "" {
+		if cl, err = SetChoreListTimezone(ctx, tx, userID, cl.ID, timezone); err != nil {
+			return cdb.ChoreList{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("committing tx: %w", err)
+	}
+	return cl, nil
+}
+
+// createChoreList creates the list with the user as its only member within the transaction of q.
+func createChoreList(ctx context.Context, q *cdb.Queries, userID, name string, now time.Time) (cdb.ChoreList, error) {
+	cl, err := q.CreateChoreList(ctx, cdb.CreateChoreListParams{
+		ID:        NewId(),
+		Name:      name,
+		CreatedAt: now.UnixMilli(),
+		UpdatedAt: now.UnixMilli(),
+	})
+	if err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("creating chore list: %w", err)
+	}
+	if err := q.AddUserToChoreList(ctx, cdb.AddUserToChoreListParams{
+		UserID:      userID,
+		ChoreListID: cl.ID,
+	}); err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("adding user to chore list: %w", err)
+	}
+	return cl, nil
+}
+
+func RenameChoreList(ctx context.Context, db cdb.DBTX, userID, id, name string) (cdb.ChoreList, error) {
+	if name == "" {
+		return cdb.ChoreList{}, fmt.Errorf("%w: missing name", ErrInvalidInput)
+	}
+	cl, err := cdb.New(db).UpdateChoreList(ctx, cdb.UpdateChoreListParams{ID: id, UpdatedAt: time.Now().UnixMilli(), Name: name, UserID: userID})
+	if err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("updating chore list: %w", err)
+	}
+	return cl, nil
+}
+
+// UpdateChoreList renames the list and sets its time zone, an empty time zone keeps the current one.
+func UpdateChoreList(ctx context.Context, db *sql.DB, userID, id, name, timezone string) (cdb.ChoreList, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	cl, err := RenameChoreList(ctx, tx, userID, id, name)
+	if err != nil {
+		return cdb.ChoreList{}, err
+	}
+	if timezone != "" && timezone != cl.Timezone {
+		if cl, err = SetChoreListTimezone(ctx, tx, userID, id, timezone); err != nil {
+			return cdb.ChoreList{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("committing tx: %w", err)
+	}
+	return cl, nil
+}
+
+// LeaveChoreList removes the user from the chore list and unassigns the chores assigned to them.
+func LeaveChoreList(ctx context.Context, db *sql.DB, userID, id string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	if err := q.RemoveUserFromChoreList(ctx, cdb.RemoveUserFromChoreListParams{UserID: userID, ChoreListID: id}); err != nil {
+		return fmt.Errorf("removing member: %w", err)
+	}
+	if err := q.UnassignChoreListMember(ctx, cdb.UnassignChoreListMemberParams{ChoreListID: id, AssignedTo: sqlu.NullString(userID)}); err != nil {
+		return fmt.Errorf("unassigning member: %w", err)
+	}
+	if err := q.DeleteChoreListFeed(ctx, cdb.DeleteChoreListFeedParams{ChoreListID: id, UserID: userID}); err != nil {
+		return fmt.Errorf("deleting feed: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing tx: %w", err)
+	}
+	return nil
+}
+
+// GetListChores is the chores of the list with their checklists and prerequisites.
+func GetListChores(ctx context.Context, db cdb.DBTX, choreListID string) ([]Chore, error) {
+	q := cdb.New(db)
+	rows, err := q.GetChoresByList(ctx, choreListID)
+	if err != nil {
+		return nil, fmt.Errorf("getting chores: %w", err)
+	}
+	chores := ChoresFromDb(rows)
+	if err := withChecklists(ctx, q, choreListID, chores); err != nil {
+		return nil, err
+	}
+	if err := withPrerequisites(ctx, q, choreListID, chores); err != nil {
+		return nil, err
+	}
+	return chores, nil
+}
+
 func ChoreListNewHandler(db *sql.DB) http.Handler {
 	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		userID := auth.MustGetSession(ctx).UserID
@@ -77,30 +255,9 @@ func ChoreListNewHandler(db *sql.DB) http.Handler {
 		if name == "" {
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing name"))
 		}
-		tx, err := db.BeginTx(ctx, nil)
+		cl, err := CreateChoreList(ctx, db, userID, name, "")
 		if err != nil {
-			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("beginning tx: %w", err))
-		}
-		defer tx.Rollback()
-		q := cdb.New(tx)
-		now := time.Now()
-		cl, err := q.CreateChoreList(ctx, cdb.CreateChoreListParams{
-			ID:        NewId(),
-			Name:      name,
-			CreatedAt: now.UnixMilli(),
-			UpdatedAt: now.UnixMilli(),
-		})
-		if err != nil {
-			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("creating chore list: %w", err))
-		}
-		if err := q.AddUserToChoreList(ctx, cdb.AddUserToChoreListParams{
-			UserID:      userID,
-			ChoreListID: cl.ID,
-		}); err != nil {
-			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("adding user to chore list: %w", err))
-		}
-		if err := tx.Commit(); err != nil {
-			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("committing tx: %w", err))
+			return srvu.Err(http.StatusInternalServerError, err)
 		}
 		http.Redirect(w, r, fmt.Sprintf("/chore-lists/%s", cl.ID), http.StatusSeeOther)
 		return nil
@@ -118,10 +275,8 @@ func ChoreListUpdateHandler(db *sql.DB, view *View) http.Handler {
 		if name == "" {
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing name"))
 		}
-		q := cdb.New(db)
-		_, err := q.UpdateChoreList(ctx, cdb.UpdateChoreListParams{ID: id, UpdatedAt: time.Now().UnixMilli(), Name: name, UserID: userID})
-		if err != nil {
-			return srvu.Err(http.StatusInternalServerError, err)
+		if _, err := UpdateChoreList(ctx, db, userID, id, name, strings.TrimSpace(r.FormValue("timezone"))); err != nil {
+			return writeErr(err, "updating chore list")
 		}
 		httpu.RedirectToNext(w, r, fmt.Sprintf("/chore-lists/%s", id))
 		return nil
@@ -135,8 +290,7 @@ func ChoreListLeaveHandler(db *sql.DB, view *View) http.Handler {
 		if id == "" {
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing id"))
 		}
-		q := cdb.New(db)
-		if err := q.RemoveUserFromChoreList(ctx, cdb.RemoveUserFromChoreListParams{UserID: userID, ChoreListID: id}); err != nil {
+		if err := LeaveChoreList(ctx, db, userID, id); err != nil {
 			return srvu.Err(http.StatusInternalServerError, err)
 		}
 		httpu.RedirectToNext(w, r, "/chore-lists")
@@ -166,14 +320,25 @@ func ChoreListRender(ctx context.Context, db *sql.DB, view *View, w http.Respons
 	if err != nil {
 		return srvu.Err(http.StatusInternalServerError, err)
 	}
-	chores, err := cdb.New(db).GetChoresByList(ctx, choreListID)
+	chores, err := GetListChores(ctx, db, choreListID)
 	if err != nil {
 		return srvu.Err(http.StatusInternalServerError, err)
 	}
+	members, err := cdb.New(db).GetChoreListMembers(ctx, choreListID)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	listView := NewListView(today, chores)
+	mine := r.URL.Query().Get("mine") != ""
+	if mine {
+		listView = listView.AssignedTo(userID)
+	}
 	return view.ChoreListPage(w, r, ChoreListView{
 		List:    choreList,
 		Weekday: time.Now().Weekday(),
-		Chores:  NewListView(today, ChoresFromDb(chores)),
+		Chores:  listView,
+		Members: members,
+		Mine:    mine,
 	})
 }
 
@@ -199,20 +364,48 @@ func ChoreListEditPage(db *sql.DB, view *View) http.Handler {
 			return srvu.Err(http.StatusInternalServerError, err)
 		}
 		invites, err := q.GetInvitationsByChoreList(ctx, cdb.GetInvitationsByChoreListParams{ChoreListID: sqlu.NullString(choreList.ID), ExpiresAt: time.Now().UnixMilli()})
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		feed, err := GetChoreListFeed(ctx, db, userID, choreList.ID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		webhooks, err := GetWebhooks(ctx, db, choreList.ID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
 		return view.ChoreListEditPage(w, r, ChoreListEditView{
-			List:    choreList,
-			Members: members,
-			Invites: invites,
+			List:     choreList,
+			Members:  members,
+			Invites:  invites,
+			Feed:     feed,
+			Webhooks: webhooks,
 		})
 	})
 }
 
-func ChoreListNewChorePage(view *View) http.Handler {
+func ChoreListNewChorePage(db *sql.DB, view *View) http.Handler {
 	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
 		choreListID := r.PathValue("choreListID")
+		q := cdb.New(db)
+		if _, err := q.GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: choreListID, UserID: userID}); err != nil {
+			return srvu.Err(http.StatusForbidden, err)
+		}
+		members, err := q.GetChoreListMembers(ctx, choreListID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		rows, err := q.GetChoresByList(ctx, choreListID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
 		return view.ChoreCreatePage(w, r, ChoreEditView{
-			Chore:     Chore{ChoreListID: choreListID},
-			ChoreType: Coalesce(r.FormValue("chore-type"), "interval"),
+			Chore:      Chore{ChoreListID: choreListID},
+			ChoreType:  Coalesce(r.FormValue("chore-type"), "interval"),
+			Members:    members,
+			ListChores: ChoresFromDb(rows),
 		})
 	})
 }
@@ -270,6 +463,12 @@ func ChoreListChartPage(db *sql.DB, view *View) http.Handler {
 	})
 }
 
+var calendarEventTypes = map[string]string{
+	"completion_calendar": EventTypeComplete,
+	"snooze_calendar":     EventTypeSnooze,
+	"expedite_calendar":   EventTypeExpedite,
+}
+
 func ChoreListChartDataHandler(db *sql.DB, view *View) http.Handler {
 	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		choreListID := r.PathValue("choreListID")
@@ -281,11 +480,13 @@ func ChoreListChartDataHandler(db *sql.DB, view *View) http.Handler {
 		if chartData == "" {
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing chartData"))
 		}
+		q := cdb.New(db)
 		switch chartData {
-		case "completion_calendar":
-			data, err := cdb.New(db).GetChoreListCalendarCompletionData(ctx, cdb.GetChoreListCalendarCompletionDataParams{
+		case "completion_calendar", "snooze_calendar", "expedite_calendar":
+			data, err := q.GetChoreListCalendarEventData(ctx, cdb.GetChoreListCalendarEventDataParams{
 				ChoreListID: choreListID,
 				UserID:      userID,
+				EventType:   calendarEventTypes[chartData],
 			})
 			if err != nil {
 				return srvu.Err(http.StatusForbidden, err)
@@ -300,6 +501,46 @@ func ChoreListChartDataHandler(db *sql.DB, view *View) http.Handler {
 				})
 			}
 			return view.ChoreListChartData(w, r, cld)
+		case "completions_by_member":
+			data, err := q.GetChoreListCompletionsByMember(ctx, cdb.GetChoreListCompletionsByMemberParams{
+				ChoreListID: choreListID,
+				UserID:      userID,
+			})
+			if err != nil {
+				return srvu.Err(http.StatusForbidden, err)
+			}
+			cld := &ChoreListDataView{
+				Categories: make([]ChoreListDataViewCategory, 0, len(data)),
+			}
+			for _, d := range data {
+				cld.Categories = append(cld.Categories, ChoreListDataViewCategory{
+					Name:  Coalesce(d.DisplayName, d.ID),
+					Value: float64(d.Count),
+				})
+			}
+			return view.ChoreListChartData(w, r, cld)
+		case "completions_by_weekday":
+			data, err := q.GetChoreListCalendarEventData(ctx, cdb.GetChoreListCalendarEventDataParams{
+				ChoreListID: choreListID,
+				UserID:      userID,
+				EventType:   EventTypeComplete,
+			})
+			if err != nil {
+				return srvu.Err(http.StatusForbidden, err)
+			}
+			return view.ChoreListChartData(w, r, &ChoreListDataView{Categories: CompletionsByWeekday(data)})
+		case "on_time_ratio":
+			completions, err := GetListCompletions(ctx, db, userID, choreListID)
+			if err != nil {
+				return srvu.Err(http.StatusForbidden, err)
+			}
+			return view.ChoreListChartData(w, r, &ChoreListDataView{Categories: OnTimeRatios(completions)})
+		case "lateness_by_chore":
+			chores, events, err := getListHistory(ctx, db, userID, choreListID)
+			if err != nil {
+				return srvu.Err(http.StatusForbidden, err)
+			}
+			return view.ChoreListChartData(w, r, &ChoreListDataView{Categories: LatenessByChore(chores, events)})
 		default:
 			return srvu.Err(http.StatusBadRequest, fmt.Errorf("unknown chart type: %s", chartData))
 		}
@@ -310,53 +551,77 @@ func ChoreListMux(db *sql.DB, view *View, inviteStore *InviteStore) *http.ServeM
 	mux := http.NewServeMux()
 	mux.Handle("GET /chore-lists/new", ChoreListNewPage(view))
 	mux.Handle("POST /chore-lists/", ChoreListNewHandler(db))
+	mux.Handle("POST /chore-lists/import", ChoreListFromExportHandler(db))
 	mux.Handle("POST /chore-lists/{choreListID}", ChoreListUpdateHandler(db, view))
 	mux.Handle("POST /chore-lists/{choreListID}/leave", ChoreListLeaveHandler(db, view))
 	mux.Handle("POST /chore-lists/{choreListID}/invites/", ChoreListCreateInviteHandler(db, view, inviteStore))
 	mux.Handle("POST /chore-lists/{choreListID}/invites/{inviteID}/delete", ChoreListDeleteInviteHandler(db, view, inviteStore))
-	mux.Handle("GET /chore-lists/{choreListID}/chores/new", ChoreListNewChorePage(view))
+	mux.Handle("GET /chore-lists/{choreListID}/chores/new", ChoreListNewChorePage(db, view))
 	mux.Handle("GET /chore-lists/{choreListID}/edit", ChoreListEditPage(db, view))
+	mux.Handle("POST /chore-lists/{choreListID}/feed", ChoreListFeedRotateHandler(db))
+	mux.Handle("POST /chore-lists/{choreListID}/feed/delete", ChoreListFeedDeleteHandler(db))
+	mux.Handle("POST /chore-lists/{choreListID}/import", ChoreListImportHandler(db, view))
+	mux.Handle("GET /chore-lists/{choreListID}/export", ChoreListExportHandler(db))
+	mux.Handle("POST /chore-lists/{choreListID}/webhooks/", ChoreListWebhookCreateHandler(db))
+	mux.Handle("GET /chore-lists/{choreListID}/webhooks/{webhookID}", ChoreListWebhookPage(db, view))
+	mux.Handle("POST /chore-lists/{choreListID}/webhooks/{webhookID}/delete", ChoreListWebhookDeleteHandler(db))
 	mux.Handle("GET /chore-lists/{choreListID}/charts", ChoreListChartPage(db, view))
 	mux.Handle("GET /chore-lists/{choreListID}/charts/{chartType}", ChoreListChartDataHandler(db, view))
+	mux.Handle("GET /chore-lists/{choreListID}/leaderboard", ChoreListLeaderboardPage(db, view))
 	mux.Handle("GET /chore-lists/{choreListID}", ChoreListPage(db, view))
 	mux.Handle("GET /chore-lists/{choreListID}/", ChoreListPage(db, view))
 	mux.Handle("GET /chore-lists/{$}", ChoreListsPage(db, view))
 	return mux
 }
 
-func APIChoreListIcsFile(db *sql.DB, view *View, apiKey string) http.Handler {
+// APIChoreListIcsFile serves the list as a calendar. Calendar apps can't send headers so the API token can also be
+// passed as the token query parameter.
+func APIChoreListIcsFile(db *sql.DB, view *View, tokens auth.APITokenStore) http.Handler {
 	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		choreListID := r.PathValue("choreListID")
-		qAPIKey := r.FormValue("apiKey")
-		if qAPIKey == "" {
-			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing APIKey"))
-		}
-		if apiKey == "" {
-			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing APIKey"))
-		}
-		// TODO: improve api key handling and make it dynamic instead of hardcoded
-		if strings.Compare(apiKey, choreListID) == 0 {
-			return srvu.Err(http.StatusUnauthorized, fmt.Errorf("invalid APIKey"))
+		token := Coalesce(r.URL.Query().Get("token"), auth.BearerToken(r))
+		if token == "" {
+			return srvu.Err(http.StatusUnauthorized, fmt.Errorf("missing token"))
 		}
-		cl, err := cdb.New(db).GetChoreListWithoutUser(ctx, choreListID)
+		session, ok, err := tokens.VerifyAPIToken(ctx, token, time.Now())
 		if err != nil {
 			return srvu.Err(http.StatusInternalServerError, err)
+		} else if !ok {
+			return srvu.Err(http.StatusUnauthorized, fmt.Errorf("invalid token"))
 		}
-		chores, err := cdb.New(db).GetChoresByList(ctx, choreListID)
-		if err != nil {
-			return srvu.Err(http.StatusInternalServerError, err)
+		if !session.Scope.AllowsList(choreListID) {
+			return srvu.Err(http.StatusForbidden, fmt.Errorf("token is not allowed to access chore list %s", choreListID))
 		}
-		return view.ChoreListIcs(w, r, &ChoreListIcsView{
-			ID:     cl.ID,
-			Name:   cl.Name,
-			Today:  date.Today(),
-			Chores: ChoresFromDb(chores),
-		})
+		return renderChoreListIcs(ctx, w, r, db, view, session.UserID, choreListID)
 	})
 }
 
-func ChoreListAPIMux(db *sql.DB, view *View, apiKey string) *http.ServeMux {
-	mux := http.NewServeMux()
-	mux.Handle("GET /api/chore-lists/{choreListID}/ics", APIChoreListIcsFile(db, view, apiKey))
-	return mux
+func renderChoreListIcs(ctx context.Context, w http.ResponseWriter, r *http.Request, db *sql.DB, view *View, userID, choreListID string) error {
+	opts, err := ParseIcsOptions(r)
+	if err != nil {
+		return srvu.Err(http.StatusBadRequest, err)
+	}
+	cl, err := cdb.New(db).GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: choreListID, UserID: userID})
+	if err != nil {
+		return srvu.Err(http.StatusNotFound, err)
+	}
+	chores, err := cdb.New(db).GetChoresByList(ctx, choreListID)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	return view.ChoreListIcs(w, r, &ChoreListIcsView{
+		ID:      cl.ID,
+		Name:    cl.Name,
+		Today:   date.Today(),
+		Chores:  filterIcsChores(opts, userID, ChoresFromDb(chores)),
+		Now:     time.Now(),
+		Options: opts,
+	})
+}
+
+func filterIcsChores(opts IcsOptions, userID string, chores []Chore) []Chore {
+	if opts.AssignedOnly {
+		return FilterAssignedTo(chores, userID)
+	}
+	return chores
 }
@@ -1,9 +1,12 @@
 package core_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/SimonSchneider/chore-tracker/internal/core"
 	"github.com/SimonSchneider/goslu/date"
@@ -115,6 +118,63 @@ func TestCRUDChore(t *testing.T) {
 			}
 		})
 	})
+	t.Run("Create DateRepeating chore", func(t *testing.T) {
+		chore, err := NewChore(ctx, client, tok, map[string]string{
+			"name":        "date-repeating",
+			"recurrence":  "monthly 1",
+			"choreType":   core.ChoreTypeDateRepeating,
+			"choreListID": cl.List.ID,
+		})
+		if err != nil {
+			t.Fatalf("failed to create chore: %s", err)
+		}
+		if chore.Recurrence.String() != "monthly 1" {
+			t.Fatalf("chore recurrence is not 'monthly 1': %s", chore.Recurrence)
+		}
+		if next := chore.NextCompletion().ToStdTime().UTC(); next.Day() != 1 || next.Before(date.Today().ToStdTime().UTC()) {
+			t.Fatalf("next completion is not the next 1st of the month: %s", chore.NextCompletion())
+		}
+		t.Run("Update", func(t *testing.T) {
+			if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s", chore.ID), map[string]string{
+				"name":       "date-repeating2",
+				"recurrence": "weekly mon,thu",
+			}).DoAndFollow(http.StatusSeeOther); err != nil {
+				t.Fatalf("failed to change chore: %s", err)
+			}
+			updatedChore := Must(GetChore(ctx, client, tok, cl.List.ID, chore.ID))
+			if updatedChore.Recurrence.String() != "weekly mon,thu" {
+				t.Fatalf("chore recurrence is not 'weekly mon,thu': %s", updatedChore.Recurrence)
+			}
+			if wd := updatedChore.NextCompletion().ToStdTime().UTC().Weekday(); wd != time.Monday && wd != time.Thursday {
+				t.Fatalf("next completion is not on a monday or thursday: %s", wd)
+			}
+		})
+		t.Run("Update with RRULE", func(t *testing.T) {
+			if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s", chore.ID), map[string]string{
+				"name":       "date-repeating2",
+				"recurrence": "RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			}).DoAndFollow(http.StatusSeeOther); err != nil {
+				t.Fatalf("failed to change chore: %s", err)
+			}
+			updatedChore := Must(GetChore(ctx, client, tok, cl.List.ID, chore.ID))
+			if updatedChore.Recurrence.String() != "monthly last-fri" {
+				t.Fatalf("chore recurrence is not 'monthly last-fri': %s", updatedChore.Recurrence)
+			}
+			if updatedChore.RepeatsLeft != 3 {
+				t.Fatalf("chore repeats is not 3: %d", updatedChore.RepeatsLeft)
+			}
+		})
+		t.Run("Invalid recurrence", func(t *testing.T) {
+			if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", "/chores/", map[string]string{
+				"name":        "invalid",
+				"recurrence":  "weekly",
+				"choreType":   core.ChoreTypeDateRepeating,
+				"choreListID": cl.List.ID,
+			}).DoAndExp(http.StatusBadRequest); err != nil {
+				t.Fatalf("expected invalid recurrence to be rejected: %s", err)
+			}
+		})
+	})
 }
 
 func TestListViewChores(t *testing.T) {
@@ -149,6 +209,254 @@ func TestListViewChores(t *testing.T) {
 	}
 }
 
+func TestChoreHistoryUndo(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	ch := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "test",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1w",
+		"repeats":     "3",
+	}))
+	today := date.Today()
+	for _, completedAt := range []date.Date{today.Add(-2 * date.Day), today} {
+		Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", ch.ID), map[string]string{
+			"completed_at": completedAt.String(),
+		}).DoAndFollow(http.StatusSeeOther))
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chores/%s/history", ch.ID)).DoAndExp(http.StatusOK))
+	history := GetTpl[core.ChoreHistoryView](client.tmpl, "chore_history.page.gohtml")
+	if len(history.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(history.Events))
+	}
+	if history.Events[0].OccurredAt != today {
+		t.Fatalf("latest event is not today: %s", history.Events[0].OccurredAt)
+	}
+	completed := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID))
+	if completed.RepeatsLeft != 1 {
+		t.Fatalf("expected 1 repeat left, got %d", completed.RepeatsLeft)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/events/%s/delete", ch.ID, history.Events[0].ID), nil).DoAndFollow(http.StatusSeeOther))
+	undone := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID))
+	if undone.LastCompletion != today.Add(-2*date.Day) {
+		t.Fatalf("last completion is not the remaining completion: %s", undone.LastCompletion)
+	}
+	if undone.RepeatsLeft != 2 {
+		t.Fatalf("expected 2 repeats left, got %d", undone.RepeatsLeft)
+	}
+	history = GetTpl[core.ChoreHistoryView](client.tmpl, "chore_history.page.gohtml")
+	if len(history.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(history.Events))
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/events/%s/delete", ch.ID, "unknown"), nil).DoAndExp(http.StatusNotFound); err != nil {
+		t.Fatalf("expected unknown event to not be found: %s", err)
+	}
+}
+
+func TestDateRepeatingChoreHistoryUndo(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	today := date.Today()
+	pending := today.Add(2 * date.Day)
+	ch := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "trash",
+		"recurrence":  "weekly " + strings.ToLower(pending.ToStdTime().UTC().Weekday().String()[:3]),
+		"choreType":   core.ChoreTypeDateRepeating,
+		"choreListID": cl.List.ID,
+	}))
+	for _, completedAt := range []date.Date{today, today.Add(date.Day)} {
+		Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", ch.ID), map[string]string{
+			"completed_at": completedAt.String(),
+		}).DoAndFollow(http.StatusSeeOther))
+	}
+	if next := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID)).NextCompletion(); next != pending.Add(2*date.Week) {
+		t.Fatalf("expected both completions to use up an occurrence, got %s", next)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chores/%s/history", ch.ID)).DoAndExp(http.StatusOK))
+	history := GetTpl[core.ChoreHistoryView](client.tmpl, "chore_history.page.gohtml")
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/events/%s/delete", ch.ID, history.Events[0].ID), nil).DoAndFollow(http.StatusSeeOther))
+	if next := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID)).NextCompletion(); next != pending.Add(date.Week) {
+		t.Fatalf("expected the chore to be due when it was before the undone completion, got %s", next)
+	}
+	history = GetTpl[core.ChoreHistoryView](client.tmpl, "chore_history.page.gohtml")
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/events/%s/delete", ch.ID, history.Events[0].ID), nil).DoAndFollow(http.StatusSeeOther))
+	if next := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID)).NextCompletion(); next != pending {
+		t.Fatalf("expected the chore to be due on its first occurrence again, got %s", next)
+	}
+}
+
+func TestFinishedChore(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	ch := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "test",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1w",
+		"repeats":     "2",
+	}))
+	for _, completedAt := range []date.Date{date.Today().Add(-1 * date.Day), date.Today()} {
+		Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", ch.ID), map[string]string{
+			"completed_at": completedAt.String(),
+		}).DoAndFollow(http.StatusSeeOther))
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s", cl.List.ID)).DoAndFollow(http.StatusOK))
+	view := GetTpl[core.ChoreListView](client.tmpl, "chore_list.page.gohtml")
+	if len(view.Chores.Finished) != 1 || view.Chores.Finished[0].ID != ch.ID {
+		t.Fatalf("expected the chore to be finished, got %v", view.Chores.Finished)
+	}
+	for _, section := range view.Chores.Sections() {
+		if section.HasChores() {
+			t.Fatalf("expected finished chore to be hidden, found it in %s", section.Title)
+		}
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", ch.ID), map[string]string{
+		"completed_at": date.Today().Add(date.Day).String(),
+	}).DoAndExp(http.StatusConflict); err != nil {
+		t.Fatalf("expected completing a finished chore to conflict: %s", err)
+	}
+}
+
+func TestChoreVersioning(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	ch := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "test",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1w",
+	}))
+	version := fmt.Sprintf("%d", ch.Version)
+	for range 2 {
+		Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", ch.ID), map[string]string{
+			"version": version,
+		}).DoAndFollow(http.StatusSeeOther))
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chores/%s/history", ch.ID)).DoAndExp(http.StatusOK))
+	if history := GetTpl[core.ChoreHistoryView](client.tmpl, "chore_history.page.gohtml"); len(history.Events) != 1 {
+		t.Fatalf("expected completing twice on the same day to count once, got %d events", len(history.Events))
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/expedite", ch.ID), map[string]string{
+		"version": version,
+	}).DoAndExp(http.StatusConflict); err != nil {
+		t.Fatalf("expected stale expedite to conflict: %s", err)
+	}
+	res := Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chores/%s/edit", ch.ID)).DoAndExp(http.StatusOK))
+	etag := res.Header.Get("ETag")
+	if etag != `"2"` {
+		t.Fatalf("expected ETag of the completed chore to be \"2\", got %s", etag)
+	}
+	update := map[string]string{"name": "updated", "interval": "2w"}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s", ch.ID), update).Header("If-Match", `"1"`).DoAndExp(http.StatusConflict); err != nil {
+		t.Fatalf("expected stale update to conflict: %s", err)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s", ch.ID), update).Header("If-Match", etag).DoAndFollow(http.StatusSeeOther))
+	updated := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID))
+	if updated.Name != "updated" || updated.Version != 3 {
+		t.Fatalf("expected the chore to be updated to version 3, got %+v", updated)
+	}
+}
+
+func TestSnoozeEvents(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	ch := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "test",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1w",
+	}))
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/snooze", ch.ID), nil).DoAndFollow(http.StatusSeeOther))
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/expedite", ch.ID), nil).DoAndFollow(http.StatusSeeOther))
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chores/%s/history", ch.ID)).DoAndExp(http.StatusOK))
+	history := GetTpl[core.ChoreHistoryView](client.tmpl, "chore_history.page.gohtml")
+	if len(history.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(history.Events))
+	}
+	byType := make(map[string]core.ChoreEvent)
+	for _, e := range history.Events {
+		byType[e.EventType] = e
+	}
+	if snooze := byType[core.EventTypeSnooze]; snooze.Duration != date.Day || snooze.CreatedBy == "" {
+		t.Fatalf("expected a snooze of a day by the user, got %+v", snooze)
+	}
+	if expedite := byType[core.EventTypeExpedite]; expedite.Duration != -date.Day {
+		t.Fatalf("expected an expedite of a day, got %+v", expedite)
+	}
+	res := Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/charts/snooze_calendar", cl.List.ID)).DoAndExp(http.StatusOK))
+	var chart struct {
+		Data []struct {
+			Value int64 `json:"value"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&chart); err != nil {
+		t.Fatalf("decoding chart data: %s", err)
+	}
+	if len(chart.Data) != 1 || chart.Data[0].Value != 1 {
+		t.Fatalf("expected one snooze in the chart, got %+v", chart.Data)
+	}
+	res = Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/charts/completion_calendar", cl.List.ID)).DoAndExp(http.StatusOK))
+	chart.Data = nil
+	if err := json.NewDecoder(res.Body).Decode(&chart); err != nil {
+		t.Fatalf("decoding chart data: %s", err)
+	}
+	if len(chart.Data) != 0 {
+		t.Fatalf("expected snoozes to not count as completions, got %+v", chart.Data)
+	}
+}
+
+func TestSnoozeDurations(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	today := date.Today()
+	tests := []struct {
+		form   map[string]string
+		status int
+		next   date.Date
+	}{
+		{form: nil, status: http.StatusSeeOther, next: today.Add(date.Day)},
+		{form: map[string]string{"duration": "3d"}, status: http.StatusSeeOther, next: today.Add(3 * date.Day)},
+		{form: map[string]string{"until": today.Add(2 * date.Week).String()}, status: http.StatusSeeOther, next: today.Add(2 * date.Week)},
+		{form: map[string]string{"until": today.String()}, status: http.StatusBadRequest},
+		{form: map[string]string{"duration": "-1d"}, status: http.StatusBadRequest},
+		{form: map[string]string{"duration": "1d", "until": today.Add(date.Week).String()}, status: http.StatusBadRequest},
+		{form: map[string]string{"duration": "soon"}, status: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v", test.form), func(t *testing.T) {
+			ch := Must(NewChore(ctx, client, tok, map[string]string{
+				"name":        "test",
+				"choreType":   core.ChoreTypeInterval,
+				"choreListID": cl.List.ID,
+				"interval":    "1w",
+			}))
+			if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/snooze", ch.ID), test.form).DoAndExp(test.status); err != nil {
+				t.Fatalf("snoozing: %s", err)
+			}
+			if test.status != http.StatusSeeOther {
+				return
+			}
+			snoozed := Must(GetChore(ctx, client, tok, cl.List.ID, ch.ID))
+			if next := snoozed.NextCompletion(); next != test.next {
+				t.Fatalf("expected next completion %s, got %s", test.next, next)
+			}
+		})
+	}
+}
+
 /*
 
    func TestCompleteChore(t *testing.T) {
@@ -267,3 +575,42 @@ func TestListViewChores(t *testing.T) {
    	}
    }
 */
+
+func TestDateRepeatingNextCompletion(t *testing.T) {
+	t.Run("No occurrence left", func(t *testing.T) {
+		chore := core.Chore{
+			ChoreType:   core.ChoreTypeDateRepeating,
+			Recurrence:  Must(core.ParseRecurrence("yearly/2 feb 29")),
+			RepeatsLeft: -1,
+			CreatedAt:   Must(date.ParseDate("2025-01-01")),
+		}
+		if !chore.IsFinished() {
+			t.Fatalf("expected a chore without occurrences to be finished")
+		}
+		if next := chore.NextCompletion(); next != chore.LastCompletion {
+			t.Fatalf("expected a finished chore to stay at its last completion, got %s", next)
+		}
+	})
+	t.Run("Completed early", func(t *testing.T) {
+		ctx, client, cancel := Setup()
+		defer cancel()
+		tok := Must(client.NewToken(ctx))
+		cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+		today := date.Today()
+		pending := today.Add(2 * date.Day)
+		chore := Must(NewChore(ctx, client, tok, map[string]string{
+			"name":        "trash",
+			"recurrence":  "weekly " + strings.ToLower(pending.ToStdTime().UTC().Weekday().String()[:3]),
+			"choreType":   core.ChoreTypeDateRepeating,
+			"choreListID": cl.List.ID,
+		}))
+		if next := chore.NextCompletion(); next != pending {
+			t.Fatalf("expected the chore to be due on %s, got %s", pending, next)
+		}
+		Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", chore.ID), nil).DoAndFollow(http.StatusSeeOther))
+		completed := Must(GetChore(ctx, client, tok, cl.List.ID, chore.ID))
+		if next := completed.NextCompletion(); next != pending.Add(date.Week) {
+			t.Fatalf("expected completing early to use up the occurrence on %s, got %s", pending, next)
+		}
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -68,7 +69,7 @@ func Setup() (context.Context, *Client, context.CancelFunc) {
 	}
 	ctx = srvu.ContextWithLogger(ctx, srvu.LogToOutput(log.New(os.Stdout, "", log.LstdFlags|log.Lshortfile)))
 	tplProv := &TestTemplateProvider{exec: make(map[string]any)}
-	view := core.NewView(tplProv)
+	view := core.NewView(tplProv, "https://chores.example.com/")
 	tokenStore := auth.NewInMemoryTokenStore()
 	authCfg := auth.Config{
 		Provider:                    core.NewAuthProvider(db),
@@ -85,8 +86,9 @@ func Setup() (context.Context, *Client, context.CancelFunc) {
 			Store:       tokenStore,
 		},
 		RefreshCookie: auth.CookieConfig{},
+		APITokens:     &core.APITokenStore{DB: db},
 	}
-	mux := core.Mux(db, view, authCfg, "")
+	mux := core.Mux(db, view, authCfg)
 	client := &Client{db: db, mux: mux, tokenStore: tokenStore, tmpl: tplProv, authCookieName: authCfg.SessionCookie.Name}
 	return ctx, client, cancel
 }
@@ -113,6 +115,13 @@ func (t ClientToken) Auth(r *http.Request) *http.Request {
 	return r
 }
 
+func (c *Client) AddMember(ctx context.Context, choreListID, userID string) error {
+	if _, err := c.DBQuery().CreateUser(ctx, cdb.CreateUserParams{ID: userID, DisplayName: userID}); err != nil {
+		return err
+	}
+	return c.DBQuery().AddUserToChoreList(ctx, cdb.AddUserToChoreListParams{ChoreListID: choreListID, UserID: userID})
+}
+
 func (c *Client) NewToken(ctx context.Context) (*ClientToken, error) {
 	u, err := c.DBQuery().CreateUser(ctx, cdb.CreateUserParams{ID: "test"})
 	if err != nil {
@@ -174,6 +183,11 @@ func (r *ChoreReq) Get(uri string) *ChoreReq {
 	return r
 }
 
+func (r *ChoreReq) Header(key, val string) *ChoreReq {
+	r.req.Header.Set(key, val)
+	return r
+}
+
 func (r *ChoreReq) Auth(t *ClientToken) *ChoreReq {
 	r.token = t
 	return r
@@ -235,7 +249,7 @@ func NewChore(ctx context.Context, client *Client, token *ClientToken, formVals
 	if cl.Chores == nil {
 		return nil, fmt.Errorf("chore not found")
 	}
-	c := findInSlice(cl.Chores.Chores, func(c core.Chore) bool {
+	c := findInSlice(slices.Concat(cl.Chores.Chores, cl.Chores.Blocked), func(c core.Chore) bool {
 		return c.Name == formVals["name"]
 	})
 	return c, nil
@@ -249,7 +263,7 @@ func GetChore(ctx context.Context, client *Client, token *ClientToken, choreList
 	if cl.Chores == nil {
 		return nil, fmt.Errorf("chore not found")
 	}
-	c := findInSlice(cl.Chores.Chores, func(c core.Chore) bool {
+	c := findInSlice(slices.Concat(cl.Chores.Chores, cl.Chores.Blocked, cl.Chores.Finished), func(c core.Chore) bool {
 		return c.ID == choreID
 	})
 	return c, nil
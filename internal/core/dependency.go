@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+// IsBlocked reports whether one of the chore's prerequisites hasn't been completed since the chore itself was last
+// completed. Finished prerequisites can't be completed again so they don't block, prerequisites that are not in chores
+// are ignored.
+func (c *Chore) IsBlocked(chores map[string]*Chore) bool {
+	for _, id := range c.Prerequisites {
+		if p, ok := chores[id]; ok && !p.IsFinished() && !p.LastCompletedOn.After(c.LastCompletedOn) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindDependencyCycle returns the chain of chores leading from start back to itself through their prerequisites, or
+// nil if start isn't part of a cycle.
+func FindDependencyCycle(prerequisites map[string][]string, start string) []string {
+	visited := make(map[string]bool)
+	var path []string
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		path = append(path, id)
+		for _, p := range prerequisites[id] {
+			if p == start {
+				path = append(path, p)
+				return true
+			}
+			if !visited[p] {
+				visited[p] = true
+				if visit(p) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if visit(start) {
+		return path
+	}
+	return nil
+}
+
+// setPrerequisites replaces the prerequisites of the chore after checking that they are other chores of its list and
+// don't depend on the chore themselves.
+func setPrerequisites(ctx context.Context, q *cdb.Queries, chore *Chore, prerequisites []string) error {
+	rows, err := q.GetChoresByList(ctx, chore.ChoreListID)
+	if err != nil {
+		return fmt.Errorf("getting chores of list: %w", err)
+	}
+	names := make(map[string]string, len(rows))
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+	deps, err := q.GetChoreListDependencies(ctx, chore.ChoreListID)
+	if err != nil {
+		return fmt.Errorf("getting dependencies of list: %w", err)
+	}
+	graph := make(map[string][]string)
+	for _, d := range deps {
+		if d.ChoreID != chore.ID {
+			graph[d.ChoreID] = append(graph[d.ChoreID], d.DependsOnID)
+		}
+	}
+	for _, p := range prerequisites {
+		if _, ok := names[p]; !ok || p == chore.ID {
+			return fmt.Errorf("%w: prerequisite %s is not another chore of the list", ErrInvalidInput, p)
+		}
+	}
+	graph[chore.ID] = prerequisites
+	if cycle := FindDependencyCycle(graph, chore.ID); cycle != nil {
+		for i, id := range cycle {
+			cycle[i] = names[id]
+		}
+		cycle[0], cycle[len(cycle)-1] = chore.Name, chore.Name
+		return fmt.Errorf("%w: prerequisites form a cycle: %s", ErrInvalidInput, strings.Join(cycle, " -> "))
+	}
+	if err := q.DeleteChoreDependencies(ctx, chore.ID); err != nil {
+		return fmt.Errorf("deleting prerequisites: %w", err)
+	}
+	for _, p := range prerequisites {
+		if err := q.CreateChoreDependency(ctx, cdb.CreateChoreDependencyParams{ChoreID: chore.ID, DependsOnID: p}); err != nil {
+			return fmt.Errorf("adding prerequisite %s: %w", p, err)
+		}
+	}
+	chore.Prerequisites = prerequisites
+	return nil
+}
+
+// withPrerequisites attaches the prerequisites of the list's chores.
+func withPrerequisites(ctx context.Context, q *cdb.Queries, choreListID string, chores []Chore) error {
+	deps, err := q.GetChoreListDependencies(ctx, choreListID)
+	if err != nil {
+		return fmt.Errorf("getting dependencies: %w", err)
+	}
+	byChore := make(map[string][]string)
+	for _, d := range deps {
+		byChore[d.ChoreID] = append(byChore[d.ChoreID], d.DependsOnID)
+	}
+	completions, err := q.GetChoreListLastCompletions(ctx, choreListID)
+	if err != nil {
+		return fmt.Errorf("getting last completions: %w", err)
+	}
+	completedOn := make(map[string]date.Date, len(completions))
+	for _, c := range completions {
+		completedOn[c.ChoreID] = date.Date(c.OccurredAt)
+	}
+	for i := range chores {
+		chores[i].Prerequisites = byChore[chores[i].ID]
+		chores[i].LastCompletedOn = completedOn[chores[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package core_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func TestFindDependencyCycle(t *testing.T) {
+	graph := map[string][]string{
+		"hang":  {"wash"},
+		"wash":  {"sort"},
+		"sort":  {"hang"},
+		"fold":  {"hang"},
+		"dust":  {},
+		"sweep": {"dust"},
+	}
+	if cycle := core.FindDependencyCycle(graph, "hang"); !slices.Equal(cycle, []string{"hang", "wash", "sort", "hang"}) {
+		t.Fatalf("expected the cycle through hang, got %v", cycle)
+	}
+	if cycle := core.FindDependencyCycle(graph, "fold"); cycle != nil {
+		t.Fatalf("expected fold to not be part of a cycle, got %v", cycle)
+	}
+	if cycle := core.FindDependencyCycle(graph, "sweep"); cycle != nil {
+		t.Fatalf("expected sweep to not be part of a cycle, got %v", cycle)
+	}
+}
+
+func TestChoreDependencies(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	newChore := func(name string, prerequisites ...string) *core.Chore {
+		form := map[string]string{
+			"name":        name,
+			"choreType":   core.ChoreTypeInterval,
+			"choreListID": cl.List.ID,
+			"interval":    "1w",
+		}
+		if len(prerequisites) > 0 {
+			form["prerequisites"] = prerequisites[0]
+		}
+		return Must(NewChore(ctx, client, tok, form))
+	}
+	wash := newChore("wash")
+	hang := newChore("hang", wash.ID)
+	listView := func() *core.ListView {
+		Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s", cl.List.ID)).DoAndFollow(http.StatusOK))
+		return GetTpl[core.ChoreListView](client.tmpl, "chore_list.page.gohtml").Chores
+	}
+	sections := listView().Sections()
+	if blocked := sections[len(sections)-1]; blocked.Title != "Blocked" || len(blocked.Chores) != 1 || blocked.Chores[0].ID != hang.ID {
+		t.Fatalf("expected hang to be blocked, got %+v", blocked)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", wash.ID), map[string]string{
+		"completed_at": date.Today().Add(-1 * date.Day).String(),
+	}).DoAndFollow(http.StatusSeeOther))
+	if blocked := listView().Blocked; len(blocked) != 0 {
+		t.Fatalf("expected hang to be unblocked after washing, got %+v", blocked)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", hang.ID), nil).DoAndFollow(http.StatusSeeOther))
+	if blocked := listView().Blocked; len(blocked) != 1 {
+		t.Fatalf("expected hang to be blocked again after hanging, got %+v", blocked)
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s", wash.ID), map[string]string{
+		"name":          "wash",
+		"interval":      "1w",
+		"prerequisites": hang.ID,
+	}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected a dependency cycle to be rejected: %s", err)
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s", wash.ID), map[string]string{
+		"name":          "wash",
+		"interval":      "1w",
+		"prerequisites": wash.ID,
+	}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected depending on itself to be rejected: %s", err)
+	}
+	other := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "other"}))
+	iron := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "iron",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": other.List.ID,
+		"interval":    "1w",
+	}))
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s", wash.ID), map[string]string{
+		"name":          "wash",
+		"interval":      "1w",
+		"prerequisites": iron.ID,
+	}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected depending on a chore of another list to be rejected: %s", err)
+	}
+
+	Panic(client.AddMember(ctx, cl.List.ID, "other"))
+	sortChore := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "sort",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1w",
+		"assignedTo":  "other",
+	}))
+	fold := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":          "fold",
+		"choreType":     core.ChoreTypeInterval,
+		"choreListID":   cl.List.ID,
+		"interval":      "1w",
+		"assignedTo":    "test",
+		"prerequisites": sortChore.ID,
+	}))
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s?mine=1", cl.List.ID)).DoAndExp(http.StatusOK))
+	mine := GetTpl[core.ChoreListView](client.tmpl, "chore_list.page.gohtml").Chores
+	if len(mine.Blocked) != 1 || mine.Blocked[0].ID != fold.ID || len(mine.Chores) != 0 {
+		t.Fatalf("expected my chore to be blocked by the prerequisite assigned to another member, got %+v", mine)
+	}
+}
+
+func TestChoreDependenciesOnDateAndFinishedChores(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	listView := func() *core.ListView {
+		Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s", cl.List.ID)).DoAndFollow(http.StatusOK))
+		return GetTpl[core.ChoreListView](client.tmpl, "chore_list.page.gohtml").Chores
+	}
+	taxes := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "taxes",
+		"choreType":   core.ChoreTypeDate,
+		"choreListID": cl.List.ID,
+		"date":        date.Today().Add(date.Day).String(),
+		"repeats":     "1",
+	}))
+	file := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":          "file",
+		"choreType":     core.ChoreTypeInterval,
+		"choreListID":   cl.List.ID,
+		"interval":      "1w",
+		"prerequisites": taxes.ID,
+	}))
+	if blocked := listView().Blocked; len(blocked) != 1 || blocked[0].ID != file.ID {
+		t.Fatalf("expected file to be blocked by the pending date chore, got %+v", blocked)
+	}
+
+	move := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "move",
+		"choreType":   core.ChoreTypeOneshot,
+		"choreListID": cl.List.ID,
+		"repeats":     "1",
+	}))
+	unpack := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":          "unpack",
+		"choreType":     core.ChoreTypeInterval,
+		"choreListID":   cl.List.ID,
+		"interval":      "1w",
+		"prerequisites": move.ID,
+	}))
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", move.ID), map[string]string{
+		"completed_at": date.Today().Add(-2 * date.Day).String(),
+	}).DoAndFollow(http.StatusSeeOther))
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", unpack.ID), map[string]string{
+		"completed_at": date.Today().Add(-1 * date.Day).String(),
+	}).DoAndFollow(http.StatusSeeOther))
+	if blocked := listView().Blocked; slices.ContainsFunc(blocked, func(c core.Chore) bool { return c.ID == unpack.ID }) {
+		t.Fatalf("expected unpack to not be blocked by the finished move, got %+v", blocked)
+	}
+}
@@ -0,0 +1,249 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"text/template"
+	"time"
+
+	choretracker "github.com/SimonSchneider/chore-tracker"
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/chore-tracker/pkg/httpu"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+// digestTextTemplate is the plain text alternative of the digest email, it is a text template so chore names aren't
+// HTML escaped.
+var digestTextTemplate = template.Must(template.ParseFS(choretracker.StaticEmbeddedFS, "static/templates/digest.email.gotxt"))
+
+const defaultDigestSendAt = 7 * 60
+
+// UserDigest is the user's choice of receiving a morning email with the chores that are due.
+type UserDigest struct {
+	Enabled  bool
+	Email    string
+	SendAt   int64
+	Timezone string
+}
+
+func UserDigestFromDb(row cdb.UserDigest) UserDigest {
+	return UserDigest{Enabled: row.Enabled == 1, Email: row.Email, SendAt: row.SendAt, Timezone: row.Timezone}
+}
+
+// SendAtTime is the time of day the digest is sent, as hh:mm.
+func (d UserDigest) SendAtTime() string {
+	return fmt.Sprintf("%02d:%02d", d.SendAt/60, d.SendAt%60)
+}
+
+func (d *UserDigest) FromForm(r *http.Request) error {
+	d.Enabled = r.FormValue("enabled") != ""
+	d.Email = strings.TrimSpace(r.FormValue("email"))
+	d.Timezone = strings.TrimSpace(r.FormValue("timezone"))
+	sendAt, err := time.Parse("15:04", r.FormValue("sendAt"))
+	if err != nil {
+		return fmt.Errorf("invalid send time '%s': %w", r.FormValue("sendAt"), err)
+	}
+	d.SendAt = int64(sendAt.Hour()*60 + sendAt.Minute())
+	return nil
+}
+
+func (d *UserDigest) Validate() error {
+	if d.Email != "" || d.Enabled {
+		if _, err := mail.ParseAddress(d.Email); err != nil {
+			return fmt.Errorf("invalid email '%s': %w", d.Email, err)
+		}
+	}
+	if d.SendAt < 0 || d.SendAt >= 24*60 {
+		return fmt.Errorf("invalid send time: %d", d.SendAt)
+	}
+	return validateTimezone(d.Timezone)
+}
+
+// GetUserDigest is the user's digest settings, users that haven't opted in get the defaults.
+func GetUserDigest(ctx context.Context, db cdb.DBTX, userID string) (UserDigest, error) {
+	row, err := cdb.New(db).GetUserDigest(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return UserDigest{SendAt: defaultDigestSendAt, Timezone: "UTC"}, nil
+	} else if err != nil {
+		return UserDigest{}, fmt.Errorf("getting digest: %w", err)
+	}
+	return UserDigestFromDb(row), nil
+}
+
+func SetUserDigest(ctx context.Context, db cdb.DBTX, userID string, d UserDigest) error {
+	if err := d.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if err := cdb.New(db).SetUserDigest(ctx, cdb.SetUserDigestParams{
+		UserID:    userID,
+		Enabled:   boolToInt(d.Enabled),
+		Email:     d.Email,
+		SendAt:    d.SendAt,
+		Timezone:  d.Timezone,
+		UpdatedAt: time.Now().UnixMilli(),
+	}); err != nil {
+		return fmt.Errorf("setting digest: %w", err)
+	}
+	return nil
+}
+
+type DigestList struct {
+	ID       string
+	Name     string
+	Sections []Section
+}
+
+// DigestView is the content of a digest email, the overdue and today's chores of each of the user's lists.
+type DigestView struct {
+	Name    string
+	Today   date.Date
+	BaseURL string
+	Lists   []DigestList
+}
+
+func (v *DigestView) ChoreCount() int {
+	count := 0
+	for _, l := range v.Lists {
+		for _, s := range l.Sections {
+			count += len(s.Chores)
+		}
+	}
+	return count
+}
+
+// URL is the absolute URL of the path, or empty if the base URL of the app isn't configured.
+func (v *DigestView) URL(path string) string {
+	if v.BaseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(v.BaseURL, "/") + path
+}
+
+func (v *DigestView) Subject() string {
+	return fmt.Sprintf("%d chores to do on %s", v.ChoreCount(), v.Today)
+}
+
+// BuildDigest collects the overdue and today's chores of the user's lists, grouped like the list page with today in
+// each list's time zone.
+func BuildDigest(ctx context.Context, db *sql.DB, userID, name string, today date.Date, now time.Time) (*DigestView, error) {
+	lists, err := cdb.New(db).GetChoreListsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting chore lists: %w", err)
+	}
+	v := &DigestView{Name: name, Today: today}
+	for _, l := range lists {
+		chores, err := GetListChores(ctx, db, l.ID)
+		if err != nil {
+			return nil, err
+		}
+		var sections []Section
+		for _, s := range NewListView(TodayIn(now, Location(l.Timezone)), chores).Sections() {
+			if s.LatestCompletion <= date.Zero && s.HasChores() {
+				sections = append(sections, s)
+			}
+		}
+		if len(sections) > 0 {
+			v.Lists = append(v.Lists, DigestList{ID: l.ID, Name: l.Name, Sections: sections})
+		}
+	}
+	return v, nil
+}
+
+// DigestSender sends the digests of the users whose send time has passed today in their time zone. Users without
+// chores to do don't get an email.
+type DigestSender struct {
+	DB      *sql.DB
+	View    *View
+	SMTP    SMTPConfig
+	BaseURL string
+	Now     func() time.Time
+}
+
+func NewDigestSender(db *sql.DB, view *View, smtpCfg SMTPConfig, baseURL string) *DigestSender {
+	return &DigestSender{DB: db, View: view, SMTP: smtpCfg, BaseURL: baseURL, Now: time.Now}
+}
+
+// Send sends the digests that are due, a digest that fails is retried the next time.
+func (s *DigestSender) Send(ctx context.Context) error {
+	q := cdb.New(s.DB)
+	rows, err := q.GetEnabledUserDigests(ctx)
+	if err != nil {
+		return fmt.Errorf("getting digests: %w", err)
+	}
+	now := s.Now()
+	var errs []error
+	for _, row := range rows {
+		loc := Location(row.Timezone)
+		today := TodayIn(now, loc)
+		local := now.In(loc)
+		if row.LastSentOn >= int64(today) || int64(local.Hour()*60+local.Minute()) < row.SendAt {
+			continue
+		}
+		if err := s.send(ctx, row, today, now); err != nil {
+			errs = append(errs, fmt.Errorf("sending digest of %s: %w", row.UserID, err))
+			continue
+		}
+		if err := q.SetUserDigestSent(ctx, cdb.SetUserDigestSentParams{UserID: row.UserID, LastSentOn: int64(today)}); err != nil {
+			errs = append(errs, fmt.Errorf("marking digest of %s sent: %w", row.UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *DigestSender) send(ctx context.Context, row cdb.GetEnabledUserDigestsRow, today date.Date, now time.Time) error {
+	d, err := BuildDigest(ctx, s.DB, row.UserID, row.DisplayName, today, now)
+	if err != nil {
+		return err
+	}
+	if d.ChoreCount() == 0 {
+		return nil
+	}
+	d.BaseURL = s.BaseURL
+	var text, html bytes.Buffer
+	if err := digestTextTemplate.Execute(&text, d); err != nil {
+		return fmt.Errorf("rendering text: %w", err)
+	}
+	if err := s.View.DigestEmail(&html, d); err != nil {
+		return fmt.Errorf("rendering html: %w", err)
+	}
+	return s.SMTP.Send(Email{To: row.Email, Subject: d.Subject(), Text: text.Bytes(), HTML: html.Bytes()}, now)
+}
+
+// Run sends the due digests every interval until the context is done.
+func (s *DigestSender) Run(ctx context.Context, interval time.Duration) {
+	logger := srvu.GetLogger(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Send(ctx); err != nil && ctx.Err() == nil {
+			logger.Printf("sending digests: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func UserDigestUpdateHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var inp UserDigest
+		if err := srvu.Decode(r, &inp, false); err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		if err := SetUserDigest(ctx, db, auth.MustGetSession(ctx).UserID, inp); err != nil {
+			return writeErr(err, "updating digest")
+		}
+		httpu.RedirectToNext(w, r, "/settings")
+		return nil
+	})
+}
@@ -0,0 +1,140 @@
+package core_test
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+// smtpSink is a local SMTP server that accepts every message and passes it on to the channel.
+func smtpSink(t *testing.T) (string, <-chan string) {
+	l := Must(net.Listen("tcp", "127.0.0.1:0"))
+	t.Cleanup(func() { l.Close() })
+	msgs := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			reply := func(line string) { Must(io.WriteString(conn, line+"\r\n")) }
+			reply("220 localhost sink")
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					break
+				}
+				switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+				case strings.HasPrefix(cmd, "DATA"):
+					reply("354 go ahead")
+					var msg strings.Builder
+					for {
+						l := Must(r.ReadString('\n'))
+						if l == ".\r\n" {
+							break
+						}
+						msg.WriteString(strings.TrimPrefix(l, "."))
+					}
+					msgs <- msg.String()
+					reply("250 ok")
+				case strings.HasPrefix(cmd, "QUIT"):
+					reply("221 bye")
+				default:
+					reply("250 ok")
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return l.Addr().String(), msgs
+}
+
+func TestDigest(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	addr, msgs := smtpSink(t)
+	tok := Must(client.NewToken(ctx))
+	home := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	Must(NewChoreList(ctx, client, tok, map[string]string{"name": "empty"}))
+	today := date.Today()
+	Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "dishes & pans", "choreType": core.ChoreTypeInterval, "choreListID": home.List.ID, "interval": "1d",
+	}))
+	Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "taxes", "choreType": core.ChoreTypeDate, "choreListID": home.List.ID, "date": today.Add(-2 * date.Day).String(), "repeats": "1",
+	}))
+	Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "windows", "choreType": core.ChoreTypeDate, "choreListID": home.List.ID, "date": today.Add(date.Week).String(), "repeats": "1",
+	}))
+
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", "/settings/digest", map[string]string{"enabled": "true", "email": "not an email", "sendAt": "07:00", "timezone": "UTC"}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected invalid emails to be rejected: %s", err)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", "/settings/digest", map[string]string{"enabled": "true", "email": "test@example.com", "sendAt": "07:30", "timezone": "UTC"}).DoAndExp(http.StatusSeeOther))
+	Must(NewChoreReq(ctx, client).Auth(tok).Get("/settings").DoAndExp(http.StatusOK))
+	if d := GetTpl[core.SettingsView](client.tmpl, "settings.page.gohtml").Digest; !d.Enabled || d.Email != "test@example.com" || d.SendAtTime() != "07:30" {
+		t.Fatalf("unexpected digest settings %+v", d)
+	}
+
+	midnight := today.ToStdTime().UTC()
+	sender := core.NewDigestSender(client.db, core.NewView(client.tmpl, ""), core.SMTPConfig{Addr: addr, From: "Chores <chores@example.com>"}, "https://chores.example.com")
+	sender.Now = func() time.Time { return midnight.Add(7 * time.Hour) }
+	Panic(sender.Send(ctx))
+	select {
+	case msg := <-msgs:
+		t.Fatalf("expected no digest before the send time, got %s", msg)
+	default:
+	}
+
+	sender.Now = func() time.Time { return midnight.Add(8 * time.Hour) }
+	Panic(sender.Send(ctx))
+	var raw string
+	select {
+	case raw = <-msgs:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a digest after the send time")
+	}
+	msg := Must(mail.ReadMessage(strings.NewReader(raw)))
+	if msg.Header.Get("To") != "<test@example.com>" || !strings.Contains(Must(new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))), "2 chores") {
+		t.Fatalf("unexpected headers %+v", msg.Header)
+	}
+	mediaType, params := Must2(mime.ParseMediaType(msg.Header.Get("Content-Type")))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("expected a multipart message, got %s", mediaType)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	text := string(Must(io.ReadAll(Must(mr.NextPart()))))
+	if html := Must(mr.NextPart()); !strings.HasPrefix(html.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("expected an html alternative, got %+v", html.Header)
+	}
+	for _, want := range []string{"home <https://chores.example.com/chore-lists/" + home.List.ID + ">", "Overdue", "- taxes (due " + today.Add(-2*date.Day).String() + ")", "Today", "- dishes & pans"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected the digest to contain %q, got\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "windows") || strings.Contains(text, "empty") {
+		t.Fatalf("expected only today's and overdue chores, got\n%s", text)
+	}
+	digest := GetTpl[*core.DigestView](client.tmpl, "digest.email.gohtml")
+	if len(digest.Lists) != 1 || len(digest.Lists[0].Sections) != 2 || digest.Lists[0].Sections[0].Title != "Overdue" {
+		t.Fatalf("expected the list page's sections, got %+v", digest.Lists)
+	}
+
+	Panic(sender.Send(ctx))
+	select {
+	case msg := <-msgs:
+		t.Fatalf("expected one digest a day, got %s", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
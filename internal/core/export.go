@@ -0,0 +1,464 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/sqlu"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+// ChoreListExportVersion is the version of the export format, it is bumped whenever the format changes in a way older
+// versions can't import.
+const ChoreListExportVersion = 1
+
+const maxExportSize = 32 << 20
+
+// ChoreListExport is a chore list with its chores and their full history, it refers to members and chores by their
+// IDs on the exporting instance. Durations are in days.
+type ChoreListExport struct {
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exportedAt"`
+	ExportedBy string          `json:"exportedBy"`
+	Name       string          `json:"name"`
+	Timezone   string          `json:"timezone,omitempty"`
+	Members    []APIMember     `json:"members"`
+	Chores     []ExportedChore `json:"chores"`
+}
+
+type ExportedChore struct {
+	ID             string                  `json:"id"`
+	Name           string                  `json:"name"`
+	ChoreType      string                  `json:"choreType"`
+	Link           string                  `json:"link,omitempty"`
+	CreatedAt      date.Date               `json:"createdAt"`
+	CreatedBy      string                  `json:"createdBy"`
+	Interval       int64                   `json:"interval,omitempty"`
+	Recurrence     string                  `json:"recurrence,omitempty"`
+	RepeatsLeft    int64                   `json:"repeatsLeft"`
+	LastCompletion *date.Date              `json:"lastCompletion,omitempty"`
+	SnoozedFor     int64                   `json:"snoozedFor,omitempty"`
+	AssignedTo     string                  `json:"assignedTo,omitempty"`
+	Assignment     string                  `json:"assignment,omitempty"`
+	AutoComplete   bool                    `json:"autoComplete,omitempty"`
+	Effort         int64                   `json:"effort"`
+	Checklist      []ExportedChecklistItem `json:"checklist,omitempty"`
+	Prerequisites  []string                `json:"prerequisites,omitempty"`
+	Events         []ExportedEvent         `json:"events"`
+}
+
+type ExportedChecklistItem struct {
+	Name      string `json:"name"`
+	Checked   bool   `json:"checked,omitempty"`
+	CheckedBy string `json:"checkedBy,omitempty"`
+}
+
+type ExportedEvent struct {
+	EventType     string    `json:"eventType"`
+	OccurredAt    date.Date `json:"occurredAt"`
+	Duration      int64     `json:"duration,omitempty"`
+	CreatedBy     string    `json:"createdBy"`
+	CreatedByName string    `json:"createdByName"`
+}
+
+// ExportChoreList exports the list with the chores' checklists, prerequisites and history.
+func ExportChoreList(ctx context.Context, db cdb.DBTX, userID, choreListID string, now time.Time) (*ChoreListExport, error) {
+	q := cdb.New(db)
+	list, err := q.GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: choreListID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("getting chore list: %w", err)
+	}
+	members, err := q.GetChoreListMembers(ctx, list.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting members: %w", err)
+	}
+	rows, err := q.GetChoresByList(ctx, list.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting chores: %w", err)
+	}
+	checklists, err := q.GetChoreListChecklists(ctx, list.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting checklists: %w", err)
+	}
+	dependencies, err := q.GetChoreListDependencies(ctx, list.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting prerequisites: %w", err)
+	}
+	events, err := q.GetChoreListEvents(ctx, cdb.GetChoreListEventsParams{UserID: userID, ChoreListID: list.ID})
+	if err != nil {
+		return nil, fmt.Errorf("getting events: %w", err)
+	}
+	chores := make([]ExportedChore, len(rows))
+	index := make(map[string]int, len(rows))
+	for i, row := range rows {
+		index[row.ID] = i
+		chores[i] = ExportedChore{
+			ID:           row.ID,
+			Name:         row.Name,
+			ChoreType:    row.ChoreType,
+			Link:         row.Link.String,
+			CreatedAt:    date.Date(row.CreatedAt),
+			CreatedBy:    row.CreatedBy,
+			Interval:     row.Interval,
+			Recurrence:   row.Recurrence,
+			RepeatsLeft:  row.RepeatsLeft,
+			SnoozedFor:   row.SnoozedFor,
+			AssignedTo:   row.AssignedTo.String,
+			Assignment:   row.Assignment,
+			AutoComplete: row.AutoComplete != 0,
+			Effort:       row.Effort,
+			Events:       []ExportedEvent{},
+		}
+		if row.LastCompletion != 0 {
+			lastCompletion := date.Date(row.LastCompletion)
+			chores[i].LastCompletion = &lastCompletion
+		}
+	}
+	for _, item := range checklists {
+		c := &chores[index[item.ChoreID]]
+		c.Checklist = append(c.Checklist, ExportedChecklistItem{Name: item.Name, Checked: item.Checked != 0, CheckedBy: item.CheckedBy.String})
+	}
+	for _, dep := range dependencies {
+		c := &chores[index[dep.ChoreID]]
+		c.Prerequisites = append(c.Prerequisites, dep.DependsOnID)
+	}
+	for _, e := range events {
+		c := &chores[index[e.ChoreID]]
+		c.Events = append(c.Events, ExportedEvent{
+			EventType:     e.EventType,
+			OccurredAt:    date.Date(e.OccurredAt),
+			Duration:      e.Duration,
+			CreatedBy:     e.CreatedBy,
+			CreatedByName: e.CreatedByName,
+		})
+	}
+	return &ChoreListExport{
+		Version:    ChoreListExportVersion,
+		ExportedAt: now.UTC(),
+		ExportedBy: userID,
+		Name:       list.Name,
+		Timezone:   list.Timezone,
+		Members:    NewAPIMembers(members),
+		Chores:     chores,
+	}, nil
+}
+
+func (e *ChoreListExport) validate() error {
+	if e.Version != ChoreListExportVersion {
+		return fmt.Errorf("unsupported export version %d, expected %d", e.Version, ChoreListExportVersion)
+	}
+	if e.Name == "" {
+		return fmt.Errorf("missing list name")
+	}
+	if e.Timezone != "" {
+		if err := validateTimezone(e.Timezone); err != nil {
+			return err
+		}
+	}
+	ids := make(map[string]bool, len(e.Chores))
+	for _, c := range e.Chores {
+		if c.ID == "" || ids[c.ID] {
+			return fmt.Errorf("missing or duplicate chore id '%s'", c.ID)
+		}
+		ids[c.ID] = true
+	}
+	latest := date.FromTime(e.ExportedAt).Add(date.Day)
+	for _, c := range e.Chores {
+		if c.CreatedAt.IsZero() {
+			return fmt.Errorf("chore %s: missing createdAt", c.ID)
+		}
+		if c.Interval < 0 || c.SnoozedFor < 0 {
+			return fmt.Errorf("chore %s: negative interval or snoozedFor", c.ID)
+		}
+		for _, p := range c.Prerequisites {
+			if !ids[p] || p == c.ID {
+				return fmt.Errorf("chore %s: unknown prerequisite %s", c.ID, p)
+			}
+		}
+		for _, ev := range c.Events {
+			switch ev.EventType {
+			case EventTypeComplete, EventTypeSnooze, EventTypeExpedite:
+			default:
+				return fmt.Errorf("chore %s: illegal event type: %s", c.ID, ev.EventType)
+			}
+			if ev.OccurredAt.IsZero() || ev.OccurredAt.After(latest) {
+				return fmt.Errorf("chore %s: event occurred at %s, outside of the history up to the export", c.ID, ev.OccurredAt)
+			}
+			if ev.Duration < 0 {
+				return fmt.Errorf("chore %s: negative event duration: %d", c.ID, ev.Duration)
+			}
+		}
+	}
+	graph := make(map[string][]string, len(e.Chores))
+	for _, c := range e.Chores {
+		graph[c.ID] = c.Prerequisites
+	}
+	for _, c := range e.Chores {
+		if cycle := FindDependencyCycle(graph, c.ID); cycle != nil {
+			return fmt.Errorf("chore %s: prerequisites form a cycle", c.ID)
+		}
+	}
+	return nil
+}
+
+// exportAuthors maps the users of an export to users of this instance. The exporting user becomes the importing user,
+// everyone else's history is attributed to the importing user under the name they had in the export.
+type exportAuthors struct {
+	userID     string
+	exportedBy string
+	names      map[string]string
+}
+
+// user is the importing user if id is the exporting user, or empty for everyone else.
+func (a *exportAuthors) user(id string) string {
+	if id == a.exportedBy {
+		return a.userID
+	}
+	return ""
+}
+
+// authorName is the name kept on the history of id, empty if it is the exporting user's own under their own name.
+func (a *exportAuthors) authorName(id, name string) string {
+	if id == a.exportedBy && (name == "" || name == a.names[id]) {
+		return ""
+	}
+	return Coalesce(Coalesce(name, a.names[id]), "former member")
+}
+
+// ImportChoreList recreates the exported list as a new list of the user, every chore and event is given a new ID. The
+// user is the only member of the new list, chores assigned to other members are unassigned and their history keeps
+// their names.
+func ImportChoreList(ctx context.Context, db *sql.DB, userID string, export ChoreListExport) (cdb.ChoreList, error) {
+	if err := export.validate(); err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	now := time.Now()
+	list, err := createChoreList(ctx, q, userID, export.Name, now)
+	if err != nil {
+		return cdb.ChoreList{}, err
+	}
+	if export.Timezone != "" {
+		if list, err = SetChoreListTimezone(ctx, tx, userID, list.ID, export.Timezone); err != nil {
+			return cdb.ChoreList{}, err
+		}
+	}
+	authors := &exportAuthors{userID: userID, exportedBy: export.ExportedBy, names: map[string]string{}}
+	for _, m := range export.Members {
+		authors.names[m.ID] = m.DisplayName
+	}
+	choreIDs := make(map[string]string, len(export.Chores))
+	for _, c := range export.Chores {
+		choreIDs[c.ID] = NewId()
+	}
+	for _, c := range export.Chores {
+		if err := importChore(ctx, q, authors, list.ID, choreIDs, c); err != nil {
+			return cdb.ChoreList{}, fmt.Errorf("importing chore %s: %w", c.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("committing tx: %w", err)
+	}
+	return list, nil
+}
+
+// input is the exported chore as the input creating it in the list. Finished chores are the input of their last
+// repeat.
+func (c ExportedChore) input(choreListID string) (Input, error) {
+	input := Input{
+		Name:          c.Name,
+		ChoreType:     c.ChoreType,
+		ChoreListID:   choreListID,
+		Interval:      date.Duration(c.Interval),
+		Repeats:       c.RepeatsLeft,
+		Link:          c.Link,
+		AssignedTo:    c.AssignedTo,
+		Assignment:    c.Assignment,
+		AutoComplete:  c.AutoComplete,
+		Prerequisites: c.Prerequisites,
+		Effort:        c.Effort,
+	}
+	if input.Repeats == 0 {
+		input.Repeats = 1
+	}
+	if c.Recurrence != "" {
+		var err error
+		if input.Recurrence, err = ParseRecurrence(c.Recurrence); err != nil {
+			return input, err
+		}
+	}
+	if c.ChoreType == ChoreTypeDate && c.LastCompletion != nil {
+		input.Date = *c.LastCompletion
+	}
+	for _, item := range c.Checklist {
+		input.Checklist = append(input.Checklist, item.Name)
+	}
+	return input, nil
+}
+
+func importChore(ctx context.Context, q *cdb.Queries, authors *exportAuthors, choreListID string, choreIDs map[string]string, c ExportedChore) error {
+	input, err := c.input(choreListID)
+	if err == nil {
+		err = input.Validate(nil)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	assignedTo, assignment := "", AssignmentNone
+	if c.AssignedTo != "" && authors.user(c.AssignedTo) != "" {
+		assignedTo, assignment = authors.userID, c.Assignment
+	}
+	var lastCompletion date.Date
+	if c.LastCompletion != nil {
+		lastCompletion = *c.LastCompletion
+	}
+	if _, err := q.CreateChore(ctx, cdb.CreateChoreParams{
+		ID:             choreIDs[c.ID],
+		Name:           c.Name,
+		ChoreType:      c.ChoreType,
+		CreatedAt:      int64(c.CreatedAt),
+		ChoreListID:    choreListID,
+		Interval:       int64(input.Interval),
+		LastCompletion: int64(lastCompletion),
+		SnoozedFor:     c.SnoozedFor,
+		RepeatsLeft:    c.RepeatsLeft,
+		CreatedBy:      authors.userID,
+		Link:           sqlu.NullString(input.Link),
+		Recurrence:     input.Recurrence.String(),
+		AssignedTo:     sqlu.NullString(assignedTo),
+		Assignment:     assignment,
+		AutoComplete:   boolToInt(c.AutoComplete),
+		Effort:         c.Effort,
+	}); err != nil {
+		return fmt.Errorf("creating chore: %w", err)
+	}
+	for i, item := range c.Checklist {
+		var checkedBy string
+		if item.Checked && item.CheckedBy != "" {
+			checkedBy = authors.user(item.CheckedBy)
+		}
+		if err := q.CreateChoreChecklistItem(ctx, cdb.CreateChoreChecklistItemParams{
+			ID:        NewId(),
+			ChoreID:   choreIDs[c.ID],
+			Position:  int64(i),
+			Name:      item.Name,
+			Checked:   boolToInt(item.Checked),
+			CheckedBy: sqlu.NullString(checkedBy),
+		}); err != nil {
+			return fmt.Errorf("creating checklist item: %w", err)
+		}
+	}
+	for _, p := range c.Prerequisites {
+		if err := q.CreateChoreDependency(ctx, cdb.CreateChoreDependencyParams{ChoreID: choreIDs[c.ID], DependsOnID: choreIDs[p]}); err != nil {
+			return fmt.Errorf("creating prerequisite: %w", err)
+		}
+	}
+	for _, ev := range c.Events {
+		if err := q.CreateChoreEvent(ctx, cdb.CreateChoreEventParams{
+			ID:         NewId(),
+			ChoreID:    choreIDs[c.ID],
+			EventType:  ev.EventType,
+			CreatedBy:  authors.userID,
+			OccurredAt: int64(ev.OccurredAt),
+			Duration:   ev.Duration,
+			AuthorName: sqlu.NullString(authors.authorName(ev.CreatedBy, ev.CreatedByName)),
+		}); err != nil {
+			return fmt.Errorf("creating event: %w", err)
+		}
+	}
+	return nil
+}
+
+// decodeChoreListExport reads the export from an uploaded file or from a JSON body.
+func decodeChoreListExport(w http.ResponseWriter, r *http.Request) (ChoreListExport, error) {
+	var export ChoreListExport
+	r.Body = http.MaxBytesReader(w, r.Body, maxExportSize)
+	var body io.Reader = r.Body
+	file, _, err := r.FormFile("file")
+	if err == nil {
+		defer file.Close()
+		body = file
+	} else if !errors.Is(err, http.ErrNotMultipart) && !errors.Is(err, http.ErrMissingFile) {
+		return export, err
+	}
+	if err := json.NewDecoder(body).Decode(&export); err != nil {
+		return export, fmt.Errorf("decoding export: %w", err)
+	}
+	return export, nil
+}
+
+func writeChoreListExport(ctx context.Context, w http.ResponseWriter, db *sql.DB, userID, choreListID string) error {
+	export, err := ExportChoreList(ctx, db, userID, choreListID, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return srvu.Err(http.StatusNotFound, err)
+	} else if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": export.Name + ".json"}))
+	return writeJSON(w, http.StatusOK, export)
+}
+
+func ChoreListExportHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return writeChoreListExport(ctx, w, db, auth.MustGetSession(ctx).UserID, r.PathValue("choreListID"))
+	})
+}
+
+// ChoreListFromExportHandler creates a new list from an uploaded export.
+func ChoreListFromExportHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		export, err := decodeChoreListExport(w, r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		cl, err := ImportChoreList(ctx, db, auth.MustGetSession(ctx).UserID, export)
+		if err != nil {
+			return writeErr(err, "importing the chore list")
+		}
+		http.Redirect(w, r, fmt.Sprintf("/chore-lists/%s", cl.ID), http.StatusSeeOther)
+		return nil
+	})
+}
+
+func APIChoreListExportHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if err := authorizeList(ctx, r.PathValue("choreListID")); err != nil {
+			return err
+		}
+		return writeChoreListExport(ctx, w, db, auth.MustGetSession(ctx).UserID, r.PathValue("choreListID"))
+	})
+}
+
+func APIChoreListImportHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		session := auth.MustGetSession(ctx)
+		if !session.Scope.AllowsAllLists() {
+			return srvu.Err(http.StatusForbidden, fmt.Errorf("token is restricted to specific chore lists"))
+		}
+		export, err := decodeChoreListExport(w, r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		cl, err := ImportChoreList(ctx, db, session.UserID, export)
+		if err != nil {
+			return writeErr(err, "importing the chore list")
+		}
+		w.Header().Set("Location", fmt.Sprintf("/api/chore-lists/%s", cl.ID))
+		return writeJSON(w, http.StatusCreated, APIChoreList{ID: cl.ID, Name: cl.Name, Timezone: cl.Timezone})
+	})
+}
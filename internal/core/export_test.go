@@ -0,0 +1,140 @@
+package core_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func TestChoreListExport(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	Panic(client.AddMember(ctx, cl.List.ID, "other"))
+	today := date.Today()
+	dishes := Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "dishes", "choreType": core.ChoreTypeInterval, "choreListID": cl.List.ID, "interval": "38w4d",
+		"checklist": "rinse\nload", "assignedTo": "other",
+	}))
+	Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "unload", "choreType": core.ChoreTypeInterval, "choreListID": cl.List.ID, "interval": "1d",
+		"prerequisites": dishes.ID, "assignedTo": "test", "assignment": core.AssignmentRoundRobin,
+	}))
+	Panic(core.Snooze(ctx, client.db, today, "test", dishes.ID, 2*date.Day, 0))
+	Panic(core.Complete(ctx, client.db, "other", dishes.ID, today.Add(-2*date.Day), 0))
+	Panic(core.Complete(ctx, client.db, "test", dishes.ID, today, 0))
+
+	exported := Must(decodeJSON[core.ChoreListExport](NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/export", cl.List.ID)).DoAndExp(http.StatusOK)))
+	if exported.Version != core.ChoreListExportVersion || exported.ExportedBy != "test" || len(exported.Members) != 2 || len(exported.Chores) != 2 {
+		t.Fatalf("unexpected export %+v", exported)
+	}
+	exportedDishes := *findInSlice(exported.Chores, func(c core.ExportedChore) bool { return c.ID == dishes.ID })
+	if len(exportedDishes.Events) != 3 || len(exportedDishes.Checklist) != 2 || exportedDishes.Interval != 270 {
+		t.Fatalf("unexpected exported chore %+v", exportedDishes)
+	}
+
+	countUsers := func() (n int) {
+		Panic(client.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM user").Scan(&n))
+		return n
+	}
+	users := countUsers()
+	body := readBody(Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/export", cl.List.ID)).DoAndExp(http.StatusOK)))
+	res := Must(uploadReq(ctx, client, tok, "/chore-lists/import", "home.json", body).DoAndExp(http.StatusSeeOther))
+	newID := strings.TrimPrefix(res.Header.Get("Location"), "/chore-lists/")
+	if newID == cl.List.ID || newID == "" {
+		t.Fatalf("expected a new list, got %s", res.Header.Get("Location"))
+	}
+	imported := Must(core.ExportChoreList(ctx, client.db, "test", newID, time.Now()))
+	if imported.Name != "home" || len(imported.Members) != 1 || len(imported.Chores) != 2 {
+		t.Fatalf("unexpected imported list %+v", imported)
+	}
+	importedDishes := *findInSlice(imported.Chores, func(c core.ExportedChore) bool { return c.Name == "dishes" })
+	importedUnload := *findInSlice(imported.Chores, func(c core.ExportedChore) bool { return c.Name == "unload" })
+	if importedDishes.ID == dishes.ID || importedDishes.AssignedTo != "" || importedDishes.Interval != 270 || *importedDishes.LastCompletion != today {
+		t.Fatalf("expected the chore to be recreated unassigned, got %+v", importedDishes)
+	}
+	if len(importedUnload.Prerequisites) != 1 || importedUnload.Prerequisites[0] != importedDishes.ID || importedUnload.AssignedTo != "test" || importedUnload.Assignment != core.AssignmentRoundRobin {
+		t.Fatalf("expected the prerequisites and own assignment to be kept, got %+v", importedUnload)
+	}
+	if len(importedDishes.Events) != 3 {
+		t.Fatalf("expected the history to be imported, got %+v", importedDishes.Events)
+	}
+	for i, e := range importedDishes.Events {
+		orig := exportedDishes.Events[i]
+		if e.EventType != orig.EventType || e.OccurredAt != orig.OccurredAt || e.Duration != orig.Duration || e.CreatedByName != orig.CreatedByName {
+			t.Fatalf("expected event %+v to match %+v", e, orig)
+		}
+		if e.CreatedBy != "test" {
+			t.Fatalf("expected the history to be attributed to the importing user under the exported names, got %+v", e)
+		}
+	}
+	if n := countUsers(); n != users {
+		t.Fatalf("expected no users to be created by the import, got %d instead of %d", n, users)
+	}
+	chart := func(chartType string) map[string]float64 {
+		data := Must(decodeJSON[core.ChoreListDataView](NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/charts/%s", newID, chartType)).DoAndExp(http.StatusOK)))
+		values := make(map[string]float64)
+		for _, c := range data.Categories {
+			values[c.Name] = c.Value
+		}
+		return values
+	}
+	if byMember := chart("completions_by_member"); len(byMember) != 2 || byMember["test"] != 1 || byMember["other"] != 1 {
+		t.Fatalf("expected the completions to be kept per member, got %+v", byMember)
+	}
+	if onTime := chart("on_time_ratio"); len(onTime) != 2 {
+		t.Fatalf("expected an on time ratio per member, got %+v", onTime)
+	}
+	leaderboard := Must(core.GetLeaderboard(ctx, client.db, newID, today, core.LeaderboardWindows[3]))
+	if len(leaderboard.Entries) != 1 || leaderboard.Entries[0].UserID != "test" || leaderboard.Entries[0].Completions != 1 {
+		t.Fatalf("expected the importing user to only be credited with their own completions, got %+v", leaderboard.Entries)
+	}
+
+	exported.Version = core.ChoreListExportVersion + 1
+	if _, err := apiReq(ctx, client, tok, "POST", "/api/chore-lists/import", exported).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected unsupported versions to be rejected: %s", err)
+	}
+	exported.Version = core.ChoreListExportVersion
+	exported.Chores[0].Prerequisites = []string{exported.Chores[1].ID}
+	exported.Chores[1].Prerequisites = []string{exported.Chores[0].ID}
+	if _, err := apiReq(ctx, client, tok, "POST", "/api/chore-lists/import", exported).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected cyclic prerequisites to be rejected: %s", err)
+	}
+	exported.Chores[0].Prerequisites = nil
+	for name, invalidate := range map[string]func(c *core.ExportedChore){
+		"zero interval": func(c *core.ExportedChore) { c.ChoreType, c.Interval = core.ChoreTypeInterval, 0 },
+		"missing recurrence": func(c *core.ExportedChore) {
+			c.ChoreType, c.Interval, c.Recurrence = core.ChoreTypeDateRepeating, 0, ""
+		},
+		"negative repeats":       func(c *core.ExportedChore) { c.RepeatsLeft = -2 },
+		"negative snooze":        func(c *core.ExportedChore) { c.SnoozedFor = -1 },
+		"negative effort":        func(c *core.ExportedChore) { c.Effort = -1 },
+		"event after the export": func(c *core.ExportedChore) { c.Events[0].OccurredAt = today.Add(7 * date.Day) },
+		"event without a date":   func(c *core.ExportedChore) { c.Events[0].OccurredAt = 0 },
+	} {
+		invalid := exported
+		invalid.Chores = append([]core.ExportedChore{}, exported.Chores...)
+		for i := range invalid.Chores {
+			if invalid.Chores[i].ID == dishes.ID {
+				invalid.Chores[i].Events = append([]core.ExportedEvent{}, invalid.Chores[i].Events...)
+				invalidate(&invalid.Chores[i])
+			}
+		}
+		if _, err := apiReq(ctx, client, tok, "POST", "/api/chore-lists/import", invalid).DoAndExp(http.StatusBadRequest); err != nil {
+			t.Fatalf("expected an export with a %s to be rejected: %s", name, err)
+		}
+	}
+	created := Must(decodeJSON[core.APIChoreList](apiReq(ctx, client, tok, "POST", "/api/chore-lists/import", exported).DoAndExp(http.StatusCreated)))
+	if created.Name != "home" || created.ID == newID {
+		t.Fatalf("unexpected imported list %+v", created)
+	}
+	if _, err := apiReq(ctx, client, tok, "GET", "/api/chore-lists/unknown/export", nil).DoAndExp(http.StatusNotFound); err != nil {
+		t.Fatalf("expected exporting unknown lists to fail: %s", err)
+	}
+}
@@ -0,0 +1,184 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/chore-tracker/pkg/httpu"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/sid"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+const feedTokenLength = 32
+
+// FeedPath is the path of the calendar feed, the token is the only credential so it has to stay secret.
+func FeedPath(token string) string {
+	return fmt.Sprintf("/feeds/%s.ics", token)
+}
+
+func GetChoreListFeed(ctx context.Context, db cdb.DBTX, userID, choreListID string) (*cdb.ChoreListFeed, error) {
+	feed, err := cdb.New(db).GetChoreListFeed(ctx, cdb.GetChoreListFeedParams{ChoreListID: choreListID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting feed: %w", err)
+	}
+	return &feed, nil
+}
+
+// RotateChoreListFeed creates the member's feed of the list, or replaces its token so the previous URL stops working.
+// Every member has their own feed so rotating it doesn't affect anyone else.
+func RotateChoreListFeed(ctx context.Context, db cdb.DBTX, userID, choreListID string) (cdb.ChoreListFeed, error) {
+	q := cdb.New(db)
+	if _, err := q.GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: choreListID, UserID: userID}); err != nil {
+		return cdb.ChoreListFeed{}, fmt.Errorf("getting chore list: %w", err)
+	}
+	token, err := sid.NewString(feedTokenLength)
+	if err != nil {
+		return cdb.ChoreListFeed{}, fmt.Errorf("generating token: %w", err)
+	}
+	feed, err := q.UpsertChoreListFeed(ctx, cdb.UpsertChoreListFeedParams{
+		Token:       token,
+		ChoreListID: choreListID,
+		UserID:      userID,
+		CreatedAt:   time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return cdb.ChoreListFeed{}, fmt.Errorf("storing feed: %w", err)
+	}
+	return feed, nil
+}
+
+func ChoreListFeedRotateHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		choreListID := r.PathValue("choreListID")
+		if _, err := RotateChoreListFeed(ctx, db, userID, choreListID); err != nil {
+			return srvu.Err(http.StatusForbidden, err)
+		}
+		httpu.RedirectToReferer(w, r, fmt.Sprintf("/chore-lists/%s/edit", choreListID))
+		return nil
+	})
+}
+
+func ChoreListFeedDeleteHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		choreListID := r.PathValue("choreListID")
+		if err := cdb.New(db).DeleteChoreListFeed(ctx, cdb.DeleteChoreListFeedParams{ChoreListID: choreListID, UserID: userID}); err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		httpu.RedirectToReferer(w, r, fmt.Sprintf("/chore-lists/%s/edit", choreListID))
+		return nil
+	})
+}
+
+func GetUserFeed(ctx context.Context, db cdb.DBTX, userID string) (*cdb.UserFeed, error) {
+	feed, err := cdb.New(db).GetUserFeed(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting feed: %w", err)
+	}
+	return &feed, nil
+}
+
+// RotateUserFeed creates the user's personal feed of all their lists, or replaces its token so the previous URL stops
+// working.
+func RotateUserFeed(ctx context.Context, db cdb.DBTX, userID string) (cdb.UserFeed, error) {
+	token, err := sid.NewString(feedTokenLength)
+	if err != nil {
+		return cdb.UserFeed{}, fmt.Errorf("generating token: %w", err)
+	}
+	feed, err := cdb.New(db).UpsertUserFeed(ctx, cdb.UpsertUserFeedParams{
+		Token:     token,
+		UserID:    userID,
+		CreatedAt: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return cdb.UserFeed{}, fmt.Errorf("storing feed: %w", err)
+	}
+	return feed, nil
+}
+
+func UserFeedRotateHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if _, err := RotateUserFeed(ctx, db, auth.MustGetSession(ctx).UserID); err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		httpu.RedirectToNext(w, r, "/settings")
+		return nil
+	})
+}
+
+func UserFeedDeleteHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if err := cdb.New(db).DeleteUserFeed(ctx, auth.MustGetSession(ctx).UserID); err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		httpu.RedirectToNext(w, r, "/settings")
+		return nil
+	})
+}
+
+// renderUserIcs renders the chores of all the user's lists as one calendar, with the list of each chore as its
+// category.
+func renderUserIcs(ctx context.Context, w http.ResponseWriter, r *http.Request, db *sql.DB, view *View, userID string) error {
+	opts, err := ParseIcsOptions(r)
+	if err != nil {
+		return srvu.Err(http.StatusBadRequest, err)
+	}
+	q := cdb.New(db)
+	lists, err := q.GetChoreListsByUser(ctx, userID)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	var chores []Chore
+	names := make(map[string]string, len(lists))
+	for _, l := range lists {
+		rows, err := q.GetChoresByList(ctx, l.ID)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		chores = append(chores, ChoresFromDb(rows)...)
+		names[l.ID] = l.Name
+	}
+	return view.ChoreListIcs(w, r, &ChoreListIcsView{
+		ID:        userID,
+		Name:      "chores",
+		Today:     date.Today(),
+		Chores:    filterIcsChores(opts, userID, chores),
+		ListNames: names,
+		Now:       time.Now(),
+		Options:   opts,
+	})
+}
+
+// FeedHandler serves calendar feeds, it is unauthenticated as calendar apps can't log in, the token in the URL is the
+// credential. The token is either of a member's feed of one list or of a user's personal feed of all their lists.
+func FeedHandler(db *sql.DB, view *View) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		token := strings.TrimSuffix(r.PathValue("file"), ".ics")
+		q := cdb.New(db)
+		if feed, err := q.GetChoreListFeedByToken(ctx, token); err == nil {
+			return renderChoreListIcs(ctx, w, r, db, view, feed.UserID, feed.ChoreListID)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		feed, err := q.GetUserFeedByToken(ctx, token)
+		if errors.Is(err, sql.ErrNoRows) {
+			return srvu.Err(http.StatusNotFound, fmt.Errorf("unknown feed"))
+		} else if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		return renderUserIcs(ctx, w, r, db, view, feed.UserID)
+	})
+}
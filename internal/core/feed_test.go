@@ -0,0 +1,103 @@
+package core_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+)
+
+func TestChoreListFeed(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	Panic(client.AddMember(ctx, cl.List.ID, "other"))
+	editPath := fmt.Sprintf("/chore-lists/%s/edit", cl.List.ID)
+	getFeed := func() core.ChoreListEditView {
+		Must(NewChoreReq(ctx, client).Auth(tok).Get(editPath).DoAndExp(http.StatusOK))
+		return GetTpl[core.ChoreListEditView](client.tmpl, "chore_list_edit.page.gohtml")
+	}
+	if edit := getFeed(); edit.Feed != nil || edit.FeedURL() != "" {
+		t.Fatalf("expected no feed before creating one, got %+v", edit.Feed)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chore-lists/%s/feed", cl.List.ID), nil).DoAndExp(http.StatusSeeOther))
+	first := getFeed()
+	if first.Feed == nil || first.FeedURL() != "https://chores.example.com"+core.FeedPath(first.Feed.Token) {
+		t.Fatalf("expected a feed URL on the base URL, got %+v", first.Feed)
+	}
+	res := Must(NewChoreReq(ctx, client).Get(core.FeedPath(first.Feed.Token)).DoAndExp(http.StatusOK))
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Fatalf("expected a calendar, got %s", ct)
+	}
+
+	other := Must(core.RotateChoreListFeed(ctx, client.db, "other", cl.List.ID))
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chore-lists/%s/feed", cl.List.ID), nil).DoAndExp(http.StatusSeeOther))
+	rotated := getFeed()
+	if rotated.Feed.Token == first.Feed.Token {
+		t.Fatalf("expected the token to be rotated")
+	}
+	if _, err := NewChoreReq(ctx, client).Get(core.FeedPath(first.Feed.Token)).DoAndExp(http.StatusNotFound); err != nil {
+		t.Fatalf("expected the old feed URL to be revoked: %s", err)
+	}
+	if _, err := NewChoreReq(ctx, client).Get(core.FeedPath(other.Token)).DoAndExp(http.StatusOK); err != nil {
+		t.Fatalf("expected the feeds of other members to be unaffected: %s", err)
+	}
+
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chore-lists/%s/feed/delete", cl.List.ID), nil).DoAndExp(http.StatusSeeOther))
+	if _, err := NewChoreReq(ctx, client).Get(core.FeedPath(rotated.Feed.Token)).DoAndExp(http.StatusNotFound); err != nil {
+		t.Fatalf("expected the deleted feed URL to be revoked: %s", err)
+	}
+	Panic(core.LeaveChoreList(ctx, client.db, "other", cl.List.ID))
+	if _, err := NewChoreReq(ctx, client).Get(core.FeedPath(other.Token)).DoAndExp(http.StatusNotFound); err != nil {
+		t.Fatalf("expected the feed to be revoked when leaving the list: %s", err)
+	}
+}
+
+func readBody(res *http.Response) string {
+	defer res.Body.Close()
+	return string(Must(io.ReadAll(res.Body)))
+}
+
+func TestUserFeed(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	home := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	work := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "work"}))
+	dishes := Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "dishes", "choreType": core.ChoreTypeInterval, "choreListID": home.List.ID, "interval": "1d", "assignedTo": "test",
+	}))
+	report := Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "report", "choreType": core.ChoreTypeInterval, "choreListID": work.List.ID, "interval": "1w",
+	}))
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", "/settings/feed", nil).DoAndExp(http.StatusSeeOther))
+	Must(NewChoreReq(ctx, client).Auth(tok).Get("/settings").DoAndExp(http.StatusOK))
+	settings := GetTpl[core.SettingsView](client.tmpl, "settings.page.gohtml")
+	if settings.Feed == nil || settings.AssignedFeedURL() != "https://chores.example.com"+core.FeedPath(settings.Feed.Token)+"?assigned=me" {
+		t.Fatalf("expected a personal feed, got %+v", settings.Feed)
+	}
+	path := core.FeedPath(settings.Feed.Token)
+
+	cal := readBody(Must(NewChoreReq(ctx, client).Get(path).DoAndExp(http.StatusOK)))
+	if strings.Count(cal, "BEGIN:VEVENT") != 2 {
+		t.Fatalf("expected the chores of both lists, got %s", cal)
+	}
+	for id, category := range map[string]string{dishes.ID: "home", report.ID: "work"} {
+		if !strings.Contains(cal, "UID:"+id+"@chore-tracker") || !strings.Contains(cal, "CATEGORIES:"+category) {
+			t.Fatalf("expected %s in category %s, got %s", id, category, cal)
+		}
+	}
+	assigned := readBody(Must(NewChoreReq(ctx, client).Get(path + "?assigned=me").DoAndExp(http.StatusOK)))
+	if strings.Count(assigned, "BEGIN:VEVENT") != 1 || !strings.Contains(assigned, "UID:"+dishes.ID+"@chore-tracker") {
+		t.Fatalf("expected only the assigned chore, got %s", assigned)
+	}
+
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", "/settings/feed", nil).DoAndExp(http.StatusSeeOther))
+	if _, err := NewChoreReq(ctx, client).Get(path).DoAndExp(http.StatusNotFound); err != nil {
+		t.Fatalf("expected the old feed URL to be revoked: %s", err)
+	}
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/chore-tracker/pkg/httpu"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+const (
+	EventTypeComplete = "complete"
+	EventTypeSnooze   = "snooze"
+	EventTypeExpedite = "expedite"
+)
+
+type ChoreEvent struct {
+	ID            string
+	ChoreID       string
+	EventType     string
+	OccurredAt    date.Date
+	Duration      date.Duration // how far a snooze or expedite moved the next completion
+	CreatedBy     string
+	CreatedByName string
+}
+
+func (e ChoreEvent) IsComplete() bool {
+	return e.EventType == EventTypeComplete
+}
+
+func (e ChoreEvent) IsSnooze() bool {
+	return e.EventType == EventTypeSnooze
+}
+
+func (e ChoreEvent) IsExpedite() bool {
+	return e.EventType == EventTypeExpedite
+}
+
+func ChoreEventsFromDb(rows []cdb.GetChoreEventsRow) []ChoreEvent {
+	events := make([]ChoreEvent, len(rows))
+	for i, row := range rows {
+		events[i] = ChoreEvent{
+			ID:            row.ID,
+			ChoreID:       row.ChoreID,
+			EventType:     row.EventType,
+			OccurredAt:    date.Date(row.OccurredAt),
+			Duration:      date.Duration(row.Duration),
+			CreatedBy:     row.CreatedBy,
+			CreatedByName: row.CreatedByName,
+		}
+	}
+	return events
+}
+
+func GetEvents(ctx context.Context, db cdb.DBTX, userID, choreID string) ([]ChoreEvent, error) {
+	q := cdb.New(db)
+	if _, err := get(ctx, q, userID, choreID); err != nil {
+		return nil, fmt.Errorf("getting chore: %w", err)
+	}
+	rows, err := q.GetChoreEvents(ctx, choreID)
+	if err != nil {
+		return nil, fmt.Errorf("querying events of chore %s: %w", choreID, err)
+	}
+	return ChoreEventsFromDb(rows), nil
+}
+
+// Uncomplete removes the completion event and, when it was the latest, rolls the chore back to where it was before it,
+// giving back the repeat the completion used up.
+func Uncomplete(ctx context.Context, db *sql.DB, userID, choreID, eventID string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	chore, err := get(ctx, q, userID, choreID)
+	if err != nil {
+		return fmt.Errorf("getting chore: %w", err)
+	}
+	deleted, err := q.DeleteChoreCompletion(ctx, cdb.DeleteChoreCompletionParams{ID: eventID, ChoreID: choreID})
+	if err != nil {
+		return fmt.Errorf("deleting completion %s: %w", eventID, err)
+	}
+	lastCompletion, err := q.GetLastChoreCompletion(ctx, choreID)
+	if err != nil {
+		return fmt.Errorf("getting last completion: %w", err)
+	}
+	switch {
+	case deleted.OccurredAt < lastCompletion:
+		// a later completion remains, for date repeating chores it may have used up a later occurrence.
+		if chore.IsDateRepeating() {
+			lastCompletion = int64(chore.LastCompletion)
+		}
+	case deleted.PreviousCompletion.Valid:
+		// the completion stored the chore's last completion from before it, which for date chores is their due date.
+		lastCompletion = deleted.PreviousCompletion.Int64
+	case lastCompletion == 0 && chore.IsDate():
+		// completions from before the previous completion was stored lose the date of a date chore, keeping the
+		// completion date makes the chore due again.
+		lastCompletion = int64(chore.LastCompletion)
+	}
+	if err := q.UncompleteChore(ctx, cdb.UncompleteChoreParams{ID: choreID, LastCompletion: lastCompletion}); err != nil {
+		return fmt.Errorf("updating last completion: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing tx: %w", err)
+	}
+	return nil
+}
+
+func ChoreHistoryPage(db *sql.DB, view *View) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := r.PathValue("id")
+		userID := auth.MustGetSession(ctx).UserID
+		if id == "" {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing id"))
+		}
+		chore, err := Get(ctx, db, userID, id)
+		if err != nil {
+			return srvu.Err(http.StatusNotFound, fmt.Errorf("getting chore from request: %w", err))
+		}
+		events, err := GetEvents(ctx, db, userID, id)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("getting chore events: %w", err))
+		}
+		return view.ChoreHistoryPage(w, r, ChoreHistoryView{
+			Chore:  *chore,
+			Events: events,
+		})
+	})
+}
+
+func ChoreEventDeleteHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := r.PathValue("id")
+		eventID := r.PathValue("eventID")
+		userID := auth.MustGetSession(ctx).UserID
+		if id == "" || eventID == "" {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing id"))
+		}
+		if err := Uncomplete(ctx, db, userID, id, eventID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return srvu.Err(http.StatusNotFound, err)
+			}
+			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("undoing completion: %w", err))
+		}
+		httpu.RedirectToNext(w, r, fmt.Sprintf("/chores/%s/history", id))
+		return nil
+	})
+}
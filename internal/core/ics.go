@@ -0,0 +1,197 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SimonSchneider/goslu/date"
+)
+
+// IcsOptions are how a calendar is rendered, they are query parameters of the calendar URL so they can be chosen when
+// subscribing.
+type IcsOptions struct {
+	// Todo renders the chores as to-dos instead of all-day events.
+	Todo bool
+	// Reminder is the time of day on the due date of an alarm, no alarm is added unless HasReminder.
+	Reminder    time.Duration
+	HasReminder bool
+	// AssignedOnly only keeps the chores assigned to the user the calendar is for.
+	AssignedOnly bool
+}
+
+func ParseIcsOptions(r *http.Request) (IcsOptions, error) {
+	query := r.URL.Query()
+	opts := IcsOptions{
+		Todo:         query.Get("todo") != "",
+		AssignedOnly: query.Get("assigned") == "me",
+	}
+	if reminder := query.Get("reminder"); reminder != "" {
+		t, err := time.Parse("15:04", reminder)
+		if err != nil {
+			return opts, fmt.Errorf("%w: reminder must be a time like 09:00", ErrInvalidInput)
+		}
+		opts.Reminder = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		opts.HasReminder = true
+	}
+	return opts, nil
+}
+
+// escapeIcsText escapes a TEXT value (RFC 5545 3.3.11).
+func escapeIcsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// foldIcsLine splits the content line into lines of at most 75 octets, continuation lines start with a space (RFC 5545
+// 3.1). Lines are only split between characters so multibyte characters stay intact.
+func foldIcsLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var b strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of continuation lines counts towards the limit
+		width = limit - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// prop writes the property, value has to be escaped already if it is text.
+func (w *icsWriter) prop(name, value string) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.w.WriteString(foldIcsLine(name+":"+value) + "\r\n")
+}
+
+func (w *icsWriter) text(name, value string) {
+	w.prop(name, escapeIcsText(value))
+}
+
+func icsDate(d date.Date) string {
+	return d.ToStdTime().Format("20060102")
+}
+
+func icsDateTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icsDuration formats a positive duration of at most a day as a DURATION value (RFC 5545 3.3.6).
+func icsDuration(d time.Duration) string {
+	return fmt.Sprintf("PT%dH%dM", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// Description summarises the chore's schedule and when it was last done.
+func (v *ChoreListIcsView) Description(c Chore) string {
+	var schedule string
+	switch {
+	case c.IsInterval():
+		schedule = "every " + c.Interval.String()
+	case c.IsDateRepeating():
+		schedule = c.Recurrence.String()
+	default:
+		schedule = "once"
+	}
+	if c.LastCompletion.IsZero() {
+		return schedule + "\nnever done"
+	}
+	return schedule + "\nlast done " + c.LastCompletion.String()
+}
+
+func (v *ChoreListIcsView) Write(out io.Writer) error {
+	w := &icsWriter{w: bufio.NewWriter(out)}
+	w.prop("BEGIN", "VCALENDAR")
+	w.prop("VERSION", "2.0")
+	w.prop("PRODID", "-//chore-tracker//EN")
+	w.prop("CALSCALE", "GREGORIAN")
+	w.prop("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", v.Name)
+	for _, c := range v.Chores {
+		if v.Options.Todo {
+			v.writeTodo(w, c)
+		} else {
+			v.writeEvent(w, c)
+		}
+	}
+	w.prop("END", "VCALENDAR")
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+func (v *ChoreListIcsView) writeCommon(w *icsWriter, c Chore) {
+	w.text("UID", c.ID+"@chore-tracker")
+	w.prop("DTSTAMP", icsDateTime(v.Now))
+	if c.IsFinished() {
+		w.text("SUMMARY", "✓ "+c.Name)
+	} else {
+		w.text("SUMMARY", c.Name)
+	}
+	w.text("CATEGORIES", v.Category(c))
+	w.text("DESCRIPTION", v.Description(c))
+	if c.Link != "" && !strings.ContainsAny(c.Link, "\r\n") {
+		w.prop("URL", c.Link)
+	}
+}
+
+func (v *ChoreListIcsView) writeAlarm(w *icsWriter, c Chore, related string) {
+	if !v.Options.HasReminder || c.IsFinished() {
+		return
+	}
+	w.prop("BEGIN", "VALARM")
+	w.prop("ACTION", "DISPLAY")
+	w.text("DESCRIPTION", c.Name)
+	w.prop("TRIGGER;RELATED="+related, icsDuration(v.Options.Reminder))
+	w.prop("END", "VALARM")
+}
+
+func (v *ChoreListIcsView) writeEvent(w *icsWriter, c Chore) {
+	next := v.NextCompletionOf(c)
+	w.prop("BEGIN", "VEVENT")
+	v.writeCommon(w, c)
+	w.prop("DTSTART;VALUE=DATE", icsDate(next))
+	w.prop("DTEND;VALUE=DATE", icsDate(next.Add(date.Day)))
+	if rule := c.RRule(); rule != "" {
+		w.prop("RRULE", rule)
+	}
+	v.writeAlarm(w, c, "START")
+	w.prop("END", "VEVENT")
+}
+
+// writeTodo writes the chore as a to-do due on its next completion. To-dos don't repeat, the feed moves the due date
+// once the chore is completed.
+func (v *ChoreListIcsView) writeTodo(w *icsWriter, c Chore) {
+	w.prop("BEGIN", "VTODO")
+	v.writeCommon(w, c)
+	if c.IsFinished() {
+		w.prop("STATUS", "COMPLETED")
+		w.prop("PERCENT-COMPLETE", "100")
+		// completions only have a date, noon UTC keeps it on the same day in most time zones
+		w.prop("COMPLETED", icsDateTime(c.LastCompletion.ToStdTime().Add(12*time.Hour)))
+	} else {
+		w.prop("STATUS", "NEEDS-ACTION")
+		w.prop("DUE;VALUE=DATE", icsDate(v.NextCompletionOf(c)))
+	}
+	v.writeAlarm(w, c, "END")
+	w.prop("END", "VTODO")
+}
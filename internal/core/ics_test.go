@@ -0,0 +1,94 @@
+package core_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func writeIcs(t *testing.T, v *core.ChoreListIcsView) string {
+	var buf bytes.Buffer
+	if err := v.Write(&buf); err != nil {
+		t.Fatalf("writing calendar: %s", err)
+	}
+	out := buf.String()
+	if !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Fatalf("expected CRLF terminated calendar, got %q", out)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 || !utf8.ValidString(line) || strings.Contains(line, "\n") {
+			t.Fatalf("expected folded lines of valid UTF-8 of at most 75 octets, got %q", line)
+		}
+	}
+	return strings.ReplaceAll(out, "\r\n ", "")
+}
+
+func TestChoreListIcs(t *testing.T) {
+	today := date.Today()
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	long := strings.Repeat("clean the fridge shelves ", 3) + "och städa ö"
+	view := func(opts core.IcsOptions) *core.ChoreListIcsView {
+		return &core.ChoreListIcsView{
+			ID:    "list",
+			Name:  "home, sweet; home",
+			Today: today,
+			Now:   now,
+			Chores: []core.Chore{
+				{ID: "a", Name: long, ChoreType: core.ChoreTypeInterval, Interval: date.Week, RepeatsLeft: -1, CreatedAt: today, Link: "https://example.com/a?b=c,d"},
+				{ID: "b", Name: "tax\\return", ChoreType: core.ChoreTypeOneshot, RepeatsLeft: 0, CreatedAt: today.Add(-3 * date.Day), LastCompletion: today.Add(-1 * date.Day)},
+			},
+			Options: opts,
+		}
+	}
+
+	events := writeIcs(t, view(core.IcsOptions{Reminder: 9 * time.Hour, HasReminder: true}))
+	for _, exp := range []string{
+		"X-WR-CALNAME:home\\, sweet\\; home\r\n",
+		"SUMMARY:" + long + "\r\n",
+		"SUMMARY:✓ tax\\\\return\r\n",
+		"CATEGORIES:home\\, sweet\\; home\r\n",
+		"DESCRIPTION:every 1w\\nnever done\r\n",
+		"DESCRIPTION:once\\nlast done " + today.Add(-1*date.Day).String() + "\r\n",
+		"URL:https://example.com/a?b=c,d\r\n",
+		"DTSTAMP:20240506T070809Z\r\n",
+		"DTSTART;VALUE=DATE:" + today.ToStdTime().Format("20060102") + "\r\n",
+		"RRULE:FREQ=WEEKLY\r\n",
+		"TRIGGER;RELATED=START:PT9H0M\r\n",
+	} {
+		if !strings.Contains(events, exp) {
+			t.Fatalf("expected %q in %s", exp, events)
+		}
+	}
+	if strings.Count(events, "BEGIN:VEVENT") != 2 || strings.Count(events, "BEGIN:VALARM") != 1 {
+		t.Fatalf("expected two events and an alarm only for the unfinished chore, got %s", events)
+	}
+
+	todos := writeIcs(t, view(core.IcsOptions{Todo: true}))
+	for _, exp := range []string{
+		"STATUS:NEEDS-ACTION\r\nDUE;VALUE=DATE:" + today.ToStdTime().Format("20060102") + "\r\n",
+		"STATUS:COMPLETED\r\nPERCENT-COMPLETE:100\r\nCOMPLETED:" + today.Add(-1*date.Day).ToStdTime().Format("20060102") + "T120000Z\r\n",
+	} {
+		if !strings.Contains(todos, exp) {
+			t.Fatalf("expected %q in %s", exp, todos)
+		}
+	}
+	if strings.Count(todos, "BEGIN:VTODO") != 2 || strings.Contains(todos, "VEVENT") || strings.Contains(todos, "VALARM") {
+		t.Fatalf("expected only to-dos without alarms, got %s", todos)
+	}
+}
+
+func TestParseIcsOptions(t *testing.T) {
+	opts, err := core.ParseIcsOptions(httptest.NewRequest("GET", "/feeds/x.ics?todo=1&reminder=08:30&assigned=me", nil))
+	if err != nil || !opts.Todo || !opts.AssignedOnly || !opts.HasReminder || opts.Reminder != 8*time.Hour+30*time.Minute {
+		t.Fatalf("unexpected options %+v: %v", opts, err)
+	}
+	if _, err := core.ParseIcsOptions(httptest.NewRequest("GET", "/feeds/x.ics?reminder=soon", nil)); err == nil {
+		t.Fatalf("expected an invalid reminder to be rejected")
+	}
+}
@@ -0,0 +1,393 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/chore-tracker/pkg/httpu"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+const maxImportSize = 1 << 20
+
+// maxIcsRepeats limits the repeats counted up to the UNTIL of an imported rule.
+const maxIcsRepeats = 1000
+
+// importColumns are the CSV columns and the form values they are parsed as, files without a header have the columns
+// name, type, interval, date and link.
+var importColumns = map[string]string{
+	"name":       "name",
+	"type":       "choreType",
+	"interval":   "interval",
+	"date":       "date",
+	"link":       "link",
+	"recurrence": "recurrence",
+}
+
+var defaultImportColumns = []string{"name", "choreType", "interval", "date", "link"}
+
+// ImportRow is a chore parsed from an imported file, Err is why it can't be created.
+type ImportRow struct {
+	Line  int
+	Input Input
+	Err   error
+}
+
+// Schedule describes when the imported chore is due.
+func (r ImportRow) Schedule() string {
+	switch r.Input.ChoreType {
+	case ChoreTypeInterval:
+		return "every " + r.Input.Interval.String()
+	case ChoreTypeDate:
+		return "on " + r.Input.Date.String()
+	case ChoreTypeDateRepeating:
+		return r.Input.Recurrence.String()
+	default:
+		return "once"
+	}
+}
+
+// newImportRow parses the form values of a chore like the chore form does. The type is inferred from the values when
+// it is missing. adjust, if set, changes the parsed input before it is validated.
+func newImportRow(line int, choreListID string, values url.Values, adjust func(*Input) error) ImportRow {
+	values.Set("choreListID", choreListID)
+	if values.Get("choreType") == "" {
+		switch {
+		case values.Get("recurrence") != "":
+			values.Set("choreType", ChoreTypeDateRepeating)
+		case values.Get("interval") != "":
+			values.Set("choreType", ChoreTypeInterval)
+		case values.Get("date") != "":
+			values.Set("choreType", ChoreTypeDate)
+		default:
+			values.Set("choreType", ChoreTypeOneshot)
+		}
+	}
+	if t := values.Get("choreType"); (t == ChoreTypeOneshot || t == ChoreTypeDate) && values.Get("repeats") == "" {
+		values.Set("repeats", "1")
+	}
+	row := ImportRow{Line: line}
+	row.Err = row.Input.fromValues(values)
+	if row.Err == nil && adjust != nil {
+		row.Err = adjust(&row.Input)
+	}
+	if row.Err == nil {
+		row.Err = row.Input.Validate(nil)
+	}
+	return row
+}
+
+// ParseImport parses the chores of a CSV or ICS file into the list, rows that aren't valid chores have an error.
+func ParseImport(content, choreListID string) ([]ImportRow, error) {
+	if strings.HasPrefix(strings.TrimSpace(content), "BEGIN:VCALENDAR") {
+		return parseIcsImport(content, choreListID)
+	}
+	return parseCsvImport(content, choreListID)
+}
+
+func parseCsvImport(content, choreListID string) ([]ImportRow, error) {
+	r := csv.NewReader(strings.NewReader(content))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	columns := defaultImportColumns
+	var rows []ImportRow
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: reading csv: %w", ErrInvalidInput, err)
+		}
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), "name") {
+			columns = make([]string, len(record))
+			for i, col := range record {
+				field, ok := importColumns[strings.ToLower(strings.TrimSpace(col))]
+				if !ok {
+					return nil, fmt.Errorf("%w: unknown column '%s'", ErrInvalidInput, col)
+				}
+				columns[i] = field
+			}
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) > len(columns) {
+			rows = append(rows, ImportRow{Line: line, Err: fmt.Errorf("expected at most %d fields, got %d", len(columns), len(record))})
+			continue
+		}
+		values := url.Values{}
+		for i, val := range record {
+			values.Set(columns[i], strings.TrimSpace(val))
+		}
+		rows = append(rows, newImportRow(line, choreListID, values, nil))
+	}
+}
+
+type icsContentLine struct {
+	no    int
+	name  string
+	value string
+}
+
+// unfoldIcs joins the folded lines of the calendar and splits them into property names and values, parameters are
+// dropped.
+func unfoldIcs(content string) []icsContentLine {
+	var lines []icsContentLine
+	for i, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].value += raw[1:]
+			continue
+		}
+		lines = append(lines, icsContentLine{no: i + 1, value: raw})
+	}
+	for i, l := range lines {
+		name, value := l.value, ""
+		quoted := false
+		for j, c := range l.value {
+			if c == '"' {
+				quoted = !quoted
+			} else if c == ':' && !quoted {
+				name, value = l.value[:j], l.value[j+1:]
+				break
+			}
+		}
+		name, _, _ = strings.Cut(name, ";")
+		lines[i].name, lines[i].value = strings.ToUpper(strings.TrimSpace(name)), value
+	}
+	return lines
+}
+
+func unescapeIcsText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// parseIcsDate parses a DATE or DATE-TIME value as the date it is on, the time and time zone are ignored.
+func parseIcsDate(value string) string {
+	if len(value) < 8 {
+		return value
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return value
+	}
+	return date.FromTime(t).String()
+}
+
+// fromIcsRule sets the repetition of an imported RRULE that starts on start. Plain daily and weekly rules, like the ones
+// of the app's own feeds, become interval chores. Rules missing the days to repeat on take them from the start, and an
+// UNTIL becomes the number of repeats up to it.
+func (i *Input) fromIcsRule(rule string, start date.Date) error {
+	value := strings.TrimSpace(rule)
+	if len(value) >= 6 && strings.EqualFold(value[:6], "RRULE:") {
+		value = value[6:]
+	}
+	var parts []string
+	var freq, until string
+	freqAt := -1
+	by := map[string]bool{}
+	for _, part := range strings.Split(strings.ToUpper(value), ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch {
+		case key == "UNTIL":
+			until = val
+			continue
+		case key == "FREQ":
+			freq, freqAt = val, len(parts)
+		case strings.HasPrefix(key, "BY"):
+			by[key] = true
+		}
+		parts = append(parts, part)
+	}
+	var end date.Date
+	if until != "" {
+		if start.IsZero() {
+			return fmt.Errorf("invalid rrule(%s): UNTIL without a start", rule)
+		}
+		var err error
+		if end, err = date.ParseDate(parseIcsDate(until)); err != nil {
+			return fmt.Errorf("invalid rrule(%s): invalid UNTIL: %w", rule, err)
+		}
+		if end.Before(start) {
+			return fmt.Errorf("invalid rrule(%s): ends before its start", rule)
+		}
+	}
+	if !start.IsZero() {
+		t := start.ToStdTime().UTC()
+		switch {
+		case freq == "WEEKLY" && len(by) > 0 && !by["BYDAY"]:
+			parts = append(parts, "BYDAY="+rruleWeekdays[t.Weekday()])
+		case freq == "MONTHLY" && !by["BYMONTHDAY"] && !by["BYDAY"]:
+			parts = append(parts, "BYMONTHDAY="+strconv.Itoa(t.Day()))
+		case freq == "YEARLY":
+			if !by["BYMONTH"] {
+				parts = append(parts, "BYMONTH="+strconv.Itoa(int(t.Month())))
+			}
+			if !by["BYMONTHDAY"] && !by["BYDAY"] {
+				parts = append(parts, "BYMONTHDAY="+strconv.Itoa(t.Day()))
+			}
+		}
+	}
+	plain := (freq == "DAILY" || freq == "WEEKLY") && len(by) == 0
+	if plain {
+		// a weekly rule without weekdays isn't a Recurrence, it is parsed as the days it repeats after
+		parts[freqAt] = "FREQ=DAILY"
+	}
+	rec, count, err := ParseRRule(strings.Join(parts, ";"))
+	if err != nil {
+		return err
+	}
+	if plain {
+		unit := date.Day
+		if freq == "WEEKLY" {
+			unit = date.Week
+		}
+		i.ChoreType, i.Interval, i.Recurrence, i.Repeats = ChoreTypeInterval, date.Duration(rec.Every)*unit, Recurrence{}, count
+		if !end.IsZero() {
+			i.Repeats = int64(end.Sub(start)/i.Interval) + 1
+		}
+	} else {
+		i.ChoreType, i.Recurrence, i.Repeats = ChoreTypeDateRepeating, rec, count
+		if !end.IsZero() {
+			i.Repeats = 0
+			for d, ok := rec.Next(start.Add(-date.Day), start); ok && !d.After(end) && i.Repeats < maxIcsRepeats; d, ok = rec.Next(d, start) {
+				i.Repeats++
+			}
+		}
+	}
+	if i.Repeats == 0 {
+		return fmt.Errorf("invalid rrule(%s): ends before its start", rule)
+	}
+	return nil
+}
+
+// parseIcsImport turns the events and to-dos of the calendar into chores. Repeating ones become interval or
+// date-repeating chores, the others are due on their start or due date.
+func parseIcsImport(content, choreListID string) ([]ImportRow, error) {
+	var rows []ImportRow
+	var values url.Values
+	var start int
+	var component string
+	nested := 0
+	for _, l := range unfoldIcs(content) {
+		switch {
+		case l.name == "BEGIN" && values == nil && (l.value == "VEVENT" || l.value == "VTODO"):
+			values, start, component = url.Values{}, l.no, l.value
+		case values == nil:
+		case l.name == "BEGIN":
+			nested++
+		case l.name == "END" && nested > 0:
+			nested--
+		case nested > 0:
+		case l.name == "END" && l.value == component:
+			var adjust func(*Input) error
+			if rule := values.Get("recurrence"); rule != "" {
+				day, _ := date.ParseDate(values.Get("date"))
+				adjust = func(i *Input) error { return i.fromIcsRule(rule, day) }
+				values.Del("recurrence")
+				values.Del("date")
+				values.Set("choreType", ChoreTypeDateRepeating)
+			}
+			rows = append(rows, newImportRow(start, choreListID, values, adjust))
+			values = nil
+		case l.name == "SUMMARY":
+			values.Set("name", strings.TrimSpace(unescapeIcsText(l.value)))
+		case l.name == "DUE" || l.name == "DTSTART" && values.Get("date") == "":
+			values.Set("date", parseIcsDate(l.value))
+		case l.name == "RRULE":
+			values.Set("recurrence", l.value)
+		case l.name == "URL":
+			values.Set("link", l.value)
+		}
+	}
+	if values != nil {
+		return nil, fmt.Errorf("%w: unterminated %s starting on line %d", ErrInvalidInput, component, start)
+	}
+	return rows, nil
+}
+
+// ImportChores creates the chores of all rows in a single transaction, nothing is created unless every row is valid.
+func ImportChores(ctx context.Context, db *sql.DB, today date.Date, userID string, rows []ImportRow) ([]Chore, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no chores to import", ErrInvalidInput)
+	}
+	for _, row := range rows {
+		if row.Err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidInput, row.Line, row.Err)
+		}
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	chores := make([]Chore, len(rows))
+	for i, row := range rows {
+		chore, err := create(ctx, q, today, userID, row.Input)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row.Line, err)
+		}
+		chores[i] = *chore
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing tx: %w", err)
+	}
+	return chores, nil
+}
+
+// importContent is the uploaded file, or the content of a previewed file when the import is confirmed.
+func importContent(r *http.Request) (string, error) {
+	file, _, err := r.FormFile("file")
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		return r.FormValue("content"), nil
+	} else if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// ChoreListImportHandler previews the chores of the uploaded file, they are only created once the preview is
+// confirmed.
+func ChoreListImportHandler(db *sql.DB, view *View) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		choreListID := r.PathValue("choreListID")
+		list, err := cdb.New(db).GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: choreListID, UserID: userID})
+		if err != nil {
+			return srvu.Err(http.StatusForbidden, err)
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+		content, err := importContent(r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("reading file: %w", err))
+		}
+		rows, err := ParseImport(content, list.ID)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		if r.FormValue("confirm") == "" {
+			return view.ChoreListImportPage(w, r, ChoreListImportView{List: list, Rows: rows, Content: content})
+		}
+		if _, err := ImportChores(ctx, db, date.Today(), userID, rows); err != nil {
+			return writeErr(err, "importing chores")
+		}
+		httpu.RedirectToNext(w, r, fmt.Sprintf("/chore-lists/%s", list.ID))
+		return nil
+	})
+}
@@ -0,0 +1,162 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func TestParseImport(t *testing.T) {
+	rows := Must(core.ParseImport("name,type,interval,date,link\n"+
+		"dishes,interval,1d,,https://example.com\n"+
+		"\"taxes, yearly\",,,2024-04-30,\n"+
+		"plant,oneshot\n"+
+		"broken,interval,,,\n", "list"))
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %+v", rows)
+	}
+	if r := rows[0]; r.Err != nil || r.Line != 2 || r.Input.ChoreType != core.ChoreTypeInterval || r.Input.Link != "https://example.com" || r.Input.ChoreListID != "list" {
+		t.Fatalf("unexpected interval row %+v", r)
+	}
+	if r := rows[1]; r.Err != nil || r.Input.Name != "taxes, yearly" || r.Input.ChoreType != core.ChoreTypeDate || r.Schedule() != "on 2024-04-30" {
+		t.Fatalf("expected the type to be inferred from the date, got %+v", r)
+	}
+	if r := rows[2]; r.Err != nil || r.Input.Repeats != 1 {
+		t.Fatalf("unexpected oneshot row %+v", r)
+	}
+	if r := rows[3]; r.Err == nil || r.Line != 5 {
+		t.Fatalf("expected the interval chore without an interval to be invalid, got %+v", r)
+	}
+	if rows := Must(core.ParseImport("vacuum,interval,1w", "list")); len(rows) != 1 || rows[0].Err != nil || rows[0].Input.Name != "vacuum" {
+		t.Fatalf("expected a file without header to have the default columns, got %+v", rows)
+	}
+	if _, err := core.ParseImport("name,color\nx,red", "list"); err == nil {
+		t.Fatalf("expected unknown columns to be rejected")
+	}
+
+	rows = Must(core.ParseImport("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"+
+		"BEGIN:VEVENT\r\nSUMMARY:water the\r\n  plants\\, all\r\nDTSTART;VALUE=DATE:20240506\r\n"+
+		"RRULE:FREQ=WEEKLY;BYDAY=MO\r\nURL:https://example.com/p\r\n"+
+		"BEGIN:VALARM\r\nDESCRIPTION:ignored\r\nEND:VALARM\r\nEND:VEVENT\r\n"+
+		"BEGIN:VTODO\r\nSUMMARY:renew passport\r\nDTSTART:20240101T100000Z\r\nDUE;VALUE=DATE:20240601\r\nEND:VTODO\r\n"+
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20240506\r\nEND:VEVENT\r\n"+
+		"END:VCALENDAR\r\n", "list"))
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %+v", rows)
+	}
+	if r := rows[0]; r.Err != nil || r.Line != 3 || r.Input.Name != "water the plants, all" || r.Input.ChoreType != core.ChoreTypeDateRepeating || !r.Input.Date.IsZero() || r.Input.Link != "https://example.com/p" {
+		t.Fatalf("unexpected repeating event %+v", r)
+	}
+	if r := rows[1]; r.Err != nil || r.Input.ChoreType != core.ChoreTypeDate || r.Input.Date.String() != "2024-06-01" {
+		t.Fatalf("expected the to-do to be due on its due date, got %+v", r)
+	}
+	if r := rows[2]; r.Err == nil {
+		t.Fatalf("expected the event without a summary to be invalid, got %+v", r)
+	}
+}
+
+func uploadReq(ctx context.Context, client *Client, tok *ClientToken, uri, filename, content string) *ChoreReq {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	Must(Must(mw.CreateFormFile("file", filename)).Write([]byte(content)))
+	Panic(mw.Close())
+	return NewChoreReq(ctx, client).Auth(tok).Method("POST", uri, &body).Header("Content-Type", mw.FormDataContentType())
+}
+
+func TestChoreListImport(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	importPath := fmt.Sprintf("/chore-lists/%s/import", cl.List.ID)
+	content := "name,type,interval\ndishes,interval,1d\nlaundry,interval,1w\n"
+
+	Must(uploadReq(ctx, client, tok, importPath, "chores.csv", content).DoAndExp(http.StatusOK))
+	preview := GetTpl[core.ChoreListImportView](client.tmpl, "chore_list_import.page.gohtml")
+	if len(preview.Rows) != 2 || preview.Errors() != 0 || preview.Content != content {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+	if chores := Must(client.DBQuery().GetChoresByList(ctx, cl.List.ID)); len(chores) != 0 {
+		t.Fatalf("expected the preview not to create chores, got %+v", chores)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", importPath, map[string]string{"content": preview.Content, "confirm": "true"}).DoAndExp(http.StatusSeeOther))
+	if chores := Must(client.DBQuery().GetChoresByList(ctx, cl.List.ID)); len(chores) != 2 {
+		t.Fatalf("expected both chores to be imported, got %+v", chores)
+	}
+
+	invalid := "name,type,interval\nvacuum,interval,1w\nbroken,interval,\n"
+	Must(uploadReq(ctx, client, tok, importPath, "chores.csv", invalid).DoAndExp(http.StatusOK))
+	if preview := GetTpl[core.ChoreListImportView](client.tmpl, "chore_list_import.page.gohtml"); preview.Errors() != 1 {
+		t.Fatalf("expected the invalid chore in the preview, got %+v", preview.Rows)
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", importPath, map[string]string{"content": invalid, "confirm": "true"}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected the import to be rejected: %s", err)
+	}
+	if chores := Must(client.DBQuery().GetChoresByList(ctx, cl.List.ID)); len(chores) != 2 || strings.Contains(fmt.Sprint(chores), "vacuum") {
+		t.Fatalf("expected nothing of an invalid file to be imported, got %+v", chores)
+	}
+
+	if _, err := uploadReq(ctx, client, tok, "/chore-lists/unknown/import", "chores.csv", content).DoAndExp(http.StatusForbidden); err != nil {
+		t.Fatalf("expected importing into lists of others to be forbidden: %s", err)
+	}
+}
+
+func TestParseImportIcsRoundTrip(t *testing.T) {
+	today := date.Today()
+	monthly := core.Recurrence{Freq: core.FreqMonthly, Every: 1, ByMonthDay: []int{15}}
+	feed := writeIcs(t, &core.ChoreListIcsView{
+		ID: "list", Name: "home", Today: today, Now: time.Now(),
+		Chores: []core.Chore{
+			{ID: "a", Name: "weekly", ChoreType: core.ChoreTypeInterval, Interval: date.Week, RepeatsLeft: -1, CreatedAt: today},
+			{ID: "b", Name: "fortnightly", ChoreType: core.ChoreTypeInterval, Interval: 2 * date.Week, RepeatsLeft: 3, CreatedAt: today},
+			{ID: "c", Name: "ten days", ChoreType: core.ChoreTypeInterval, Interval: 10 * date.Day, RepeatsLeft: -1, CreatedAt: today},
+			{ID: "d", Name: "rent", ChoreType: core.ChoreTypeDateRepeating, Recurrence: monthly, RepeatsLeft: -1, CreatedAt: today},
+		},
+	})
+	rows := Must(core.ParseImport(feed, "list"))
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %+v", rows)
+	}
+	for i, exp := range []struct {
+		interval date.Duration
+		repeats  int64
+	}{{date.Week, -1}, {2 * date.Week, 3}, {10 * date.Day, -1}} {
+		if r := rows[i]; r.Err != nil || r.Input.ChoreType != core.ChoreTypeInterval || r.Input.Interval != exp.interval || r.Input.Repeats != exp.repeats {
+			t.Fatalf("expected the interval chore to be imported as one, got %+v", r)
+		}
+	}
+	if r := rows[3]; r.Err != nil || r.Input.ChoreType != core.ChoreTypeDateRepeating || r.Input.Recurrence.RRule() != monthly.RRule() {
+		t.Fatalf("expected the date-repeating chore to keep its recurrence, got %+v", r)
+	}
+
+	rows = Must(core.ParseImport("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\nSUMMARY:rent\r\nDTSTART;VALUE=DATE:20240131\r\nRRULE:FREQ=MONTHLY\r\nEND:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\nSUMMARY:birthday\r\nDTSTART;VALUE=DATE:20240229\r\nRRULE:FREQ=YEARLY\r\nEND:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\nSUMMARY:summer\r\nDTSTART;VALUE=DATE:20240605\r\nRRULE:FREQ=WEEKLY;BYMONTH=6,7\r\nEND:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\nSUMMARY:course\r\nDTSTART;VALUE=DATE:20240506\r\nRRULE:FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20240520T000000Z\r\nEND:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\nSUMMARY:plants\r\nDTSTART;VALUE=DATE:20240501\r\nRRULE:FREQ=DAILY;INTERVAL=3;UNTIL=20240510\r\nEND:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\nSUMMARY:past\r\nDTSTART;VALUE=DATE:20240501\r\nRRULE:FREQ=DAILY;UNTIL=20240401\r\nEND:VEVENT\r\n"+
+		"END:VCALENDAR\r\n", "list"))
+	for i, exp := range []string{"FREQ=MONTHLY;BYMONTHDAY=31", "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", "FREQ=WEEKLY;BYMONTH=6,7;BYDAY=WE", "FREQ=WEEKLY;BYDAY=MO,WE"} {
+		if r := rows[i]; r.Err != nil || r.Input.ChoreType != core.ChoreTypeDateRepeating || r.Input.Recurrence.RRule() != exp {
+			t.Fatalf("expected %s, got %+v", exp, r)
+		}
+	}
+	if r := rows[3]; r.Input.Repeats != 5 {
+		t.Fatalf("expected the repeats up to UNTIL, got %+v", r)
+	}
+	if r := rows[4]; r.Err != nil || r.Input.Interval != 3*date.Day || r.Input.Repeats != 4 {
+		t.Fatalf("expected an interval chore repeating up to UNTIL, got %+v", r)
+	}
+	if r := rows[5]; r.Err == nil {
+		t.Fatalf("expected a rule ending before its start to be invalid, got %+v", r)
+	}
+}
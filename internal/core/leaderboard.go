@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+// LeaderboardWindow is a period the leaderboard sums points over, a zero duration covers all time.
+type LeaderboardWindow struct {
+	Label    string
+	Duration date.Duration
+}
+
+func (w LeaderboardWindow) Param() string {
+	if w.Duration.Zero() {
+		return "all"
+	}
+	return w.Duration.String()
+}
+
+var LeaderboardWindows = []LeaderboardWindow{
+	{Label: "Week", Duration: date.Week},
+	{Label: "Month", Duration: date.Month},
+	{Label: "Year", Duration: date.Year},
+	{Label: "All time", Duration: date.Zero},
+}
+
+// ParseLeaderboardWindow parses the window query parameter, any duration or "all" is accepted and the default is a
+// month.
+func ParseLeaderboardWindow(param string) (LeaderboardWindow, error) {
+	if param == "" {
+		return LeaderboardWindows[1], nil
+	}
+	for _, w := range LeaderboardWindows {
+		if w.Param() == param {
+			return w, nil
+		}
+	}
+	d, err := date.ParseDuration(param)
+	if err != nil {
+		return LeaderboardWindow{}, err
+	}
+	if d <= 0 {
+		return LeaderboardWindow{}, fmt.Errorf("window must be positive: %s", param)
+	}
+	return LeaderboardWindow{Label: d.String(), Duration: d}, nil
+}
+
+// Since is the first day included in the window ending today.
+func (w LeaderboardWindow) Since(today date.Date) date.Date {
+	if w.Duration.Zero() {
+		return 0
+	}
+	return today.Add(-w.Duration + date.Day)
+}
+
+type LeaderboardEntry struct {
+	UserID      string
+	DisplayName string
+	Points      int64
+	Completions int64
+	Share       float64 // of all points earned in the window
+}
+
+type Leaderboard struct {
+	Window  LeaderboardWindow
+	Entries []LeaderboardEntry
+	Total   int64
+}
+
+func NewLeaderboard(window LeaderboardWindow, rows []cdb.GetChoreListLeaderboardRow) Leaderboard {
+	l := Leaderboard{Window: window, Entries: make([]LeaderboardEntry, len(rows))}
+	for _, row := range rows {
+		l.Total += row.Points
+	}
+	for i, row := range rows {
+		l.Entries[i] = LeaderboardEntry{
+			UserID:      row.ID,
+			DisplayName: row.DisplayName,
+			Points:      row.Points,
+			Completions: row.Completions,
+		}
+		if l.Total > 0 {
+			l.Entries[i].Share = float64(row.Points) / float64(l.Total)
+		}
+	}
+	return l
+}
+
+// FairShare is the share of the points each member would have if the effort was split equally.
+func (l Leaderboard) FairShare() float64 {
+	if len(l.Entries) == 0 {
+		return 0
+	}
+	return 1 / float64(len(l.Entries))
+}
+
+// Fairness is 1 when the points are split equally between the members and 0 when one member earned all of them.
+func (l Leaderboard) Fairness() float64 {
+	fair := l.FairShare()
+	if l.Total == 0 || fair == 1 {
+		return 1
+	}
+	top := 0.0
+	for _, e := range l.Entries {
+		top = max(top, e.Share)
+	}
+	return 1 - (top-fair)/(1-fair)
+}
+
+// Carrying is the member carrying the household, the one with the most points when the fairness drops below a half.
+func (l Leaderboard) Carrying() *LeaderboardEntry {
+	if l.Fairness() >= 0.5 {
+		return nil
+	}
+	return &l.Entries[0]
+}
+
+func GetLeaderboard(ctx context.Context, db cdb.DBTX, choreListID string, today date.Date, window LeaderboardWindow) (Leaderboard, error) {
+	rows, err := cdb.New(db).GetChoreListLeaderboard(ctx, cdb.GetChoreListLeaderboardParams{
+		ChoreListID: choreListID,
+		OccurredAt:  int64(window.Since(today)),
+	})
+	if err != nil {
+		return Leaderboard{}, fmt.Errorf("querying leaderboard: %w", err)
+	}
+	return NewLeaderboard(window, rows), nil
+}
+
+func ChoreListLeaderboardPage(db *sql.DB, view *View) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		choreListID := r.PathValue("choreListID")
+		userID := auth.MustGetSession(ctx).UserID
+		cl, err := cdb.New(db).GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: choreListID, UserID: userID})
+		if err != nil {
+			return srvu.Err(http.StatusForbidden, err)
+		}
+		window, err := ParseLeaderboardWindow(r.FormValue("window"))
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("invalid window: %w", err))
+		}
+		leaderboard, err := GetLeaderboard(ctx, db, choreListID, date.Today(), window)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		return view.ChoreListLeaderboardPage(w, r, ChoreListLeaderboardView{
+			List:        cl,
+			Leaderboard: leaderboard,
+		})
+	})
+}
@@ -0,0 +1,129 @@
+package core_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func TestLeaderboardFairness(t *testing.T) {
+	tests := []struct {
+		name     string
+		points   []int64
+		fairness float64
+		carrying bool
+	}{
+		{name: "equal", points: []int64{5, 5}, fairness: 1},
+		{name: "nothing done", points: []int64{0, 0}, fairness: 1},
+		{name: "alone", points: []int64{7}, fairness: 1},
+		{name: "one does everything", points: []int64{6, 0, 0}, fairness: 0, carrying: true},
+		{name: "uneven", points: []int64{3, 1}, fairness: 0.5},
+		{name: "lopsided", points: []int64{9, 1}, fairness: 0.2, carrying: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows := make([]cdb.GetChoreListLeaderboardRow, len(test.points))
+			for i, p := range test.points {
+				rows[i] = cdb.GetChoreListLeaderboardRow{ID: fmt.Sprintf("u%d", i), Points: p}
+			}
+			l := core.NewLeaderboard(core.LeaderboardWindows[0], rows)
+			if f := l.Fairness(); f < test.fairness-0.001 || f > test.fairness+0.001 {
+				t.Fatalf("expected fairness %f, got %f", test.fairness, f)
+			}
+			if carrying := l.Carrying(); (carrying != nil) != test.carrying {
+				t.Fatalf("expected carrying to be %t, got %+v", test.carrying, carrying)
+			}
+		})
+	}
+}
+
+func TestParseLeaderboardWindow(t *testing.T) {
+	today := date.Today()
+	tests := []struct {
+		param string
+		since date.Date
+	}{
+		{param: "", since: today.Add(-date.Month + date.Day)},
+		{param: "1w", since: today.Add(-date.Week + date.Day)},
+		{param: "2w", since: today.Add(-2*date.Week + date.Day)},
+		{param: "all", since: 0},
+	}
+	for _, test := range tests {
+		w, err := core.ParseLeaderboardWindow(test.param)
+		if err != nil {
+			t.Fatalf("parsing %q: %s", test.param, err)
+		}
+		if since := w.Since(today); since != test.since {
+			t.Fatalf("expected %q to start at %s, got %s", test.param, test.since, since)
+		}
+	}
+	for _, param := range []string{"soon", "-1w"} {
+		if _, err := core.ParseLeaderboardWindow(param); err == nil {
+			t.Fatalf("expected %q to be invalid", param)
+		}
+	}
+}
+
+func TestChoreListLeaderboard(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	Panic(client.AddMember(ctx, cl.List.ID, "other"))
+	heavy := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "heavy",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1w",
+		"effort":      "5",
+	}))
+	light := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "light",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1w",
+	}))
+	if heavy.Effort != 5 || light.Effort != 1 {
+		t.Fatalf("expected efforts 5 and 1, got %d and %d", heavy.Effort, light.Effort)
+	}
+	today := date.Today()
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", heavy.ID), nil).DoAndFollow(http.StatusSeeOther))
+	for i, occurredAt := range []date.Date{today.Add(-2 * date.Day), today.Add(-3 * date.Month)} {
+		Panic(client.DBQuery().CreateChoreEvent(ctx, cdb.CreateChoreEventParams{
+			ID:         fmt.Sprintf("other-%d", i),
+			ChoreID:    light.ID,
+			EventType:  core.EventTypeComplete,
+			CreatedBy:  "other",
+			OccurredAt: int64(occurredAt),
+		}))
+	}
+	leaderboard := func(window string) core.Leaderboard {
+		Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/leaderboard?window=%s", cl.List.ID, window)).DoAndExp(http.StatusOK))
+		return GetTpl[core.ChoreListLeaderboardView](client.tmpl, "chore_list_leaderboard.page.gohtml").Leaderboard
+	}
+	month := leaderboard("")
+	if month.Total != 6 || month.Entries[0].UserID != "test" || month.Entries[0].Points != 5 || month.Entries[1].Points != 1 {
+		t.Fatalf("unexpected monthly leaderboard %+v", month)
+	}
+	if carrying := month.Carrying(); carrying == nil || carrying.UserID != "test" {
+		t.Fatalf("expected test to be carrying the household, got %+v", carrying)
+	}
+	all := leaderboard("all")
+	if all.Total != 7 || all.Entries[1].Completions != 2 {
+		t.Fatalf("unexpected all time leaderboard %+v", all)
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/leaderboard?window=soon", cl.List.ID)).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected an invalid window to be rejected: %s", err)
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s", light.ID), map[string]string{
+		"name":     "light",
+		"interval": "1w",
+		"effort":   "101",
+	}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected too much effort to be rejected: %s", err)
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+type SMTPConfig struct {
+	Addr     string `config:"u:SMTP server as host:port to send email digests"`
+	Username string `config:"u:SMTP username for PLAIN auth"`
+	Password string `config:"u:SMTP password"`
+	From     string `config:"u:sender of the emails like Chores <chores@example.com>"`
+}
+
+func (c SMTPConfig) Enabled() bool {
+	return c.Addr != ""
+}
+
+// Email is a message with a plain text and an HTML alternative.
+type Email struct {
+	To      string
+	Subject string
+	Text    []byte
+	HTML    []byte
+}
+
+// Send sends the email through the SMTP server, upgrading to TLS when the server supports it.
+func (c SMTPConfig) Send(e Email, now time.Time) error {
+	from, err := mail.ParseAddress(c.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender '%s': %w", c.From, err)
+	}
+	to, err := mail.ParseAddress(e.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient '%s': %w", e.To, err)
+	}
+	msg, err := e.message(from, to, now)
+	if err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	var a smtp.Auth
+	if c.Username != "" {
+		host, _, err := net.SplitHostPort(c.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address '%s': %w", c.Addr, err)
+		}
+		a = smtp.PlainAuth("", c.Username, c.Password, host)
+	}
+	if err := smtp.SendMail(c.Addr, a, from.Address, []string{to.Address}, msg); err != nil {
+		return fmt.Errorf("sending mail to %s: %w", to.Address, err)
+	}
+	return nil
+}
+
+func (e Email) message(from, to *mail.Address, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{{"text/plain; charset=utf-8", e.Text}, {"text/html; charset=utf-8", e.HTML}} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write(part.content); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
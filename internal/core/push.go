@@ -0,0 +1,462 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/chore-tracker/pkg/httpu"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+// pushRecordSize is the record size of the aes128gcm encoding, pushes are sent as a single record so the payload
+// must fit in it with the padding delimiter and the authentication tag.
+const pushRecordSize = 4096
+
+// pushDefaultSubject is the contact of the VAPID claims when the base URL of the app isn't configured.
+const pushDefaultSubject = "https://github.com/SimonSchneider/chore-tracker"
+
+// VAPIDKey identifies the app to the push services (RFC 8292), the browsers only accept pushes signed by the key the
+// subscription was created with.
+type VAPIDKey struct {
+	priv *ecdsa.PrivateKey
+}
+
+// GetVAPIDKey is the app's key, it is generated the first time it is needed and kept in the db so subscriptions
+// survive restarts.
+func GetVAPIDKey(ctx context.Context, db cdb.DBTX) (*VAPIDKey, error) {
+	q := cdb.New(db)
+	row, err := q.GetVAPIDKey(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := createVAPIDKey(ctx, q); err != nil {
+			return nil, err
+		}
+		row, err = q.GetVAPIDKey(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting VAPID key: %w", err)
+	}
+	der, err := base64.RawURLEncoding.DecodeString(row.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding VAPID key: %w", err)
+	}
+	priv, err := x509.ParseECPrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing VAPID key: %w", err)
+	}
+	return &VAPIDKey{priv: priv}, nil
+}
+
+// createVAPIDKey stores a new key unless another one was stored first.
+func createVAPIDKey(ctx context.Context, q *cdb.Queries) error {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("generating VAPID key: %w", err)
+	}
+	der, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return fmt.Errorf("encoding VAPID key: %w", err)
+	}
+	if err := q.CreateVAPIDKey(ctx, cdb.CreateVAPIDKeyParams{
+		PrivateKey: base64.RawURLEncoding.EncodeToString(der),
+		CreatedAt:  time.Now().UnixMilli(),
+	}); err != nil {
+		return fmt.Errorf("creating VAPID key: %w", err)
+	}
+	return nil
+}
+
+// PublicKey is the uncompressed public key in URL safe base64, the applicationServerKey of the browser's subscribe.
+func (k *VAPIDKey) PublicKey() string {
+	pub, err := k.priv.PublicKey.ECDH()
+	if err != nil {
+		panic(fmt.Sprintf("invalid VAPID key: %s", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(pub.Bytes())
+}
+
+// Authorization is the vapid Authorization header of a push to the endpoint, an ES256 JWT with the endpoint's origin
+// as audience.
+func (k *VAPIDKey) Authorization(endpoint, subject string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint: %w", err)
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, k.priv, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("signing JWT: %w", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", unsigned, base64.RawURLEncoding.EncodeToString(sig), k.PublicKey()), nil
+}
+
+// PushSubscription is a device of a user that receives pushes, one per browser the user enabled notifications in.
+type PushSubscription struct {
+	ID        string
+	Endpoint  string
+	P256dh    string
+	Auth      string
+	UserAgent string
+	CreatedAt time.Time
+}
+
+func PushSubscriptionFromDb(row cdb.PushSubscription) PushSubscription {
+	return PushSubscription{
+		ID:        row.ID,
+		Endpoint:  row.Endpoint,
+		P256dh:    row.P256dh,
+		Auth:      row.Auth,
+		UserAgent: row.UserAgent,
+		CreatedAt: time.UnixMilli(row.CreatedAt),
+	}
+}
+
+// PushSubscriptionInput is the JSON of the browser's PushSubscription.
+type PushSubscriptionInput struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+func (inp *PushSubscriptionInput) Validate() error {
+	u, err := url.Parse(inp.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid endpoint '%s'", inp.Endpoint)
+	}
+	p256dh, err := decodePushKey(inp.Keys.P256dh)
+	if err != nil {
+		return fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	if _, err := ecdh.P256().NewPublicKey(p256dh); err != nil {
+		return fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	if authSecret, err := decodePushKey(inp.Keys.Auth); err != nil || len(authSecret) != 16 {
+		return fmt.Errorf("invalid auth secret '%s'", inp.Keys.Auth)
+	}
+	return nil
+}
+
+// decodePushKey decodes the URL safe base64 keys of a subscription, browsers differ in whether they pad them.
+func decodePushKey(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// SavePushSubscription stores the device's subscription, a subscription that is saved again replaces the earlier one
+// of the endpoint.
+func SavePushSubscription(ctx context.Context, db cdb.DBTX, userID, userAgent string, inp PushSubscriptionInput) error {
+	if err := inp.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if err := cdb.New(db).UpsertPushSubscription(ctx, cdb.UpsertPushSubscriptionParams{
+		ID:        NewId(),
+		UserID:    userID,
+		Endpoint:  inp.Endpoint,
+		P256dh:    inp.Keys.P256dh,
+		Auth:      inp.Keys.Auth,
+		UserAgent: userAgent,
+		CreatedAt: time.Now().UnixMilli(),
+	}); err != nil {
+		return fmt.Errorf("saving push subscription: %w", err)
+	}
+	return nil
+}
+
+func GetPushSubscriptions(ctx context.Context, db cdb.DBTX, userID string) ([]PushSubscription, error) {
+	rows, err := cdb.New(db).GetPushSubscriptionsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting push subscriptions: %w", err)
+	}
+	subs := make([]PushSubscription, len(rows))
+	for i, row := range rows {
+		subs[i] = PushSubscriptionFromDb(row)
+	}
+	return subs, nil
+}
+
+func DeletePushSubscription(ctx context.Context, db cdb.DBTX, userID, id string) error {
+	n, err := cdb.New(db).DeletePushSubscription(ctx, cdb.DeletePushSubscriptionParams{ID: id, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("deleting push subscription: %w", err)
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// EncryptPush encrypts the payload for the subscription with the aes128gcm content encoding of Web Push (RFC 8291),
+// only the subscribed browser can decrypt it.
+func EncryptPush(p256dh, authSecret string, payload []byte) ([]byte, error) {
+	uaPublic, err := decodePushKey(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("decoding p256dh key: %w", err)
+	}
+	secret, err := decodePushKey(authSecret)
+	if err != nil {
+		return nil, fmt.Errorf("decoding auth secret: %w", err)
+	}
+	if len(payload)+1+16 > pushRecordSize {
+		return nil, fmt.Errorf("payload of %d bytes is too large", len(payload))
+	}
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("parsing p256dh key: %w", err)
+	}
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+	sharedSecret, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, fmt.Errorf("deriving shared secret: %w", err)
+	}
+	asPublic := asKey.PublicKey().Bytes()
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, secret, "WebPush: info\x00"+string(uaPublic)+string(asPublic), 32)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	body.Write(salt)
+	binary.Write(&body, binary.BigEndian, uint32(pushRecordSize))
+	body.WriteByte(byte(len(asPublic)))
+	body.Write(asPublic)
+	// the 0x02 delimiter marks the last, and only, record
+	body.Write(gcm.Seal(nil, nonce, append(bytes.Clone(payload), 0x02), nil))
+	return body.Bytes(), nil
+}
+
+// PushMessage is the JSON payload of a push, the service worker shows it as a notification that opens the URL.
+type PushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// PushSender sends pushes to the devices of the users. Subscriptions the push service reports as gone are deleted.
+type PushSender struct {
+	DB      *sql.DB
+	Client  *http.Client
+	Subject string
+	TTL     time.Duration
+	Now     func() time.Time
+}
+
+// NewPushSender signs the pushes with subject as contact, the push services require a mailto: or https: URL.
+func NewPushSender(db *sql.DB, subject string) *PushSender {
+	if subject == "" {
+		subject = pushDefaultSubject
+	}
+	return &PushSender{
+		DB:      db,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		Subject: subject,
+		TTL:     12 * time.Hour,
+		Now:     time.Now,
+	}
+}
+
+// Push sends the message to every device of the user.
+func (s *PushSender) Push(ctx context.Context, userID string, msg PushMessage) error {
+	subs, err := GetPushSubscriptions(ctx, s.DB, userID)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+	key, err := GetVAPIDKey(ctx, s.DB)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encoding push: %w", err)
+	}
+	var errs []error
+	for _, sub := range subs {
+		status, err := s.send(ctx, key, sub, payload)
+		if status == http.StatusNotFound || status == http.StatusGone {
+			if err := cdb.New(s.DB).DeletePushSubscriptionByEndpoint(ctx, sub.Endpoint); err != nil {
+				errs = append(errs, fmt.Errorf("deleting expired subscription %s: %w", sub.ID, err))
+			}
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("pushing to %s: %w", sub.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *PushSender) send(ctx context.Context, key *VAPIDKey, sub PushSubscription, payload []byte) (int, error) {
+	body, err := EncryptPush(sub.P256dh, sub.Auth, payload)
+	if err != nil {
+		return 0, err
+	}
+	authorization, err := key.Authorization(sub.Endpoint, s.Subject, s.Now())
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(s.TTL.Seconds())))
+	req.Header.Set("Urgency", "normal")
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("unexpected response %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// PushNotifier pushes the chores that became due or overdue to the devices of their assignee, or of every member of
+// the list when the chore isn't assigned.
+type PushNotifier struct {
+	Sender *PushSender
+}
+
+func (n *PushNotifier) Name() string {
+	return "push"
+}
+
+// Notify looks up the pushes of all transitions before sending any, only failing to look them up is returned. Failed
+// pushes are logged rather than retried, so the devices that did receive them aren't alerted again.
+func (n *PushNotifier) Notify(ctx context.Context, transitions []Transition) error {
+	type push struct {
+		userID string
+		msg    PushMessage
+	}
+	q := cdb.New(n.Sender.DB)
+	lists := make(map[string]cdb.ChoreList)
+	var pushes []push
+	for _, t := range transitions {
+		if t.To != ChoreStateDue && t.To != ChoreStateOverdue {
+			continue
+		}
+		list, ok := lists[t.Chore.ChoreListID]
+		if !ok {
+			var err error
+			if list, err = q.GetChoreListWithoutUser(ctx, t.Chore.ChoreListID); err != nil {
+				return fmt.Errorf("getting chore list: %w", err)
+			}
+			lists[list.ID] = list
+		}
+		recipients := []string{t.Chore.AssignedTo}
+		if t.Chore.AssignedTo == "" {
+			members, err := q.GetChoreListMembers(ctx, list.ID)
+			if err != nil {
+				return fmt.Errorf("getting members: %w", err)
+			}
+			recipients = recipients[:0]
+			for _, m := range members {
+				recipients = append(recipients, m.ID)
+			}
+		}
+		msg := PushMessage{
+			Title: list.Name,
+			Body:  fmt.Sprintf("%s is due today", t.Chore.Name),
+			URL:   fmt.Sprintf("/chore-lists/%s", list.ID),
+			Tag:   t.Chore.ID,
+		}
+		if t.To == ChoreStateOverdue {
+			msg.Body = fmt.Sprintf("%s is overdue since %s", t.Chore.Name, t.DueOn)
+		}
+		for _, userID := range recipients {
+			pushes = append(pushes, push{userID: userID, msg: msg})
+		}
+	}
+	logger := srvu.GetLogger(ctx)
+	for _, p := range pushes {
+		if err := n.Sender.Push(ctx, p.userID, p.msg); err != nil {
+			logger.Printf("pushing %s to %s: %s", p.msg.Tag, p.userID, err)
+		}
+	}
+	return nil
+}
+
+func PushSubscribeHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var inp PushSubscriptionInput
+		if err := srvu.Decode(r, &inp, false); err != nil {
+			return err
+		}
+		if err := SavePushSubscription(ctx, db, auth.MustGetSession(ctx).UserID, r.UserAgent(), inp); err != nil {
+			return writeErr(err, "saving push subscription")
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+func PushSubscriptionDeleteHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if err := DeletePushSubscription(ctx, db, auth.MustGetSession(ctx).UserID, r.PathValue("subscriptionID")); errors.Is(err, sql.ErrNoRows) {
+			return srvu.Err(http.StatusNotFound, err)
+		} else if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		httpu.RedirectToNext(w, r, "/settings")
+		return nil
+	})
+}
@@ -0,0 +1,204 @@
+package core_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+// pushDevice is a browser's side of a subscription, it decrypts the pushes the push service passes on.
+type pushDevice struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newPushDevice() *pushDevice {
+	d := &pushDevice{key: Must(ecdh.P256().GenerateKey(rand.Reader)), auth: make([]byte, 16)}
+	Must(rand.Read(d.auth))
+	return d
+}
+
+func (d *pushDevice) subscription(endpoint string) map[string]any {
+	return map[string]any{
+		"endpoint": endpoint,
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(d.key.PublicKey().Bytes()),
+			"auth":   base64.URLEncoding.EncodeToString(d.auth),
+		},
+	}
+}
+
+func (d *pushDevice) decrypt(body []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, fmt.Errorf("short body")
+	}
+	salt, rs, idLen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	asPublic, record := body[21:21+idLen], body[21+idLen:]
+	if rs != 4096 || len(record) > int(rs) {
+		return nil, fmt.Errorf("unexpected record size %d of a %d byte record", rs, len(record))
+	}
+	asKey, err := ecdh.P256().NewPublicKey(asPublic)
+	if err != nil {
+		return nil, err
+	}
+	shared := Must(d.key.ECDH(asKey))
+	ikm := Must(hkdf.Key(sha256.New, shared, d.auth, "WebPush: info\x00"+string(d.key.PublicKey().Bytes())+string(asPublic), 32))
+	cek := Must(hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16))
+	nonce := Must(hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12))
+	gcm := Must(cipher.NewGCM(Must(aes.NewCipher(cek))))
+	plain, err := gcm.Open(nil, nonce, record, nil)
+	if err != nil {
+		return nil, err
+	}
+	plain = bytes.TrimRight(plain, "\x00")
+	if !bytes.HasSuffix(plain, []byte{0x02}) {
+		return nil, fmt.Errorf("expected the last record delimiter")
+	}
+	return plain[:len(plain)-1], nil
+}
+
+type pushService struct {
+	mu     sync.Mutex
+	pushes map[string][]*http.Request
+	bodies map[string][][]byte
+}
+
+func (s *pushService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pushes[r.URL.Path] = append(s.pushes[r.URL.Path], r)
+	s.bodies[r.URL.Path] = append(s.bodies[r.URL.Path], Must(io.ReadAll(r.Body)))
+	if strings.HasSuffix(r.URL.Path, "/gone") {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/broken") {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// verifyVAPID checks the vapid Authorization header is an ES256 JWT for the audience signed by the key.
+func verifyVAPID(header, key, audience string) error {
+	t, k, ok := strings.Cut(strings.TrimPrefix(header, "vapid t="), ", k=")
+	if !ok || k != key {
+		return fmt.Errorf("unexpected authorization %s", header)
+	}
+	pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), Must(base64.RawURLEncoding.DecodeString(k)))
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(t, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid JWT %s", t)
+	}
+	sig := Must(base64.RawURLEncoding.DecodeString(parts[2]))
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(sig) != 64 || !ecdsa.Verify(pub, hash[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		return fmt.Errorf("invalid signature")
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	Panic(json.Unmarshal(Must(base64.RawURLEncoding.DecodeString(parts[1])), &claims))
+	if claims.Aud != audience || claims.Sub != "mailto:admin@example.com" || claims.Exp < time.Now().Unix() {
+		return fmt.Errorf("unexpected claims %+v", claims)
+	}
+	return nil
+}
+
+func TestPush(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	service := &pushService{pushes: map[string][]*http.Request{}, bodies: map[string][][]byte{}}
+	srv := httptest.NewTLSServer(service)
+	defer srv.Close()
+	tok := Must(client.NewToken(ctx))
+	home := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	dishes := Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "dishes", "choreType": core.ChoreTypeInterval, "choreListID": home.List.ID, "interval": "1d",
+	}))
+	subscribe := func(body any) *ChoreReq {
+		return NewChoreReq(ctx, client).Auth(tok).Method("POST", "/settings/push/subscriptions/", strings.NewReader(string(Must(json.Marshal(body))))).
+			Header("Content-Type", "application/json").Header("User-Agent", "phone")
+	}
+
+	phone := newPushDevice()
+	if _, err := subscribe(phone.subscription("http://push.example.com/insecure")).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected insecure endpoints to be rejected: %s", err)
+	}
+	if _, err := subscribe(map[string]any{"endpoint": srv.URL + "/push/phone", "keys": map[string]string{"p256dh": "bm90IGEga2V5", "auth": "c2VjcmV0"}}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected invalid keys to be rejected: %s", err)
+	}
+	Must(subscribe(phone.subscription(srv.URL + "/push/phone")).DoAndExp(http.StatusNoContent))
+	Must(subscribe(phone.subscription(srv.URL + "/push/phone")).DoAndExp(http.StatusNoContent))
+	Must(subscribe(newPushDevice().subscription(srv.URL + "/push/gone")).DoAndExp(http.StatusNoContent))
+	Must(subscribe(newPushDevice().subscription(srv.URL + "/push/broken")).DoAndExp(http.StatusNoContent))
+	Must(NewChoreReq(ctx, client).Auth(tok).Get("/settings").DoAndExp(http.StatusOK))
+	settings := GetTpl[core.SettingsView](client.tmpl, "settings.page.gohtml")
+	if len(settings.Devices) != 3 || settings.Devices[0].UserAgent != "phone" || settings.PushKey == "" {
+		t.Fatalf("expected a device per endpoint, got %+v", settings.Devices)
+	}
+
+	sender := core.NewPushSender(client.db, "mailto:admin@example.com")
+	sender.Client = srv.Client()
+	scheduler := core.NewScheduler(client.db, &core.PushNotifier{Sender: sender})
+	now := date.Today().ToStdTime().UTC().Add(14 * time.Hour)
+	for _, days := range []int{-1, 0, 0, 0} {
+		scheduler.Now = func() time.Time { return now.Add(time.Duration(days) * 24 * time.Hour) }
+		Must(scheduler.Evaluate(ctx))
+	}
+	if len(service.pushes["/push/phone"]) != 1 || len(service.pushes["/push/gone"]) != 1 || len(service.pushes["/push/broken"]) != 1 {
+		t.Fatalf("expected a single push to each device despite the failing one, got %+v", service.pushes)
+	}
+	req := service.pushes["/push/phone"][0]
+	if req.Header.Get("Content-Encoding") != "aes128gcm" || req.Header.Get("TTL") == "" {
+		t.Fatalf("unexpected headers %+v", req.Header)
+	}
+	if err := verifyVAPID(req.Header.Get("Authorization"), settings.PushKey, srv.URL); err != nil {
+		t.Fatalf("expected a valid VAPID authorization: %s", err)
+	}
+	var msg core.PushMessage
+	Panic(json.Unmarshal(Must(phone.decrypt(service.bodies["/push/phone"][0])), &msg))
+	if msg.Title != "home" || msg.Body != "dishes is due today" || msg.URL != "/chore-lists/"+home.List.ID || msg.Tag != dishes.ID {
+		t.Fatalf("unexpected push %+v", msg)
+	}
+	devices := Must(core.GetPushSubscriptions(ctx, client.db, "test"))
+	phoneDevice := findInSlice(devices, func(d core.PushSubscription) bool { return d.Endpoint == srv.URL+"/push/phone" })
+	if len(devices) != 2 || phoneDevice == nil {
+		t.Fatalf("expected the gone subscription to be deleted, got %+v", devices)
+	}
+
+	deletePath := fmt.Sprintf("/settings/push/subscriptions/%s/delete", phoneDevice.ID)
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", deletePath, nil).DoAndExp(http.StatusSeeOther))
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", deletePath, nil).DoAndExp(http.StatusNotFound); err != nil {
+		t.Fatalf("expected deleted devices to be gone: %s", err)
+	}
+	if devices := Must(core.GetPushSubscriptions(ctx, client.db, "test")); len(devices) != 1 {
+		t.Fatalf("expected only the broken device, got %+v", devices)
+	}
+}
@@ -0,0 +1,386 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SimonSchneider/goslu/date"
+)
+
+// Frequency is the period a Recurrence repeats over.
+type Frequency int
+
+const (
+	FreqNone Frequency = iota
+	FreqDaily
+	FreqWeekly
+	FreqMonthly
+	FreqYearly
+)
+
+var frequencyNames = map[Frequency]string{
+	FreqDaily:   "daily",
+	FreqWeekly:  "weekly",
+	FreqMonthly: "monthly",
+	FreqYearly:  "yearly",
+}
+
+func (f Frequency) String() string {
+	return frequencyNames[f]
+}
+
+var weekdayNames = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+var monthNames = [...]string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+// NthWeekday is a weekday within a month, Nth 0 matches every such weekday and a negative Nth counts from the end of
+// the month (-1 is the last one).
+type NthWeekday struct {
+	Nth     int
+	Weekday time.Weekday
+}
+
+func (n NthWeekday) String() string {
+	wd := weekdayNames[n.Weekday]
+	switch {
+	case n.Nth == 0:
+		return wd
+	case n.Nth == -1:
+		return "last-" + wd
+	case n.Nth < 0:
+		return ordinal(-n.Nth) + "-last-" + wd
+	default:
+		return ordinal(n.Nth) + "-" + wd
+	}
+}
+
+// Recurrence is a calendar anchored repetition, a subset of the RFC 5545 recurrence rule. It is written in a small
+// language of a frequency, optionally followed by "/n" to only use every n:th period, and lists of months and days
+// that further restrict which dates match:
+//
+//	weekly mon,thu        every monday and thursday
+//	weekly/2 sat          every other saturday
+//	monthly 1,15          the 1st and 15th of each month
+//	monthly last          the last day of each month
+//	monthly last-fri      the last friday of each month
+//	monthly 2nd-tue       the second tuesday of each month
+//	yearly dec 24         every christmas eve
+//	weekly may,jun,jul,aug sat
+//
+// When both month days and weekdays are given a date has to match both, so "monthly 13 fri" is every friday the 13th.
+type Recurrence struct {
+	Freq       Frequency
+	Every      int
+	ByMonth    []time.Month
+	ByMonthDay []int
+	ByDay      []NthWeekday
+}
+
+func (r Recurrence) IsZero() bool {
+	return r.Freq == FreqNone
+}
+
+func (r Recurrence) String() string {
+	if r.IsZero() {
+		return ""
+	}
+	parts := []string{r.Freq.String()}
+	if r.Every > 1 {
+		parts[0] += "/" + strconv.Itoa(r.Every)
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = monthNames[m-1]
+		}
+		parts = append(parts, strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			if d == -1 {
+				days[i] = "last"
+			} else {
+				days[i] = strconv.Itoa(d)
+			}
+		}
+		parts = append(parts, strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		parts = append(parts, strings.Join(days, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+func ParseRecurrence(str string) (Recurrence, error) {
+	var r Recurrence
+	fields := strings.Fields(strings.ToLower(str))
+	if len(fields) == 0 {
+		return r, fmt.Errorf("invalid recurrence(%s): empty string", str)
+	}
+	freq, every, hasEvery := strings.Cut(fields[0], "/")
+	for f, name := range frequencyNames {
+		if name == freq {
+			r.Freq = f
+		}
+	}
+	if r.Freq == FreqNone {
+		return r, fmt.Errorf("invalid recurrence(%s): unknown frequency '%s'", str, freq)
+	}
+	r.Every = 1
+	if hasEvery {
+		n, err := strconv.Atoi(every)
+		if err != nil {
+			return r, fmt.Errorf("invalid recurrence(%s): invalid period count '%s'", str, every)
+		}
+		r.Every = n
+	}
+	for _, field := range fields[1:] {
+		if err := r.parseList(field); err != nil {
+			return r, fmt.Errorf("invalid recurrence(%s): %w", str, err)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return r, fmt.Errorf("invalid recurrence(%s): %w", str, err)
+	}
+	return r, nil
+}
+
+func (r *Recurrence) parseList(field string) error {
+	items := strings.Split(field, ",")
+	if _, ok := parseMonth(items[0]); ok {
+		if len(r.ByMonth) > 0 {
+			return fmt.Errorf("months given more than once")
+		}
+		for _, item := range items {
+			m, ok := parseMonth(item)
+			if !ok {
+				return fmt.Errorf("invalid month '%s'", item)
+			}
+			r.ByMonth = append(r.ByMonth, m)
+		}
+		return nil
+	}
+	monthDays, weekdays := len(r.ByMonthDay), len(r.ByDay)
+	for _, item := range items {
+		if item == "last" {
+			r.ByMonthDay = append(r.ByMonthDay, -1)
+		} else if d, err := strconv.Atoi(item); err == nil {
+			r.ByMonthDay = append(r.ByMonthDay, d)
+		} else if wd, err := parseNthWeekday(item); err == nil {
+			r.ByDay = append(r.ByDay, wd)
+		} else {
+			return fmt.Errorf("invalid day '%s': %w", item, err)
+		}
+	}
+	if monthDays > 0 && len(r.ByMonthDay) > monthDays || weekdays > 0 && len(r.ByDay) > weekdays {
+		return fmt.Errorf("days given more than once")
+	}
+	return nil
+}
+
+func parseMonth(str string) (time.Month, bool) {
+	for i, name := range monthNames {
+		if name == str {
+			return time.Month(i + 1), true
+		}
+	}
+	return 0, false
+}
+
+func parseWeekday(str string) (time.Weekday, bool) {
+	for i, name := range weekdayNames {
+		if name == str {
+			return time.Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+func parseNthWeekday(str string) (NthWeekday, error) {
+	if wd, ok := parseWeekday(str); ok {
+		return NthWeekday{Weekday: wd}, nil
+	}
+	idx := strings.LastIndexByte(str, '-')
+	if idx == -1 {
+		return NthWeekday{}, fmt.Errorf("unknown weekday")
+	}
+	wd, ok := parseWeekday(str[idx+1:])
+	if !ok {
+		return NthWeekday{}, fmt.Errorf("unknown weekday '%s'", str[idx+1:])
+	}
+	nth := str[:idx]
+	sign := 1
+	if nth == "last" {
+		return NthWeekday{Nth: -1, Weekday: wd}, nil
+	} else if before, ok := strings.CutSuffix(nth, "-last"); ok {
+		sign = -1
+		nth = before
+	}
+	n, err := parseOrdinal(nth)
+	if err != nil {
+		return NthWeekday{}, err
+	}
+	return NthWeekday{Nth: sign * n, Weekday: wd}, nil
+}
+
+func parseOrdinal(str string) (int, error) {
+	if len(str) < 3 {
+		return 0, fmt.Errorf("invalid ordinal '%s'", str)
+	}
+	n, err := strconv.Atoi(str[:len(str)-2])
+	if err != nil || ordinal(n) != str {
+		return 0, fmt.Errorf("invalid ordinal '%s'", str)
+	}
+	return n, nil
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+func (r Recurrence) Validate() error {
+	if r.IsZero() {
+		return fmt.Errorf("missing frequency")
+	}
+	if r.Every < 1 || r.Every > 99 {
+		return fmt.Errorf("period count must be between 1 and 99: %d", r.Every)
+	}
+	for _, d := range r.ByMonthDay {
+		if d == 0 || d > 31 || d < -31 {
+			return fmt.Errorf("invalid day of month: %d", d)
+		}
+	}
+	for _, d := range r.ByDay {
+		if d.Nth > 5 || d.Nth < -5 {
+			return fmt.Errorf("invalid weekday in month: %s", d)
+		}
+	}
+	switch r.Freq {
+	case FreqDaily:
+		if len(r.ByMonthDay) > 0 || len(r.ByDay) > 0 {
+			return fmt.Errorf("daily recurrence can't have days")
+		}
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return fmt.Errorf("weekly recurrence must have weekdays")
+		}
+		if len(r.ByMonthDay) > 0 {
+			return fmt.Errorf("weekly recurrence can't have days of month")
+		}
+		for _, d := range r.ByDay {
+			if d.Nth != 0 {
+				return fmt.Errorf("weekly recurrence can't have weekdays in month: %s", d)
+			}
+		}
+	case FreqMonthly:
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			return fmt.Errorf("monthly recurrence must have days")
+		}
+	case FreqYearly:
+		if len(r.ByMonth) == 0 {
+			return fmt.Errorf("yearly recurrence must have months")
+		}
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			return fmt.Errorf("yearly recurrence must have days")
+		}
+	default:
+		return fmt.Errorf("unknown frequency: %d", r.Freq)
+	}
+	if _, ok := r.Next(0, 0); !ok {
+		return fmt.Errorf("never occurs")
+	}
+	return nil
+}
+
+// Next returns the first date strictly after `after` that matches the recurrence. The anchor decides which periods
+// count when only every n:th period is used. If nothing matches within the search horizon the end of the horizon is
+// returned together with false.
+func (r Recurrence) Next(after, anchor date.Date) (date.Date, bool) {
+	horizon := after.Add(8 * date.Year * date.Duration(max(r.Every, 1)))
+	for d := after.Add(date.Day); d.Before(horizon); d = d.Add(date.Day) {
+		if r.matches(d, anchor) {
+			return d, true
+		}
+	}
+	return horizon, false
+}
+
+func (r Recurrence) matches(d, anchor date.Date) bool {
+	t := d.ToStdTime().UTC()
+	if len(r.ByMonth) > 0 && !contains(r.ByMonth, t.Month()) {
+		return false
+	}
+	if r.Every > 1 && floorMod(r.periodIndex(d)-r.periodIndex(anchor), r.Every) != 0 {
+		return false
+	}
+	daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if len(r.ByMonthDay) > 0 && !matchesAny(r.ByMonthDay, func(md int) bool {
+		return md == t.Day() || md < 0 && daysInMonth+md+1 == t.Day()
+	}) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !matchesAny(r.ByDay, func(wd NthWeekday) bool {
+		if wd.Weekday != t.Weekday() {
+			return false
+		}
+		if wd.Nth > 0 {
+			return (t.Day()-1)/7+1 == wd.Nth
+		}
+		if wd.Nth < 0 {
+			return (daysInMonth-t.Day())/7+1 == -wd.Nth
+		}
+		return true
+	}) {
+		return false
+	}
+	return true
+}
+
+func (r Recurrence) periodIndex(d date.Date) int {
+	t := d.ToStdTime().UTC()
+	switch r.Freq {
+	case FreqWeekly:
+		// weeks start on monday, date 0 is a thursday
+		return int(d+3) / 7
+	case FreqMonthly:
+		return t.Year()*12 + int(t.Month())
+	case FreqYearly:
+		return t.Year()
+	default:
+		return int(d)
+	}
+}
+
+func floorMod(a, b int) int {
+	return ((a % b) + b) % b
+}
+
+func contains[T comparable](s []T, v T) bool {
+	return matchesAny(s, func(e T) bool { return e == v })
+}
+
+func matchesAny[T any](s []T, pred func(T) bool) bool {
+	for _, e := range s {
+		if pred(e) {
+			return true
+		}
+	}
+	return false
+}
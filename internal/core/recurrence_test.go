@@ -0,0 +1,185 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func TestParseAndPrintRecurrence(t *testing.T) {
+	tests := []struct {
+		s string
+		e string
+	}{
+		{s: "daily"},
+		{s: "daily/3"},
+		{s: "weekly mon,thu"},
+		{s: "Weekly/2  SAT", e: "weekly/2 sat"},
+		{s: "monthly 1,15"},
+		{s: "monthly -1", e: "monthly last"},
+		{s: "monthly last-fri"},
+		{s: "monthly 2nd-tue,4th-tue"},
+		{s: "monthly 2nd-last-sun"},
+		{s: "monthly 13 fri"},
+		{s: "yearly dec 24"},
+		{s: "yearly 1st-mon mar,sep", e: "yearly mar,sep 1st-mon"},
+		{s: "weekly may,jun,jul,aug sat"},
+	}
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			r, err := core.ParseRecurrence(test.s)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			exp := test.s
+			if test.e != "" {
+				exp = test.e
+			}
+			if r.String() != exp {
+				t.Fatalf("expected %v, got %v", exp, r.String())
+			}
+		})
+	}
+}
+
+func TestInvalidRecurrenceParsing(t *testing.T) {
+	tests := []string{
+		"",
+		"hourly",
+		"weekly",
+		"weekly 1",
+		"weekly 1st-mon",
+		"weekly/0 mon",
+		"daily mon",
+		"monthly",
+		"monthly 32",
+		"monthly 6th-mon",
+		"monthly 1th-mon",
+		"monthly mon tue",
+		"yearly 24",
+		"yearly feb 30",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			r, err := core.ParseRecurrence(test)
+			if err == nil {
+				t.Fatalf("expected error, got nil err and recurrence %s", r)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	tests := []struct {
+		rec    string
+		anchor string
+		after  string
+		exp    string
+	}{
+		{rec: "monthly 1", after: "2024-07-01", exp: "2024-08-01"},
+		{rec: "monthly 1", after: "2024-06-30", exp: "2024-07-01"},
+		{rec: "monthly last", after: "2024-02-01", exp: "2024-02-29"},
+		{rec: "monthly 31", after: "2024-04-01", exp: "2024-05-31"},
+		{rec: "monthly last-fri", after: "2024-07-14", exp: "2024-07-26"},
+		{rec: "monthly last-fri", after: "2024-07-26", exp: "2024-08-30"},
+		{rec: "monthly 2nd-tue", after: "2024-07-01", exp: "2024-07-09"},
+		{rec: "monthly 13 fri", after: "2024-01-01", exp: "2024-09-13"},
+		{rec: "weekly mon,thu", after: "2024-07-15", exp: "2024-07-18"},
+		{rec: "weekly mon,thu", after: "2024-07-18", exp: "2024-07-22"},
+		{rec: "weekly/2 sat", anchor: "2024-07-01", after: "2024-07-06", exp: "2024-07-20"},
+		{rec: "weekly/2 sat", anchor: "2024-07-08", after: "2024-07-01", exp: "2024-07-13"},
+		{rec: "daily/3", anchor: "2024-07-01", after: "2024-07-01", exp: "2024-07-04"},
+		{rec: "yearly dec 24", after: "2024-12-24", exp: "2025-12-24"},
+		{rec: "yearly feb 29", after: "2024-03-01", exp: "2028-02-29"},
+		{rec: "weekly may,jun,jul,aug sat", after: "2024-08-31", exp: "2025-05-03"},
+	}
+	for _, test := range tests {
+		t.Run(test.rec+" after "+test.after, func(t *testing.T) {
+			r := Must(core.ParseRecurrence(test.rec))
+			anchor := date.Date(0)
+			if test.anchor != "" {
+				anchor = Must(date.ParseDate(test.anchor))
+			}
+			next, ok := r.Next(Must(date.ParseDate(test.after)), anchor)
+			if !ok {
+				t.Fatalf("expected an occurrence")
+			}
+			if next.String() != test.exp {
+				t.Fatalf("expected %s, got %s", test.exp, next)
+			}
+		})
+	}
+}
+
+func TestRRuleRoundTrip(t *testing.T) {
+	tests := []struct {
+		rec   string
+		rrule string
+	}{
+		{rec: "daily/3", rrule: "FREQ=DAILY;INTERVAL=3"},
+		{rec: "weekly mon,thu", rrule: "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{rec: "weekly/2 sat", rrule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA"},
+		{rec: "monthly 1,last", rrule: "FREQ=MONTHLY;BYMONTHDAY=1,-1"},
+		{rec: "monthly last-fri", rrule: "FREQ=MONTHLY;BYDAY=-1FR"},
+		{rec: "monthly 13 fri", rrule: "FREQ=MONTHLY;BYMONTHDAY=13;BYDAY=FR"},
+		{rec: "yearly dec 24", rrule: "FREQ=YEARLY;BYMONTH=12;BYMONTHDAY=24"},
+	}
+	for _, test := range tests {
+		t.Run(test.rec, func(t *testing.T) {
+			r := Must(core.ParseRecurrence(test.rec))
+			if r.RRule() != test.rrule {
+				t.Fatalf("expected %s, got %s", test.rrule, r.RRule())
+			}
+			parsed, count, err := core.ParseRRule("RRULE:" + test.rrule)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count != -1 {
+				t.Fatalf("expected no count, got %d", count)
+			}
+			if parsed.String() != test.rec {
+				t.Fatalf("expected %s, got %s", test.rec, parsed)
+			}
+		})
+	}
+}
+
+func TestInvalidRRuleParsing(t *testing.T) {
+	tests := []string{
+		"",
+		"FREQ=HOURLY",
+		"FREQ=WEEKLY",
+		"FREQ=MONTHLY;BYSETPOS=-1;BYDAY=MO,TU,WE,TH,FR",
+		"FREQ=WEEKLY;BYDAY=MO;UNTIL=20250101T000000Z",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=WEEKLY;BYDAY=MO;COUNT=0",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			r, _, err := core.ParseRRule(test)
+			if err == nil {
+				t.Fatalf("expected error, got nil err and recurrence %s", r)
+			}
+		})
+	}
+}
+
+func TestChoreRRule(t *testing.T) {
+	tests := []struct {
+		chore core.Chore
+		rrule string
+	}{
+		{chore: core.Chore{ChoreType: core.ChoreTypeOneshot, RepeatsLeft: 1}, rrule: ""},
+		{chore: core.Chore{ChoreType: core.ChoreTypeInterval, Interval: 2 * date.Week, RepeatsLeft: -1}, rrule: "FREQ=WEEKLY;INTERVAL=2"},
+		{chore: core.Chore{ChoreType: core.ChoreTypeInterval, Interval: 3 * date.Day, RepeatsLeft: 4}, rrule: "FREQ=DAILY;INTERVAL=3;COUNT=4"},
+		{chore: core.Chore{ChoreType: core.ChoreTypeDateRepeating, Recurrence: Must(core.ParseRecurrence("monthly 1")), RepeatsLeft: -1}, rrule: "FREQ=MONTHLY;BYMONTHDAY=1"},
+	}
+	for _, test := range tests {
+		t.Run(test.rrule, func(t *testing.T) {
+			if rrule := test.chore.RRule(); rrule != test.rrule {
+				t.Fatalf("expected %s, got %s", test.rrule, rrule)
+			}
+		})
+	}
+}
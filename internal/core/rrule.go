@@ -0,0 +1,152 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var rruleFrequencies = map[Frequency]string{
+	FreqDaily:   "DAILY",
+	FreqWeekly:  "WEEKLY",
+	FreqMonthly: "MONTHLY",
+	FreqYearly:  "YEARLY",
+}
+
+var rruleWeekdays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// RRule formats the recurrence as the value of an RFC 5545 RRULE property.
+func (r Recurrence) RRule() string {
+	if r.IsZero() {
+		return ""
+	}
+	parts := []string{"FREQ=" + rruleFrequencies[r.Freq]}
+	if r.Every > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Every))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinFormatted(r.ByMonth, func(m time.Month) string { return strconv.Itoa(int(m)) }))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinFormatted(r.ByMonthDay, strconv.Itoa))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+joinFormatted(r.ByDay, func(d NthWeekday) string {
+			if d.Nth == 0 {
+				return rruleWeekdays[d.Weekday]
+			}
+			return strconv.Itoa(d.Nth) + rruleWeekdays[d.Weekday]
+		}))
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinFormatted[T any](s []T, format func(T) string) string {
+	strs := make([]string, len(s))
+	for i, v := range s {
+		strs[i] = format(v)
+	}
+	return strings.Join(strs, ",")
+}
+
+// IsRRule reports whether str looks like an RFC 5545 RRULE rather than the recurrence language.
+func IsRRule(str string) bool {
+	str = strings.ToUpper(strings.TrimSpace(str))
+	return strings.HasPrefix(str, "RRULE:") || strings.HasPrefix(str, "FREQ=")
+}
+
+// ParseRRule parses the RFC 5545 RRULE str, with or without the "RRULE:" property name. Rules that can't be
+// represented as a Recurrence are rejected. The returned count is the rule's COUNT or -1 if it repeats forever.
+func ParseRRule(str string) (Recurrence, int64, error) {
+	r := Recurrence{Every: 1}
+	count := int64(-1)
+	value := strings.TrimSpace(str)
+	if len(value) >= 6 && strings.EqualFold(value[:6], "RRULE:") {
+		value = value[6:]
+	}
+	for _, part := range strings.Split(strings.ToUpper(value), ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return r, 0, fmt.Errorf("invalid rrule(%s): malformed part '%s'", str, part)
+		}
+		var err error
+		switch key {
+		case "FREQ":
+			for f, name := range rruleFrequencies {
+				if name == val {
+					r.Freq = f
+				}
+			}
+			if r.Freq == FreqNone {
+				err = fmt.Errorf("unsupported frequency '%s'", val)
+			}
+		case "INTERVAL":
+			r.Every, err = strconv.Atoi(val)
+		case "COUNT":
+			count, err = strconv.ParseInt(val, 10, 64)
+			if err == nil && count < 1 {
+				err = fmt.Errorf("count must be positive")
+			}
+		case "BYMONTH":
+			r.ByMonth, err = splitParse(val, func(s string) (time.Month, error) {
+				m, err := strconv.Atoi(s)
+				if err != nil || m < 1 || m > 12 {
+					return 0, fmt.Errorf("invalid month '%s'", s)
+				}
+				return time.Month(m), nil
+			})
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = splitParse(val, strconv.Atoi)
+		case "BYDAY":
+			r.ByDay, err = splitParse(val, parseRRuleWeekday)
+		case "WKST":
+			if val != "MO" {
+				err = fmt.Errorf("only weeks starting on monday are supported")
+			}
+		default:
+			err = fmt.Errorf("unsupported part '%s'", key)
+		}
+		if err != nil {
+			return r, 0, fmt.Errorf("invalid rrule(%s): %w", str, err)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return r, 0, fmt.Errorf("invalid rrule(%s): %w", str, err)
+	}
+	return r, count, nil
+}
+
+func splitParse[T any](val string, parser func(string) (T, error)) ([]T, error) {
+	parts := strings.Split(val, ",")
+	res := make([]T, len(parts))
+	for i, part := range parts {
+		v, err := parser(part)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+
+func parseRRuleWeekday(str string) (NthWeekday, error) {
+	if len(str) < 2 {
+		return NthWeekday{}, fmt.Errorf("invalid weekday '%s'", str)
+	}
+	for i, name := range rruleWeekdays {
+		if name != str[len(str)-2:] {
+			continue
+		}
+		wd := NthWeekday{Weekday: time.Weekday(i)}
+		if nth := str[:len(str)-2]; nth != "" {
+			n, err := strconv.Atoi(nth)
+			if err != nil || n == 0 {
+				return NthWeekday{}, fmt.Errorf("invalid weekday '%s'", str)
+			}
+			wd.Nth = n
+		}
+		return wd, nil
+	}
+	return NthWeekday{}, fmt.Errorf("invalid weekday '%s'", str)
+}
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
 
 	choretracker "github.com/SimonSchneider/chore-tracker"
@@ -35,17 +36,26 @@ func LoginPage(view *View) http.Handler {
 	})
 }
 
-func Mux(db *sql.DB, view *View, authConfig auth.Config, apiKey string) http.Handler {
+func Mux(db *sql.DB, view *View, authConfig auth.Config) http.Handler {
 	inviteStore := &InviteStore{db: db, view: view}
 	mux := http.NewServeMux()
 	mux.Handle("GET /login", srvu.With(LoginPage(view), authConfig.Middleware(true, true)))
 	mux.Handle("POST /logout", authConfig.DeleteSessionHandler())
 	mux.Handle(authConfig.SessionsPath, authConfig.SessionHandler())
 	mux.Handle("GET /settings", srvu.With(SettingsPage(view, db), authConfig.Middleware(false, false)))
+	mux.Handle("POST /settings/tokens/{$}", srvu.With(APITokenCreateHandler(view, db), authConfig.Middleware(false, false)))
+	mux.Handle("POST /settings/tokens/{tokenID}/delete", srvu.With(APITokenRevokeHandler(db), authConfig.Middleware(false, false)))
+	mux.Handle("POST /settings/feed", srvu.With(UserFeedRotateHandler(db), authConfig.Middleware(false, false)))
+	mux.Handle("POST /settings/feed/delete", srvu.With(UserFeedDeleteHandler(db), authConfig.Middleware(false, false)))
+	mux.Handle("POST /settings/digest", srvu.With(UserDigestUpdateHandler(db), authConfig.Middleware(false, false)))
+	mux.Handle("POST /settings/push/subscriptions/{$}", srvu.With(PushSubscribeHandler(db), authConfig.Middleware(false, false)))
+	mux.Handle("POST /settings/push/subscriptions/{subscriptionID}/delete", srvu.With(PushSubscriptionDeleteHandler(db), authConfig.Middleware(false, false)))
 
 	httpu.HandleNested(mux, "/invites/", auth.InviteHandler(inviteStore, authConfig))
 	mux.Handle("/chore-lists/", srvu.With(ChoreListMux(db, view, inviteStore), authConfig.Middleware(false, false)))
-	mux.Handle("/api/chore-lists/", srvu.With(ChoreListAPIMux(db, view, apiKey)))
+	mux.Handle("GET /api/chore-lists/{choreListID}/ics", APIChoreListIcsFile(db, view, authConfig.APITokens))
+	mux.Handle("GET /feeds/{file}", FeedHandler(db, view))
+	mux.Handle("/api/", srvu.With(APIMux(db), authConfig.APIMiddleware(), http.NewCrossOriginProtection().Handler))
 	mux.Handle("/chores/", srvu.With(ChoreMux(db, view), authConfig.Middleware(false, false)))
 	mux.Handle("/{$}", http.RedirectHandler("/chore-lists/", http.StatusFound))
 	return mux
@@ -58,7 +68,7 @@ func Run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer,
 	}
 	public, tmplProv, err := templ.GetPublicAndTemplates(choretracker.StaticEmbeddedFS, &templ.Config{
 		Watch:        cfg.Watch,
-		TmplPatterns: []string{"templates/*.gohtml", "templates/*.goics"},
+		TmplPatterns: []string{"templates/*.gohtml"},
 	})
 	if err != nil {
 		return fmt.Errorf("sub static: %w", err)
@@ -76,7 +86,7 @@ func RunCfg(ctx context.Context, stdout io.Writer, cfg Config, public fs.FS, tmp
 		return fmt.Errorf("failed to migrate db: %w", err)
 	}
 
-	view := NewView(tmplProv)
+	view := NewView(tmplProv, cfg.BaseURL)
 	authConfig := auth.Config{
 		Provider:                    &AuthProvider{db: db},
 		RedirectParam:               "redirect",
@@ -99,11 +109,12 @@ func RunCfg(ctx context.Context, stdout io.Writer, cfg Config, public fs.FS, tmp
 			TokenLength:   102,
 			Store:         &DBTokenStore{DB: db},
 		},
+		APITokens: &APITokenStore{DB: db},
 	}
 
 	mux := http.NewServeMux()
 	httpu.HandleNested(mux, "GET /static/public/", srvu.With(http.FileServerFS(public), http.NewCrossOriginProtection().Handler, srvu.WithCacheCtrlHeader(365*24*time.Hour)))
-	mux.Handle("/", Mux(db, view, authConfig, cfg.ApiKey))
+	mux.Handle("/", Mux(db, view, authConfig))
 
 	srv := &http.Server{
 		BaseContext: func(listener net.Listener) context.Context {
@@ -120,6 +131,18 @@ func RunCfg(ctx context.Context, stdout io.Writer, cfg Config, public fs.FS, tmp
 		}
 		logger.Printf("created invite: http://localhost%s/invites/%s", cfg.Addr, invID)
 	}
+	workerCtx, cancelWorkers := context.WithCancel(srvu.ContextWithLogger(ctx, logger))
+	var workers sync.WaitGroup
+	defer func() {
+		cancelWorkers()
+		workers.Wait()
+	}()
+	scheduler := NewScheduler(db, &WebhookNotifier{DB: db}, &PushNotifier{Sender: NewPushSender(db, cfg.BaseURL)})
+	workers.Go(func() { scheduler.Run(workerCtx, time.Minute) })
+	workers.Go(func() { NewWebhookSender(db).Run(workerCtx, time.Minute) })
+	if cfg.SMTP.Enabled() {
+		workers.Go(func() { NewDigestSender(db, view, cfg.SMTP, cfg.BaseURL).Run(workerCtx, time.Minute) })
+	}
 	return srvu.RunServerGracefully(ctx, srv, logger)
 }
 
@@ -153,11 +176,12 @@ func GenerateInvite(ctx context.Context, db *sql.DB) (string, error) {
 }
 
 type Config struct {
-	Addr   string
-	Watch  bool
-	DbURL  string
-	GenInv bool
-	ApiKey string
+	Addr    string
+	Watch   bool
+	DbURL   string
+	GenInv  bool
+	BaseURL string `config:"u:public URL of the app for links in emails and feeds and the contact of web pushes"`
+	SMTP    SMTPConfig
 }
 
 func parseConfig(args []string, getEnv func(string) string) (cfg Config, err error) {
@@ -0,0 +1,300 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+// States of a chore as evaluated by the Scheduler.
+const (
+	ChoreStateUpcoming = "upcoming"
+	ChoreStateDue      = "due"
+	ChoreStateOverdue  = "overdue"
+	ChoreStateBlocked  = "blocked"
+	ChoreStateFinished = "finished"
+)
+
+// Transition is a change of a chore's state, or of the date a due or overdue chore is due on when it was completed
+// and became due again before the next evaluation.
+type Transition struct {
+	ID         string
+	Chore      Chore
+	From       string
+	To         string
+	DueOn      date.Date
+	OccurredOn date.Date
+}
+
+// Notifier is a channel the Scheduler fans the transitions out to. Its name keeps track of the transitions it still has
+// to be notified of, so it must not change between runs. A transition may be notified again, by its ID, when the
+// scheduler failed to record that it was notified.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, transitions []Transition) error
+}
+
+// pendingTransitionTTL is how long the transitions of a failing notifier are retried.
+const pendingTransitionTTL = 24 * time.Hour
+
+// Location is the list's time zone, lists with an unknown time zone fall back to UTC.
+func Location(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		return time.UTC
+	}
+	return loc
+}
+
+// TodayIn is the date of now in the time zone.
+func TodayIn(now time.Time, loc *time.Location) date.Date {
+	y, m, d := now.In(loc).Date()
+	return date.FromTime(time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+}
+
+func validateTimezone(timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return fmt.Errorf("unknown time zone: '%s'", timezone)
+	}
+	return nil
+}
+
+// SetChoreListTimezone sets the time zone the list's days start in.
+func SetChoreListTimezone(ctx context.Context, db cdb.DBTX, userID, id, timezone string) (cdb.ChoreList, error) {
+	if err := validateTimezone(timezone); err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	cl, err := cdb.New(db).UpdateChoreListTimezone(ctx, cdb.UpdateChoreListTimezoneParams{ID: id, Timezone: timezone, UpdatedAt: time.Now().UnixMilli(), UserID: userID})
+	if err != nil {
+		return cdb.ChoreList{}, fmt.Errorf("updating time zone: %w", err)
+	}
+	return cl, nil
+}
+
+// ChoreState is the state of the chore on the day, blocked chores are the ones waiting for a prerequisite.
+func ChoreState(today date.Date, chore Chore, blocked bool) string {
+	switch durToNext := chore.DurationToNextFrom(today); {
+	case chore.IsFinished():
+		return ChoreStateFinished
+	case blocked:
+		return ChoreStateBlocked
+	case durToNext < 0:
+		return ChoreStateOverdue
+	case durToNext == 0:
+		return ChoreStateDue
+	default:
+		return ChoreStateUpcoming
+	}
+}
+
+// Scheduler evaluates the chores of every list once a day, when the day starts in the list's time zone. The changes
+// since the previous evaluation are recorded as transitions and passed on to the notifiers.
+type Scheduler struct {
+	DB        *sql.DB
+	Now       func() time.Time
+	Notifiers []Notifier
+	evaluated map[string]date.Date
+}
+
+func NewScheduler(db *sql.DB, notifiers ...Notifier) *Scheduler {
+	return &Scheduler{DB: db, Now: time.Now, Notifiers: notifiers}
+}
+
+// Evaluate evaluates the lists that haven't been evaluated yet today and notifies the transitions. The first
+// evaluation of a chore records its state without a transition. Transitions stay pending for every notifier until it
+// has been notified of them, the failures of all notifiers are joined.
+func (s *Scheduler) Evaluate(ctx context.Context) ([]Transition, error) {
+	if s.evaluated == nil {
+		s.evaluated = make(map[string]date.Date)
+	}
+	lists, err := cdb.New(s.DB).GetChoreLists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting chore lists: %w", err)
+	}
+	now := s.Now()
+	notifiers := make([]string, len(s.Notifiers))
+	for i, n := range s.Notifiers {
+		notifiers[i] = n.Name()
+	}
+	var transitions []Transition
+	for _, l := range lists {
+		today := TodayIn(now, Location(l.Timezone))
+		if s.evaluated[l.ID] == today {
+			continue
+		}
+		ts, err := evaluateChoreList(ctx, s.DB, l.ID, today, now, notifiers)
+		if err != nil {
+			return transitions, fmt.Errorf("evaluating chore list %s: %w", l.ID, err)
+		}
+		s.evaluated[l.ID] = today
+		transitions = append(transitions, ts...)
+	}
+	if err := expireTransitions(ctx, s.DB, now.Add(-pendingTransitionTTL)); err != nil {
+		return transitions, err
+	}
+	q := cdb.New(s.DB)
+	var errs []error
+	for _, n := range s.Notifiers {
+		if err := s.notifyPending(ctx, q, n); err != nil {
+			errs = append(errs, fmt.Errorf("notifying %s: %w", n.Name(), err))
+		}
+	}
+	return transitions, errors.Join(errs...)
+}
+
+// notifyPending notifies n of its pending transitions, they are no longer pending once it succeeded. Notifiers may be
+// notified of a transition again if that fails, so they ignore the transitions they were already notified of.
+func (s *Scheduler) notifyPending(ctx context.Context, q *cdb.Queries, n Notifier) error {
+	rows, err := q.GetPendingChoreTransitions(ctx, n.Name())
+	if err != nil {
+		return fmt.Errorf("getting pending transitions: %w", err)
+	}
+	chores := make(map[string]Chore)
+	loaded := make(map[string]bool)
+	transitions := make([]Transition, 0, len(rows))
+	for _, row := range rows {
+		if !loaded[row.ChoreListID] {
+			listChores, err := GetListChores(ctx, s.DB, row.ChoreListID)
+			if err != nil {
+				return err
+			}
+			for _, c := range listChores {
+				chores[c.ID] = c
+			}
+			loaded[row.ChoreListID] = true
+		}
+		chore, ok := chores[row.ChoreID]
+		if !ok {
+			// the chore moved to another list since, the transition is dropped when it expires
+			continue
+		}
+		transitions = append(transitions, Transition{
+			ID:         row.ID,
+			Chore:      chore,
+			From:       row.FromState,
+			To:         row.ToState,
+			DueOn:      date.Date(row.DueOn),
+			OccurredOn: date.Date(row.OccurredOn),
+		})
+	}
+	if len(transitions) == 0 {
+		return nil
+	}
+	if err := n.Notify(ctx, transitions); err != nil {
+		return err
+	}
+	ids := make([]string, len(transitions))
+	for i, t := range transitions {
+		ids[i] = t.ID
+	}
+	if err := q.DeletePendingChoreTransitions(ctx, cdb.DeletePendingChoreTransitionsParams{Notifier: n.Name(), TransitionIds: ids}); err != nil {
+		return fmt.Errorf("marking transitions notified: %w", err)
+	}
+	return nil
+}
+
+// expireTransitions stops retrying the transitions recorded before the cutoff and deletes the ones no notifier is
+// waiting for anymore.
+func expireTransitions(ctx context.Context, db *sql.DB, cutoff time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	if err := q.DeleteExpiredPendingChoreTransitions(ctx, cutoff.UnixMilli()); err != nil {
+		return fmt.Errorf("deleting expired pending transitions: %w", err)
+	}
+	if err := q.DeleteExpiredChoreTransitions(ctx, cutoff.UnixMilli()); err != nil {
+		return fmt.Errorf("deleting expired transitions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing tx: %w", err)
+	}
+	return nil
+}
+
+func evaluateChoreList(ctx context.Context, db *sql.DB, choreListID string, today date.Date, now time.Time, notifiers []string) ([]Transition, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	chores, err := GetListChores(ctx, tx, choreListID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.GetChoreStatesByList(ctx, choreListID)
+	if err != nil {
+		return nil, fmt.Errorf("getting chore states: %w", err)
+	}
+	prev := make(map[string]cdb.ChoreState, len(rows))
+	for _, row := range rows {
+		prev[row.ChoreID] = row
+	}
+	byID := make(map[string]*Chore, len(chores))
+	for i := range chores {
+		byID[chores[i].ID] = &chores[i]
+	}
+	var transitions []Transition
+	for _, c := range chores {
+		state := ChoreState(today, c, c.IsBlocked(byID))
+		dueOn := c.NextCompletion()
+		if p, ok := prev[c.ID]; ok && (p.State != state || p.DueOn != int64(dueOn) && isDueState(state)) {
+			t := Transition{ID: NewId(), Chore: c, From: p.State, To: state, DueOn: dueOn, OccurredOn: today}
+			if err := q.CreateChoreTransition(ctx, cdb.CreateChoreTransitionParams{
+				ID:          t.ID,
+				ChoreID:     c.ID,
+				ChoreListID: choreListID,
+				FromState:   t.From,
+				ToState:     t.To,
+				DueOn:       int64(dueOn),
+				OccurredOn:  int64(today),
+				CreatedAt:   now.UnixMilli(),
+			}); err != nil {
+				return nil, fmt.Errorf("recording transition: %w", err)
+			}
+			for _, n := range notifiers {
+				if err := q.CreatePendingChoreTransition(ctx, cdb.CreatePendingChoreTransitionParams{TransitionID: t.ID, Notifier: n, CreatedAt: now.UnixMilli()}); err != nil {
+					return nil, fmt.Errorf("recording pending transition: %w", err)
+				}
+			}
+			transitions = append(transitions, t)
+		}
+		if err := q.SetChoreState(ctx, cdb.SetChoreStateParams{ChoreID: c.ID, State: state, DueOn: int64(dueOn), EvaluatedOn: int64(today)}); err != nil {
+			return nil, fmt.Errorf("setting chore state: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing tx: %w", err)
+	}
+	return transitions, nil
+}
+
+func isDueState(state string) bool {
+	return state == ChoreStateDue || state == ChoreStateOverdue
+}
+
+// Run evaluates the lists every interval until the context is done.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	logger := srvu.GetLogger(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Evaluate(ctx); err != nil && ctx.Err() == nil {
+			logger.Printf("evaluating chores: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
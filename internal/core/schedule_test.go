@@ -0,0 +1,150 @@
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+type recordingNotifier struct {
+	name        string
+	err         error
+	transitions []core.Transition
+}
+
+func (n *recordingNotifier) Name() string {
+	return n.name
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, transitions []core.Transition) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.transitions = append(n.transitions, transitions...)
+	return nil
+}
+
+func TestScheduler(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	home := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	away := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "away"}))
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chore-lists/%s", away.List.ID), map[string]string{"name": "away", "timezone": "Pacific/Auckland"}).DoAndExp(http.StatusSeeOther))
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chore-lists/%s", home.List.ID), map[string]string{"name": "home", "timezone": "Mars/Olympus"}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected unknown time zones to be rejected: %s", err)
+	}
+	today := date.Today()
+	tomorrow := today.Add(date.Day)
+	dishes := Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "dishes", "choreType": core.ChoreTypeInterval, "choreListID": home.List.ID, "interval": "1d",
+	}))
+	taxes := Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "taxes", "choreType": core.ChoreTypeDate, "choreListID": home.List.ID, "date": tomorrow.String(), "repeats": "1",
+	}))
+	flight := Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "flight", "choreType": core.ChoreTypeDate, "choreListID": away.List.ID, "date": tomorrow.String(), "repeats": "1",
+	}))
+
+	notifier := &recordingNotifier{name: "recording"}
+	now := today.ToStdTime().UTC().Add(14 * time.Hour)
+	scheduler := core.NewScheduler(client.db, notifier)
+	at := func(days int) []core.Transition {
+		scheduler.Now = func() time.Time { return now.Add(time.Duration(days) * 24 * time.Hour) }
+		return Must(scheduler.Evaluate(ctx))
+	}
+	if ts := at(-1); len(ts) != 0 {
+		t.Fatalf("expected the first evaluation to only record the states, got %+v", ts)
+	}
+	ts := at(0)
+	if len(ts) != 2 {
+		t.Fatalf("expected the chores due today to transition, got %+v", ts)
+	}
+	if tr := *findInSlice(ts, func(tr core.Transition) bool { return tr.Chore.ID == flight.ID }); tr.From != core.ChoreStateUpcoming || tr.To != core.ChoreStateDue || tr.OccurredOn != tomorrow {
+		t.Fatalf("expected the chore to be due as it is tomorrow in the list's time zone, got %+v", tr)
+	}
+	if tr := *findInSlice(ts, func(tr core.Transition) bool { return tr.Chore.ID == dishes.ID }); tr.To != core.ChoreStateDue || tr.OccurredOn != today {
+		t.Fatalf("unexpected transition %+v", tr)
+	}
+	if ts := at(0); len(ts) != 0 || len(notifier.transitions) != 2 {
+		t.Fatalf("expected the lists to be evaluated once a day, got %+v", ts)
+	}
+
+	Panic(core.Complete(ctx, client.db, "test", dishes.ID, today, 0))
+	ts = at(1)
+	if len(ts) != 3 {
+		t.Fatalf("expected 3 transitions, got %+v", ts)
+	}
+	if tr := *findInSlice(ts, func(tr core.Transition) bool { return tr.Chore.ID == dishes.ID }); tr.From != core.ChoreStateDue || tr.To != core.ChoreStateDue || tr.DueOn != tomorrow {
+		t.Fatalf("expected the completed chore to become due again, got %+v", tr)
+	}
+	if tr := *findInSlice(ts, func(tr core.Transition) bool { return tr.Chore.ID == taxes.ID }); tr.To != core.ChoreStateDue {
+		t.Fatalf("unexpected transition %+v", tr)
+	}
+	if tr := *findInSlice(ts, func(tr core.Transition) bool { return tr.Chore.ID == flight.ID }); tr.From != core.ChoreStateDue || tr.To != core.ChoreStateOverdue {
+		t.Fatalf("expected the chore to become overdue, got %+v", tr)
+	}
+	if recorded := Must(client.DBQuery().GetChoreTransitions(ctx, flight.ID)); len(recorded) != 2 || recorded[1].ToState != core.ChoreStateOverdue {
+		t.Fatalf("expected the transitions to be recorded, got %+v", recorded)
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scheduler.Run(runCtx, time.Hour)
+	}()
+	stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the scheduler to stop with the context")
+	}
+}
+
+func TestSchedulerNotifierFailures(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	home := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	dishes := Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "dishes", "choreType": core.ChoreTypeInterval, "choreListID": home.List.ID, "interval": "1d",
+	}))
+	failing := &recordingNotifier{name: "failing", err: fmt.Errorf("unavailable")}
+	recording := &recordingNotifier{name: "recording"}
+	scheduler := core.NewScheduler(client.db, failing, recording)
+	now := date.Today().ToStdTime().UTC().Add(14 * time.Hour)
+	at := func(days int) ([]core.Transition, error) {
+		scheduler.Now = func() time.Time { return now.Add(time.Duration(days) * 24 * time.Hour) }
+		return scheduler.Evaluate(ctx)
+	}
+	Must(at(-1))
+	if ts, err := at(0); err == nil || len(ts) != 1 || len(recording.transitions) != 1 {
+		t.Fatalf("expected the failure to be returned after notifying the other notifiers, got %+v %v", ts, err)
+	}
+	failing.err = nil
+	if ts := Must(at(0)); len(ts) != 0 || len(failing.transitions) != 1 || failing.transitions[0].ID != recording.transitions[0].ID || failing.transitions[0].Chore.ID != dishes.ID {
+		t.Fatalf("expected the failed notifier to be retried with its pending transitions, got %+v", failing.transitions)
+	}
+	Must(at(0))
+	if len(failing.transitions) != 1 || len(recording.transitions) != 1 {
+		t.Fatalf("expected notified transitions to no longer be pending, got %+v %+v", failing.transitions, recording.transitions)
+	}
+
+	failing.err = fmt.Errorf("unavailable")
+	if _, err := at(1); err == nil || len(recording.transitions) != 2 {
+		t.Fatalf("expected the overdue transition to fail for one notifier, got %v", err)
+	}
+	failing.err = nil
+	if ts := Must(at(3)); len(ts) != 0 || len(failing.transitions) != 1 {
+		t.Fatalf("expected pending transitions to expire, got %+v", failing.transitions)
+	}
+	if recorded := Must(client.DBQuery().GetChoreTransitions(ctx, dishes.ID)); len(recorded) != 0 {
+		t.Fatalf("expected expired transitions to be deleted, got %+v", recorded)
+	}
+}
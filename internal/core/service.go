@@ -3,22 +3,63 @@ package core
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/SimonSchneider/chore-tracker/internal/cdb"
 	"github.com/SimonSchneider/goslu/date"
 	"github.com/SimonSchneider/goslu/sqlu"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 type Input struct {
-	Name        string
-	ChoreType   string
-	ChoreListID string
-	Interval    date.Duration
-	Repeats     int64
-	Link        string
-	Date        date.Date
+	Name          string
+	ChoreType     string
+	ChoreListID   string
+	Interval      date.Duration
+	Repeats       int64
+	Link          string
+	Date          date.Date
+	Recurrence    Recurrence
+	AssignedTo    string
+	Assignment    string
+	Checklist     []string
+	AutoComplete  bool
+	Prerequisites []string
+	Effort        int64
+	Version       int64
+}
+
+// InputFrom is the input that leaves the chore unchanged when updating it with it.
+func InputFrom(c Chore) Input {
+	checklist := make([]string, len(c.Checklist))
+	for i, item := range c.Checklist {
+		checklist[i] = item.Name
+	}
+	var day date.Date
+	if c.IsDate() {
+		day = c.LastCompletion
+	}
+	return Input{
+		Name:          c.Name,
+		ChoreType:     c.ChoreType,
+		ChoreListID:   c.ChoreListID,
+		Interval:      c.Interval,
+		Repeats:       c.RepeatsLeft,
+		Link:          c.Link,
+		Date:          day,
+		Recurrence:    c.Recurrence,
+		AssignedTo:    c.AssignedTo,
+		Assignment:    c.Assignment,
+		Checklist:     checklist,
+		AutoComplete:  c.AutoComplete,
+		Prerequisites: c.Prerequisites,
+		Effort:        c.Effort,
+		Version:       c.Version,
+	}
 }
 
 func parse[T any](into *T, parser func(string) (T, error), val string, ifEmpty T) error {
@@ -39,19 +80,101 @@ func parseInt(val string) (int64, error) {
 }
 
 func (i *Input) FromForm(r *http.Request) error {
-	i.Name = r.FormValue("name")
-	i.ChoreListID = r.FormValue("choreListID")
-	i.ChoreType = r.FormValue("choreType")
-	if err := parse(&i.Interval, date.ParseDuration, r.FormValue("interval"), 0); err != nil {
+	return i.fromValues(r.Form)
+}
+
+// fromValues parses the input from form values, JSON input is turned into the same values so both are parsed alike.
+func (i *Input) fromValues(form url.Values) error {
+	i.Name = form.Get("name")
+	i.ChoreListID = form.Get("choreListID")
+	i.ChoreType = form.Get("choreType")
+	if err := parse(&i.Interval, date.ParseDuration, form.Get("interval"), 0); err != nil {
 		return fmt.Errorf("invalid interval: %w", err)
 	}
-	if err := parse(&i.Repeats, parseInt, r.FormValue("repeats"), -1); err != nil {
+	if err := parse(&i.Repeats, parseInt, form.Get("repeats"), -1); err != nil {
 		return fmt.Errorf("invalid repeats: %w", err)
 	}
-	if err := parse(&i.Date, date.ParseDate, r.FormValue("date"), 0); err != nil {
+	if err := parse(&i.Date, date.ParseDate, form.Get("date"), 0); err != nil {
 		return fmt.Errorf("invalid date: %w", err)
 	}
-	i.Link = r.FormValue("link")
+	if recurrence := form.Get("recurrence"); IsRRule(recurrence) {
+		rec, count, err := ParseRRule(recurrence)
+		if err != nil {
+			return fmt.Errorf("invalid recurrence: %w", err)
+		}
+		i.Recurrence = rec
+		if count > 0 && form.Get("repeats") == "" {
+			i.Repeats = count
+		}
+	} else if err := parse(&i.Recurrence, ParseRecurrence, recurrence, Recurrence{}); err != nil {
+		return fmt.Errorf("invalid recurrence: %w", err)
+	}
+	if err := parse(&i.Effort, parseInt, form.Get("effort"), 1); err != nil {
+		return fmt.Errorf("invalid effort: %w", err)
+	}
+	i.Link = form.Get("link")
+	i.AssignedTo = form.Get("assignedTo")
+	i.Assignment = form.Get("assignment")
+	i.Checklist = ParseChecklist(form.Get("checklist"))
+	i.AutoComplete = form.Get("autoComplete") != ""
+	i.Prerequisites = nil
+	for _, p := range form["prerequisites"] {
+		if p != "" && !contains(i.Prerequisites, p) {
+			i.Prerequisites = append(i.Prerequisites, p)
+		}
+	}
+	return nil
+}
+
+type inputJSON struct {
+	Name          string   `json:"name"`
+	ChoreType     string   `json:"choreType"`
+	ChoreListID   string   `json:"choreListID"`
+	Interval      string   `json:"interval"`
+	Repeats       *int64   `json:"repeats"`
+	Link          string   `json:"link"`
+	Date          string   `json:"date"`
+	Recurrence    string   `json:"recurrence"`
+	AssignedTo    string   `json:"assignedTo"`
+	Assignment    string   `json:"assignment"`
+	Checklist     []string `json:"checklist"`
+	AutoComplete  bool     `json:"autoComplete"`
+	Prerequisites []string `json:"prerequisites"`
+	Effort        *int64   `json:"effort"`
+	Version       int64    `json:"version"`
+}
+
+func (i *Input) UnmarshalJSON(b []byte) error {
+	var in inputJSON
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	form := url.Values{
+		"name":          {in.Name},
+		"choreType":     {in.ChoreType},
+		"choreListID":   {in.ChoreListID},
+		"interval":      {in.Interval},
+		"link":          {in.Link},
+		"date":          {in.Date},
+		"recurrence":    {in.Recurrence},
+		"assignedTo":    {in.AssignedTo},
+		"assignment":    {in.Assignment},
+		"checklist":     {strings.Join(in.Checklist, "\n")},
+		"prerequisites": in.Prerequisites,
+	}
+	if in.Repeats != nil {
+		form.Set("repeats", strconv.FormatInt(*in.Repeats, 10))
+	}
+	if in.Effort != nil {
+		form.Set("effort", strconv.FormatInt(*in.Effort, 10))
+	}
+	if in.AutoComplete {
+		form.Set("autoComplete", "true")
+	}
+	if err := i.fromValues(form); err != nil {
+		return err
+	}
+	i.Version = in.Version
 	return nil
 }
 
@@ -65,6 +188,26 @@ func (i *Input) Validate(prev *Chore) error {
 	if prev != nil {
 		i.ChoreType = prev.ChoreType
 	}
+	if i.Effort < 0 || i.Effort > MaxEffort {
+		return fmt.Errorf("effort must be between 0 and %d: %d", MaxEffort, i.Effort)
+	}
+	if i.AutoComplete && len(i.Checklist) == 0 {
+		return fmt.Errorf("only chores with a checklist can be completed automatically")
+	}
+	if !IsAssignment(i.Assignment) {
+		return fmt.Errorf("illegal assignment: %s", i.Assignment)
+	}
+	switch {
+	case i.AssignedTo == "" && (i.Assignment == AssignmentRoundRobin || i.Assignment == AssignmentLeastRecent):
+		return fmt.Errorf("%s assignment needs an assignee to start with", i.Assignment)
+	case i.AssignedTo == "":
+		i.Assignment = AssignmentNone
+	case i.Assignment == AssignmentNone:
+		i.Assignment = AssignmentFixed
+	}
+	if i.ChoreType != ChoreTypeDateRepeating && !i.Recurrence.IsZero() {
+		return fmt.Errorf("%s chore can't have a recurrence", i.ChoreType)
+	}
 	switch i.ChoreType {
 	case ChoreTypeInterval:
 		if !i.Date.IsZero() {
@@ -73,7 +216,7 @@ func (i *Input) Validate(prev *Chore) error {
 		if i.Interval.Zero() {
 			return fmt.Errorf("interval chore can't have a zero interval")
 		}
-		if !(i.Repeats == -1 || i.Repeats > 0) {
+		if !i.validRepeats(prev) {
 			return fmt.Errorf("interval chore must have repeats > 0 or -1")
 		}
 		if prev != nil {
@@ -89,6 +232,9 @@ func (i *Input) Validate(prev *Chore) error {
 		if i.Repeats != 1 {
 			return fmt.Errorf("oneshot chore can't have repeats")
 		}
+		if prev != nil && prev.IsFinished() {
+			i.Repeats = 0
+		}
 		if prev != nil {
 			i.Date = prev.LastCompletion
 		}
@@ -102,12 +248,36 @@ func (i *Input) Validate(prev *Chore) error {
 		if i.Repeats != 1 {
 			return fmt.Errorf("date chore can't have repeats: %d", i.Repeats)
 		}
+		if prev != nil && prev.IsFinished() {
+			i.Repeats = 0
+		}
+	case ChoreTypeDateRepeating:
+		if i.Recurrence.IsZero() {
+			return fmt.Errorf("date-repeating chore must have a recurrence")
+		}
+		if !i.Date.IsZero() {
+			return fmt.Errorf("date-repeating chore can't have a date")
+		}
+		if !i.Interval.Zero() {
+			return fmt.Errorf("date-repeating chore can't have an interval")
+		}
+		if !i.validRepeats(prev) {
+			return fmt.Errorf("date-repeating chore must have repeats > 0 or -1")
+		}
+		if prev != nil {
+			i.Date = prev.LastCompletion
+		}
 	default:
 		return fmt.Errorf("illegal choreType: %s", i.ChoreType)
 	}
 	return nil
 }
 
+// validRepeats allows repeating forever (-1) or a number of times, finished chores may also keep their 0 repeats.
+func (i *Input) validRepeats(prev *Chore) bool {
+	return i.Repeats == -1 || i.Repeats > 0 || i.Repeats == 0 && prev != nil && prev.IsFinished()
+}
+
 func ChoresFromDb(dbChores []cdb.Chore) []Chore {
 	chores := make([]Chore, len(dbChores))
 	for i, dbChore := range dbChores {
@@ -131,9 +301,29 @@ func get(ctx context.Context, db *cdb.Queries, userID, id string) (*Chore, error
 
 func Create(ctx context.Context, db *sql.DB, today date.Date, userID string, input Input) (*Chore, error) {
 	if err := input.Validate(nil); err != nil {
-		return nil, fmt.Errorf("invalid input: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	chore, err := create(ctx, cdb.New(tx), today, userID, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing tx: %w", err)
+	}
+	return chore, nil
+}
+
+// create creates the chore from the validated input within the transaction of q.
+func create(ctx context.Context, q *cdb.Queries, today date.Date, userID string, input Input) (*Chore, error) {
+	if err := validateAssignee(ctx, q, input.ChoreListID, input.AssignedTo); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
 	}
-	row, err := cdb.New(db).CreateChore(ctx, cdb.CreateChoreParams{
+	row, err := q.CreateChore(ctx, cdb.CreateChoreParams{
 		ID:             NewId(),
 		Name:           input.Name,
 		ChoreType:      input.ChoreType,
@@ -144,11 +334,25 @@ func Create(ctx context.Context, db *sql.DB, today date.Date, userID string, inp
 		RepeatsLeft:    input.Repeats,
 		CreatedBy:      userID,
 		Link:           sqlu.NullString(input.Link),
+		Recurrence:     input.Recurrence.String(),
+		AssignedTo:     sqlu.NullString(input.AssignedTo),
+		Assignment:     input.Assignment,
+		AutoComplete:   boolToInt(input.AutoComplete),
+		Effort:         input.Effort,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("creating chore: %w", err)
 	}
 	chore := ChoreFromDb(row)
+	if chore.Checklist, err = setChecklist(ctx, q, chore.ID, input.Checklist); err != nil {
+		return nil, fmt.Errorf("creating checklist: %w", err)
+	}
+	if err := setPrerequisites(ctx, q, &chore, input.Prerequisites); err != nil {
+		return nil, fmt.Errorf("setting prerequisites: %w", err)
+	}
+	if err := enqueueWebhooks(ctx, q, WebhookCreated, userID, chore); err != nil {
+		return nil, err
+	}
 	return &chore, nil
 }
 
@@ -157,20 +361,55 @@ func Update(ctx context.Context, db *sql.DB, prev *Chore, input Input) (*Chore,
 		return nil, fmt.Errorf("illegal empty id for updating chore")
 	}
 	if err := input.Validate(prev); err != nil {
-		return nil, fmt.Errorf("invalid input: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if input.Version != 0 && input.Version != prev.Version {
+		return nil, ErrStale
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning tx: %w", err)
 	}
-	dbChore, err := cdb.New(db).UpdateChore(ctx, cdb.UpdateChoreParams{
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	if err := validateAssignee(ctx, q, prev.ChoreListID, input.AssignedTo); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	// only date chores are given their date, the others keep their last completion and snooze
+	lastCompletion, snoozedFor := prev.LastCompletion, prev.SnoozedFor
+	if prev.IsDate() {
+		lastCompletion, snoozedFor = input.Date, 0
+	}
+	dbChore, err := q.UpdateChore(ctx, cdb.UpdateChoreParams{
 		ID:             prev.ID,
+		Version:        prev.Version,
 		Name:           input.Name,
 		Interval:       int64(input.Interval),
 		RepeatsLeft:    input.Repeats,
-		LastCompletion: int64(input.Date),
+		SnoozedFor:     int64(snoozedFor),
+		LastCompletion: int64(lastCompletion),
 		Link:           sqlu.NullString(input.Link),
+		Recurrence:     input.Recurrence.String(),
+		AssignedTo:     sqlu.NullString(input.AssignedTo),
+		Assignment:     input.Assignment,
+		AutoComplete:   boolToInt(input.AutoComplete),
+		Effort:         input.Effort,
 	})
-	if err != nil {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrStale
+	} else if err != nil {
 		return nil, fmt.Errorf("updating chore: %w", err)
 	}
 	chore := ChoreFromDb(dbChore)
+	if chore.Checklist, err = setChecklist(ctx, q, chore.ID, input.Checklist); err != nil {
+		return nil, fmt.Errorf("updating checklist: %w", err)
+	}
+	if err := setPrerequisites(ctx, q, &chore, input.Prerequisites); err != nil {
+		return nil, fmt.Errorf("setting prerequisites: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing tx: %w", err)
+	}
 	return &chore, nil
 }
 
@@ -181,43 +420,86 @@ func Delete(ctx context.Context, db *sql.DB, id string) error {
 	return nil
 }
 
-func Complete(ctx context.Context, db *sql.DB, userID, id string, occurredAt date.Date) error {
-	// TODO: idempotency (with etag versioning?)
-	// TODO: don't complete if already completed on this day.
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrFinished     = errors.New("chore is finished")
+	ErrStale        = errors.New("chore was changed since it was read")
+)
+
+// Complete completes the chore on occurredAt. Completing a chore that was already completed on that day does nothing,
+// so two members completing it at once only count once. A non-zero version must match the chore's current version.
+func Complete(ctx context.Context, db *sql.DB, userID, id string, occurredAt date.Date, version int64) error {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("beginning tx: %w", err)
 	}
-	defer tx.Commit()
+	defer tx.Rollback()
+	txc := cdb.New(tx)
+	ex, err := get(ctx, txc, userID, id)
+	if err != nil {
+		return fmt.Errorf("getting chore: %w", err)
+	}
+	if err := complete(ctx, txc, userID, ex, occurredAt, version); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing tx: %w", err)
+	}
+	return nil
+}
+
+func complete(ctx context.Context, txc *cdb.Queries, userID string, ex *Chore, occurredAt date.Date, version int64) error {
 	if occurredAt.IsZero() {
 		occurredAt = date.Today()
 	}
 	occAt := int64(occurredAt)
-	txc := cdb.New(tx)
-	if err := txc.CreateChoreEvent(ctx, cdb.CreateChoreEventParams{ID: NewId(), ChoreID: id, OccurredAt: occAt, CreatedBy: userID, EventType: "complete"}); err != nil {
-		tx.Rollback()
+	completed, err := txc.HasChoreCompletionOn(ctx, cdb.HasChoreCompletionOnParams{ChoreID: ex.ID, OccurredAt: occAt})
+	if err != nil {
+		return fmt.Errorf("checking completions on %s: %w", occurredAt, err)
+	}
+	if completed == 1 {
+		return nil
+	}
+	if ex.IsFinished() {
+		return ErrFinished
+	}
+	if version != 0 && version != ex.Version {
+		return ErrStale
+	}
+	if err := txc.CreateChoreEvent(ctx, cdb.CreateChoreEventParams{ID: NewId(), ChoreID: ex.ID, OccurredAt: occAt, CreatedBy: userID, EventType: EventTypeComplete, PreviousCompletion: sql.NullInt64{Int64: int64(ex.LastCompletion), Valid: true}}); err != nil {
 		return fmt.Errorf("inserting new event: %w", err)
 	}
-	if err := txc.CompleteChore(ctx, cdb.CompleteChoreParams{ID: id, LastCompletion: occAt}); err != nil {
-		tx.Rollback()
+	if n, err := txc.CompleteChore(ctx, cdb.CompleteChoreParams{ID: ex.ID, LastCompletion: int64(ex.completion(occurredAt)), Version: ex.Version}); err != nil {
 		return fmt.Errorf("updating last completion: %w", err)
+	} else if n == 0 {
+		return ErrStale
 	}
-	return nil
+	if err := rotateAssignee(ctx, txc, ex); err != nil {
+		return fmt.Errorf("rotating assignee: %w", err)
+	}
+	if err := txc.ResetChoreChecklist(ctx, ex.ID); err != nil {
+		return fmt.Errorf("resetting checklist: %w", err)
+	}
+	completedChore, err := get(ctx, txc, userID, ex.ID)
+	if err != nil {
+		return fmt.Errorf("getting completed chore: %w", err)
+	}
+	return enqueueWebhooks(ctx, txc, WebhookCompleted, userID, *completedChore)
 }
 
-func Expedite(ctx context.Context, db *sql.DB, today date.Date, userID, id string) error {
-	return changeSnooze(ctx, db, today, userID, id, 0, func(durToNext date.Duration) bool {
+func Expedite(ctx context.Context, db *sql.DB, today date.Date, userID, id string, version int64) error {
+	return changeSnooze(ctx, db, today, userID, id, EventTypeExpedite, 0, version, func(durToNext date.Duration) bool {
 		return durToNext <= 0
 	})
 }
 
-func Snooze(ctx context.Context, db *sql.DB, today date.Date, userID, id string, snoozeFor date.Duration) error {
-	return changeSnooze(ctx, db, today, userID, id, snoozeFor, func(durToNext date.Duration) bool {
+func Snooze(ctx context.Context, db *sql.DB, today date.Date, userID, id string, snoozeFor date.Duration, version int64) error {
+	return changeSnooze(ctx, db, today, userID, id, EventTypeSnooze, snoozeFor, version, func(durToNext date.Duration) bool {
 		return durToNext > 0
 	})
 }
 
-func changeSnooze(ctx context.Context, db *sql.DB, today date.Date, userID, id string, snoozeFor date.Duration, validateDurToNext func(date.Duration) bool) error {
+func changeSnooze(ctx context.Context, db *sql.DB, today date.Date, userID, id, eventType string, snoozeFor date.Duration, version int64, validateDurToNext func(date.Duration) bool) error {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("beginning tx: %w", err)
@@ -229,15 +511,44 @@ func changeSnooze(ctx context.Context, db *sql.DB, today date.Date, userID, id s
 		tx.Rollback()
 		return fmt.Errorf("getting chore: %w", err)
 	}
+	if version != 0 && version != ex.Version {
+		tx.Rollback()
+		return ErrStale
+	}
 	durToNext := ex.DurationToNextFrom(today)
 	if validateDurToNext(durToNext) {
 		tx.Rollback()
 		return fmt.Errorf("can't snooze a chore that is not due: %s", durToNext)
 	}
 	snoozedFor := snoozeFor + ex.SnoozedFor - durToNext
-	if err := txc.SnoozeChore(ctx, cdb.SnoozeChoreParams{ID: id, SnoozedFor: int64(snoozedFor)}); err != nil {
+	if n, err := txc.SnoozeChore(ctx, cdb.SnoozeChoreParams{ID: id, SnoozedFor: int64(snoozedFor), Version: ex.Version}); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("updating snooze duration: %w", err)
+	} else if n == 0 {
+		tx.Rollback()
+		return ErrStale
+	}
+	if err := txc.CreateChoreEvent(ctx, cdb.CreateChoreEventParams{
+		ID:         NewId(),
+		ChoreID:    id,
+		EventType:  eventType,
+		OccurredAt: int64(today),
+		Duration:   int64(snoozedFor - ex.SnoozedFor),
+		CreatedBy:  userID,
+	}); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("inserting new event: %w", err)
+	}
+	if eventType == EventTypeSnooze {
+		snoozed, err := get(ctx, txc, userID, id)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("getting snoozed chore: %w", err)
+		}
+		if err := enqueueWebhooks(ctx, txc, WebhookSnoozed, userID, *snoozed); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 	return nil
 }
@@ -11,23 +11,56 @@ import (
 )
 
 func SettingsPage(view *View, db *sql.DB) http.Handler {
-	q := cdb.New(db)
 	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		userId := auth.MustGetSession(ctx).UserID
-		usernames, err := q.GetPasswordAuthsByUser(ctx, userId)
-		if err != nil {
-			return srvu.Err(http.StatusInternalServerError, err)
-		}
-		choreLists, err := q.GetChoreListsByUser(ctx, userId)
-		if err != nil {
-			return srvu.Err(http.StatusInternalServerError, err)
-		}
-		invites, err := q.GetInvitationsByCreator(ctx, cdb.GetInvitationsByCreatorParams{CreatedBy: userId, ExpiresAt: time.Now().UnixMilli()})
-		return view.SettingsPage(w, r, SettingsView{
-			UserID:         userId,
-			Usernames:      usernames,
-			ChoreLists:     choreLists,
-			CreatedInvites: invites,
-		})
+		return renderSettingsPage(ctx, w, r, view, db, auth.MustGetSession(ctx).UserID, "")
+	})
+}
+
+// renderSettingsPage renders the settings, createdToken is shown once right after a token has been created.
+func renderSettingsPage(ctx context.Context, w http.ResponseWriter, r *http.Request, view *View, db *sql.DB, userId, createdToken string) error {
+	q := cdb.New(db)
+	usernames, err := q.GetPasswordAuthsByUser(ctx, userId)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	choreLists, err := q.GetChoreListsByUser(ctx, userId)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	invites, err := q.GetInvitationsByCreator(ctx, cdb.GetInvitationsByCreatorParams{CreatedBy: userId, ExpiresAt: time.Now().UnixMilli()})
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	tokens, err := GetAPITokens(ctx, db, userId)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	feed, err := GetUserFeed(ctx, db, userId)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	digest, err := GetUserDigest(ctx, db, userId)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	pushKey, err := GetVAPIDKey(ctx, db)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	devices, err := GetPushSubscriptions(ctx, db, userId)
+	if err != nil {
+		return srvu.Err(http.StatusInternalServerError, err)
+	}
+	return view.SettingsPage(w, r, SettingsView{
+		UserID:         userId,
+		Usernames:      usernames,
+		ChoreLists:     choreLists,
+		CreatedInvites: invites,
+		APITokens:      tokens,
+		CreatedToken:   createdToken,
+		Feed:           feed,
+		Digest:         digest,
+		PushKey:        pushKey.PublicKey(),
+		Devices:        devices,
 	})
 }
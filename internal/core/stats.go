@@ -0,0 +1,232 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+// Completion is a completion of a chore together with the day it was due at the time.
+type Completion struct {
+	ChoreID       string
+	OccurredAt    date.Date
+	DueAt         date.Date
+	CreatedBy     string
+	CreatedByName string
+}
+
+// Lateness is how many days after being due the chore was completed, negative if it was completed early.
+func (c Completion) Lateness() date.Duration {
+	return c.OccurredAt.Sub(c.DueAt)
+}
+
+func (c Completion) OnTime() bool {
+	return c.Lateness() <= 0
+}
+
+// Completions replays the events of the chore, oldest first, to find when each completion was due. Every completion
+// is due at the chore's next completion after the previous one, moved by the snoozes and expedites in between. Date
+// chores don't keep their date once completed so they have no completions.
+func Completions(chore Chore, events []ChoreEvent) []Completion {
+	if chore.IsDate() {
+		return nil
+	}
+	replay := chore
+	replay.LastCompletion = 0
+	replay.SnoozedFor = 0
+	var completions []Completion
+	for _, e := range events {
+		if e.ChoreID != chore.ID {
+			continue
+		}
+		switch e.EventType {
+		case EventTypeSnooze, EventTypeExpedite:
+			replay.SnoozedFor += e.Duration
+		case EventTypeComplete:
+			completions = append(completions, Completion{
+				ChoreID:       chore.ID,
+				OccurredAt:    e.OccurredAt,
+				DueAt:         replay.NextCompletion(),
+				CreatedBy:     e.CreatedBy,
+				CreatedByName: e.CreatedByName,
+			})
+			replay.LastCompletion = replay.completion(e.OccurredAt)
+			replay.SnoozedFor = 0
+		}
+	}
+	return completions
+}
+
+// getListHistory is the list's chores and all their events, oldest first.
+func getListHistory(ctx context.Context, db cdb.DBTX, userID, choreListID string) ([]Chore, []ChoreEvent, error) {
+	q := cdb.New(db)
+	rows, err := q.GetChoreListEvents(ctx, cdb.GetChoreListEventsParams{UserID: userID, ChoreListID: choreListID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying events: %w", err)
+	}
+	events := make([]cdb.GetChoreEventsRow, len(rows))
+	for i, row := range rows {
+		events[i] = cdb.GetChoreEventsRow(row)
+	}
+	chores, err := q.GetChoresByList(ctx, choreListID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying chores: %w", err)
+	}
+	return ChoresFromDb(chores), ChoreEventsFromDb(events), nil
+}
+
+// GetListCompletions is every completion of the list's chores, with when they were due.
+func GetListCompletions(ctx context.Context, db cdb.DBTX, userID, choreListID string) ([]Completion, error) {
+	chores, events, err := getListHistory(ctx, db, userID, choreListID)
+	if err != nil {
+		return nil, err
+	}
+	var completions []Completion
+	for _, chore := range chores {
+		completions = append(completions, Completions(chore, events)...)
+	}
+	return completions, nil
+}
+
+// Adherence summarises how well the completions of a chore kept to its schedule.
+type Adherence struct {
+	Completions     []Completion
+	AverageLateness float64 // days, negative when the chore is done early on average
+	OnTimeStreak    int     // the latest completions in a row that were on time
+	LongestStreak   int
+	AverageInterval float64 // realised days between completions, zero until completed twice
+}
+
+func NewAdherence(completions []Completion) Adherence {
+	a := Adherence{Completions: completions}
+	if len(completions) == 0 {
+		return a
+	}
+	var lateness date.Duration
+	for _, c := range completions {
+		lateness += c.Lateness()
+		if c.OnTime() {
+			a.OnTimeStreak++
+			a.LongestStreak = max(a.LongestStreak, a.OnTimeStreak)
+		} else {
+			a.OnTimeStreak = 0
+		}
+	}
+	a.AverageLateness = float64(lateness) / float64(len(completions))
+	if intervals := RealisedIntervals(completions); len(intervals) > 0 {
+		var sum date.Duration
+		for _, i := range intervals {
+			sum += i
+		}
+		a.AverageInterval = float64(sum) / float64(len(intervals))
+	}
+	return a
+}
+
+// RealisedIntervals are the days between consecutive completions.
+func RealisedIntervals(completions []Completion) []date.Duration {
+	if len(completions) < 2 {
+		return nil
+	}
+	intervals := make([]date.Duration, 0, len(completions)-1)
+	for i := 1; i < len(completions); i++ {
+		intervals = append(intervals, completions[i].OccurredAt.Sub(completions[i-1].OccurredAt))
+	}
+	return intervals
+}
+
+// GetAdherence is the adherence of the chore to its schedule.
+func GetAdherence(ctx context.Context, db cdb.DBTX, userID string, chore Chore) (Adherence, error) {
+	_, events, err := getListHistory(ctx, db, userID, chore.ChoreListID)
+	if err != nil {
+		return Adherence{}, err
+	}
+	return NewAdherence(Completions(chore, events)), nil
+}
+
+// LatenessByChore is the average lateness of the list's completed chores, the most overdue first.
+func LatenessByChore(chores []Chore, events []ChoreEvent) []ChoreListDataViewCategory {
+	lateness := make([]ChoreListDataViewCategory, 0, len(chores))
+	for _, chore := range chores {
+		if completions := Completions(chore, events); len(completions) > 0 {
+			lateness = append(lateness, ChoreListDataViewCategory{
+				Name:  chore.Name,
+				Value: NewAdherence(completions).AverageLateness,
+			})
+		}
+	}
+	sort.SliceStable(lateness, func(i, j int) bool {
+		return lateness[i].Value > lateness[j].Value
+	})
+	return lateness
+}
+
+// OnTimeRatios is the share of each member's completions that were done on time, in the order the members first
+// appear in completions.
+func OnTimeRatios(completions []Completion) []ChoreListDataViewCategory {
+	type member struct{ id, name string }
+	var ratios []ChoreListDataViewCategory
+	index := make(map[member]int)
+	counts := make(map[member]int)
+	for _, c := range completions {
+		// imported history of former members is kept by the importing user under their names
+		m := member{c.CreatedBy, c.CreatedByName}
+		i, ok := index[m]
+		if !ok {
+			i = len(ratios)
+			index[m] = i
+			ratios = append(ratios, ChoreListDataViewCategory{Name: Coalesce(c.CreatedByName, c.CreatedBy)})
+		}
+		counts[m]++
+		if c.OnTime() {
+			ratios[i].Value++
+		}
+	}
+	for m, i := range index {
+		ratios[i].Value /= float64(counts[m])
+	}
+	return ratios
+}
+
+// CompletionsByWeekday sums the completions per day of the week, starting on monday.
+func CompletionsByWeekday(data []cdb.GetChoreListCalendarEventDataRow) []ChoreListDataViewCategory {
+	weekdays := make([]ChoreListDataViewCategory, 7)
+	for i := range weekdays {
+		weekdays[i].Name = time.Weekday((i + 1) % 7).String()
+	}
+	for _, d := range data {
+		i := (int(date.Date(d.OccurredAt).ToStdTime().UTC().Weekday()) + 6) % 7
+		weekdays[i].Value += float64(d.Count)
+	}
+	return weekdays
+}
+
+func ChoreInsightsPage(db *sql.DB, view *View) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := r.PathValue("id")
+		userID := auth.MustGetSession(ctx).UserID
+		if id == "" {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing id"))
+		}
+		chore, err := Get(ctx, db, userID, id)
+		if err != nil {
+			return srvu.Err(http.StatusNotFound, fmt.Errorf("getting chore from request: %w", err))
+		}
+		adherence, err := GetAdherence(ctx, db, userID, *chore)
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, fmt.Errorf("getting adherence: %w", err))
+		}
+		return view.ChoreInsightsPage(w, r, ChoreInsightsView{
+			Chore:     *chore,
+			Adherence: adherence,
+		})
+	})
+}
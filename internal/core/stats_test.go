@@ -0,0 +1,174 @@
+package core_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func TestCompletionsByWeekday(t *testing.T) {
+	local := time.Local
+	defer func() { time.Local = local }()
+	time.Local = time.FixedZone("west", -8*60*60)
+	monday := date.FromTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	weekdays := core.CompletionsByWeekday([]cdb.GetChoreListCalendarEventDataRow{
+		{OccurredAt: int64(monday), Count: 2},
+		{OccurredAt: int64(monday.Add(6 * date.Day)), Count: 1},
+	})
+	if weekdays[0].Name != "Monday" || weekdays[0].Value != 2 || weekdays[6].Name != "Sunday" || weekdays[6].Value != 1 {
+		t.Fatalf("expected the completions on their weekday regardless of the server's time zone, got %+v", weekdays)
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	created := date.Today()
+	day := func(n int) date.Date { return created.Add(date.Duration(n) * date.Day) }
+	chore := core.Chore{ID: "c", ChoreType: core.ChoreTypeInterval, Interval: 3 * date.Day, CreatedAt: created}
+	events := []core.ChoreEvent{
+		{ChoreID: "c", EventType: core.EventTypeComplete, OccurredAt: day(0)},
+		{ChoreID: "c", EventType: core.EventTypeSnooze, OccurredAt: day(1), Duration: 2 * date.Day},
+		{ChoreID: "other", EventType: core.EventTypeComplete, OccurredAt: day(2)},
+		{ChoreID: "c", EventType: core.EventTypeComplete, OccurredAt: day(5)},
+		{ChoreID: "c", EventType: core.EventTypeComplete, OccurredAt: day(10)},
+		{ChoreID: "c", EventType: core.EventTypeExpedite, OccurredAt: day(11), Duration: -date.Day},
+		{ChoreID: "c", EventType: core.EventTypeComplete, OccurredAt: day(12)},
+	}
+	completions := core.Completions(chore, events)
+	exp := []struct {
+		due      date.Date
+		lateness date.Duration
+	}{{day(0), 0}, {day(5), 0}, {day(8), 2 * date.Day}, {day(12), 0}}
+	if len(completions) != len(exp) {
+		t.Fatalf("expected %d completions, got %+v", len(exp), completions)
+	}
+	for i, c := range completions {
+		if c.DueAt != exp[i].due || c.Lateness() != exp[i].lateness {
+			t.Fatalf("expected completion %d to be due %s and %s late, got %+v", i, exp[i].due, exp[i].lateness, c)
+		}
+	}
+	chore.ChoreType = core.ChoreTypeDate
+	if completions := core.Completions(chore, events); completions != nil {
+		t.Fatalf("expected date chores to have no completions, got %+v", completions)
+	}
+}
+
+func TestAdherence(t *testing.T) {
+	created := date.Today()
+	day := func(n int) date.Date { return created.Add(date.Duration(n) * date.Day) }
+	completions := []core.Completion{
+		{OccurredAt: day(0), DueAt: day(0)},
+		{OccurredAt: day(4), DueAt: day(3)},
+		{OccurredAt: day(6), DueAt: day(7)},
+		{OccurredAt: day(9), DueAt: day(9)},
+	}
+	a := core.NewAdherence(completions)
+	if a.AverageLateness != 0 || a.OnTimeStreak != 2 || a.LongestStreak != 2 || a.AverageInterval != 3 {
+		t.Fatalf("unexpected adherence %+v", a)
+	}
+	if a := core.NewAdherence(completions[:2]); a.AverageLateness != 0.5 || a.OnTimeStreak != 0 || a.LongestStreak != 1 {
+		t.Fatalf("unexpected adherence of a late chore %+v", a)
+	}
+	if a := core.NewAdherence(nil); a.AverageInterval != 0 || a.OnTimeStreak != 0 {
+		t.Fatalf("expected no adherence without completions, got %+v", a)
+	}
+}
+
+func TestChoreInsights(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	newChore := func(name string) *core.Chore {
+		return Must(NewChore(ctx, client, tok, map[string]string{
+			"name":        name,
+			"choreType":   core.ChoreTypeInterval,
+			"choreListID": cl.List.ID,
+			"interval":    "2d",
+		}))
+	}
+	plants, dishes := newChore("plants"), newChore("dishes")
+	today := date.Today()
+	for i, e := range []struct {
+		chore string
+		at    date.Duration
+	}{{plants.ID, 0}, {plants.ID, 5}, {plants.ID, 10}, {dishes.ID, 0}, {dishes.ID, 2}} {
+		Panic(client.DBQuery().CreateChoreEvent(ctx, cdb.CreateChoreEventParams{
+			ID:         fmt.Sprintf("event-%d", i),
+			ChoreID:    e.chore,
+			EventType:  core.EventTypeComplete,
+			CreatedBy:  "test",
+			OccurredAt: int64(today.Add(e.at * date.Day)),
+		}))
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chores/%s/insights", plants.ID)).DoAndExp(http.StatusOK))
+	insights := GetTpl[core.ChoreInsightsView](client.tmpl, "chore_insights.page.gohtml")
+	if a := insights.Adherence; len(a.Completions) != 3 || a.AverageLateness != 2 || a.AverageInterval != 5 || a.OnTimeStreak != 0 {
+		t.Fatalf("unexpected adherence of plants %+v", a)
+	}
+	if insights.Lateness() != "2.0d late" {
+		t.Fatalf("expected plants to be 2 days late, got %s", insights.Lateness())
+	}
+	res := Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/charts/lateness_by_chore", cl.List.ID)).DoAndExp(http.StatusOK))
+	var chart core.ChoreListDataView
+	if err := json.NewDecoder(res.Body).Decode(&chart); err != nil {
+		t.Fatalf("decoding chart: %s", err)
+	}
+	if len(chart.Categories) != 2 || chart.Categories[0].Name != "plants" || chart.Categories[1].Value != 0 {
+		t.Fatalf("expected plants to be the most overdue chore, got %+v", chart.Categories)
+	}
+}
+
+func TestMemberChartData(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	Panic(client.AddMember(ctx, cl.List.ID, "other"))
+	chore := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "weekly",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "1w",
+	}))
+	today := date.Today()
+	for i, e := range []struct {
+		by string
+		at date.Date
+	}{{"test", today}, {"other", today.Add(10 * date.Day)}} {
+		Panic(client.DBQuery().CreateChoreEvent(ctx, cdb.CreateChoreEventParams{
+			ID:         fmt.Sprintf("event-%d", i),
+			ChoreID:    chore.ID,
+			EventType:  core.EventTypeComplete,
+			CreatedBy:  e.by,
+			OccurredAt: int64(e.at),
+		}))
+	}
+	categories := func(chartType string) map[string]float64 {
+		res := Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/charts/%s", cl.List.ID, chartType)).DoAndExp(http.StatusOK))
+		var chart core.ChoreListDataView
+		if err := json.NewDecoder(res.Body).Decode(&chart); err != nil {
+			t.Fatalf("decoding %s: %s", chartType, err)
+		}
+		values := make(map[string]float64)
+		for _, c := range chart.Categories {
+			values[c.Name] = c.Value
+		}
+		return values
+	}
+	if byMember := categories("completions_by_member"); len(byMember) != 2 || byMember["test"] != 1 || byMember["other"] != 1 {
+		t.Fatalf("expected a completion each, got %+v", byMember)
+	}
+	byWeekday := categories("completions_by_weekday")
+	if len(byWeekday) != 7 || byWeekday[today.ToStdTime().UTC().Weekday().String()] == 0 || byWeekday[today.Add(10*date.Day).ToStdTime().UTC().Weekday().String()] == 0 {
+		t.Fatalf("expected completions on the weekdays of the completions, got %+v", byWeekday)
+	}
+	if onTime := categories("on_time_ratio"); onTime["test"] != 1 || onTime["other"] != 0 {
+		t.Fatalf("expected test to be on time and other late, got %+v", onTime)
+	}
+}
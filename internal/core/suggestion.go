@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/chore-tracker/pkg/httpu"
+	"github.com/SimonSchneider/goslu/date"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+const (
+	// suggestionSamples is how many of the latest realised intervals a suggestion is based on.
+	suggestionSamples = 6
+	// minSuggestionSamples is how many realised intervals are needed before suggesting anything.
+	minSuggestionSamples = 3
+)
+
+// IntervalSuggestion proposes an interval matching how often the chore actually gets done.
+type IntervalSuggestion struct {
+	Current   date.Duration
+	Suggested date.Duration
+	Samples   int
+}
+
+// SuggestInterval compares the interval of the chore with the median of its latest realised intervals and suggests the
+// median if they differ by more than a quarter. Suggestions of two weeks or more are rounded to whole weeks. Only
+// interval chores that are still repeating get suggestions.
+func SuggestInterval(chore Chore, completions []Completion) *IntervalSuggestion {
+	if !chore.IsInterval() || chore.IsFinished() || chore.Interval <= 0 {
+		return nil
+	}
+	intervals := RealisedIntervals(completions)
+	if len(intervals) < minSuggestionSamples {
+		return nil
+	}
+	intervals = slices.Clone(intervals[max(0, len(intervals)-suggestionSamples):])
+	slices.Sort(intervals)
+	suggested := intervals[len(intervals)/2]
+	if len(intervals)%2 == 0 {
+		suggested = (intervals[len(intervals)/2-1] + suggested + 1) / 2
+	}
+	if suggested >= 2*date.Week {
+		suggested = (suggested + date.Week/2) / date.Week * date.Week
+	}
+	diff := suggested - chore.Interval
+	if suggested <= 0 || diff == 0 || 4*max(diff, -diff) <= chore.Interval {
+		return nil
+	}
+	return &IntervalSuggestion{Current: chore.Interval, Suggested: suggested, Samples: len(intervals)}
+}
+
+// GetIntervalSuggestion suggests a new interval for the chore from its completions.
+func GetIntervalSuggestion(ctx context.Context, db cdb.DBTX, userID string, chore Chore) (*IntervalSuggestion, error) {
+	adherence, err := GetAdherence(ctx, db, userID, chore)
+	if err != nil {
+		return nil, err
+	}
+	return SuggestInterval(chore, adherence.Completions), nil
+}
+
+// AcceptInterval updates the chore to the interval, keeping everything else as it is.
+func AcceptInterval(ctx context.Context, db *sql.DB, userID, id string, interval date.Duration, version int64) (*Chore, error) {
+	q := cdb.New(db)
+	chore, err := get(ctx, q, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting chore: %w", err)
+	}
+	checklist, err := q.GetChoreChecklist(ctx, chore.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting checklist: %w", err)
+	}
+	chore.Checklist = ChecklistFromDb(checklist)
+	chores := []Chore{*chore}
+	if err := withPrerequisites(ctx, q, chore.ChoreListID, chores); err != nil {
+		return nil, err
+	}
+	chore.Prerequisites = chores[0].Prerequisites
+	input := InputFrom(*chore)
+	input.Interval = interval
+	input.Version = version
+	return Update(ctx, db, chore, input)
+}
+
+func ChoreIntervalAcceptHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := r.PathValue("id")
+		userID := auth.MustGetSession(ctx).UserID
+		if id == "" {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("missing id"))
+		}
+		interval, err := date.ParseDuration(r.FormValue("interval"))
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, fmt.Errorf("invalid interval: %w", err))
+		}
+		version, err := RequestVersion(r)
+		if err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		chore, err := AcceptInterval(ctx, db, userID, id, interval, version)
+		if err != nil {
+			return writeErr(err, "accepting the interval")
+		}
+		httpu.RedirectToNext(w, r, fmt.Sprintf("/chores/%s/edit", chore.ID))
+		return nil
+	})
+}
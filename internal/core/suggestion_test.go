@@ -0,0 +1,91 @@
+package core_test
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+func TestSuggestInterval(t *testing.T) {
+	completionsEvery := func(days ...int) []core.Completion {
+		completions := []core.Completion{{}}
+		for _, d := range days {
+			prev := completions[len(completions)-1].OccurredAt
+			completions = append(completions, core.Completion{OccurredAt: prev.Add(date.Duration(d) * date.Day)})
+		}
+		return completions
+	}
+	interval := core.Chore{ChoreType: core.ChoreTypeInterval, Interval: 3 * date.Day, RepeatsLeft: -1}
+	tests := []struct {
+		name        string
+		chore       core.Chore
+		completions []core.Completion
+		exp         date.Duration
+	}{
+		{name: "slower", chore: interval, completions: completionsEvery(5, 4, 6, 5), exp: 5 * date.Day},
+		{name: "faster", chore: interval, completions: completionsEvery(2, 2, 1), exp: 2 * date.Day},
+		{name: "on schedule", chore: interval, completions: completionsEvery(3, 4, 3)},
+		{name: "too few", chore: interval, completions: completionsEvery(5, 5)},
+		{name: "outlier", chore: interval, completions: completionsEvery(3, 3, 30, 3)},
+		{name: "only latest", chore: interval, completions: completionsEvery(9, 9, 9, 5, 5, 5, 5, 5, 5), exp: 5 * date.Day},
+		{name: "weeks", chore: interval, completions: completionsEvery(20, 22, 21), exp: 3 * date.Week},
+		{name: "date repeating", chore: core.Chore{ChoreType: core.ChoreTypeDateRepeating}, completions: completionsEvery(5, 5, 5)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			suggestion := core.SuggestInterval(test.chore, test.completions)
+			if test.exp == 0 && suggestion != nil {
+				t.Fatalf("expected no suggestion, got %+v", suggestion)
+			} else if test.exp != 0 && (suggestion == nil || suggestion.Suggested != test.exp) {
+				t.Fatalf("expected a suggestion of %s, got %+v", test.exp, suggestion)
+			}
+		})
+	}
+}
+
+func TestAcceptIntervalSuggestion(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "test"}))
+	plants := Must(NewChore(ctx, client, tok, map[string]string{
+		"name":        "water plants",
+		"choreType":   core.ChoreTypeInterval,
+		"choreListID": cl.List.ID,
+		"interval":    "3d",
+		"checklist":   "kitchen\nbalcony",
+	}))
+	today := date.Today()
+	for i := range 4 {
+		Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/complete", plants.ID), map[string]string{
+			"completed_at": today.Add(date.Duration(5*(i-3)) * date.Day).String(),
+		}).DoAndFollow(http.StatusSeeOther))
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chores/%s/edit", plants.ID)).DoAndExp(http.StatusOK))
+	edit := GetTpl[core.ChoreEditView](client.tmpl, "chore_edit.page.gohtml")
+	if edit.Suggestion == nil || edit.Suggestion.Suggested != 5*date.Day || edit.Suggestion.Current != 3*date.Day {
+		t.Fatalf("expected a suggestion of 5d, got %+v", edit.Suggestion)
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/interval", plants.ID), map[string]string{
+		"interval": edit.Suggestion.Suggested.String(),
+		"version":  strconv.FormatInt(edit.Chore.Version-1, 10),
+	}).DoAndExp(http.StatusConflict); err != nil {
+		t.Fatalf("expected a stale version to be rejected: %s", err)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chores/%s/interval", plants.ID), map[string]string{
+		"interval": edit.Suggestion.Suggested.String(),
+		"version":  strconv.FormatInt(edit.Chore.Version, 10),
+	}).DoAndExp(http.StatusSeeOther))
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chores/%s/edit", plants.ID)).DoAndExp(http.StatusOK))
+	accepted := GetTpl[core.ChoreEditView](client.tmpl, "chore_edit.page.gohtml")
+	if accepted.Chore.Interval != 5*date.Day || accepted.Suggestion != nil {
+		t.Fatalf("expected the interval to be accepted, got %s and %+v", accepted.Chore.Interval, accepted.Suggestion)
+	}
+	if accepted.Chore.LastCompletion != edit.Chore.LastCompletion || accepted.Chore.Name != "water plants" || len(accepted.Chore.Checklist) != 2 {
+		t.Fatalf("expected the rest of the chore to be kept, got %+v", accepted.Chore)
+	}
+}
@@ -15,3 +15,10 @@ func Coalesce[T comparable](a, b T) T {
 	}
 	return a
 }
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
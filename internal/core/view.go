@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/SimonSchneider/chore-tracker/internal/cdb"
@@ -13,7 +15,8 @@ import (
 )
 
 type RequestDetails struct {
-	req *http.Request
+	req     *http.Request
+	baseURL string
 }
 
 func (r *RequestDetails) CurrPath() string {
@@ -24,6 +27,21 @@ func (r *RequestDetails) PrevPath() string {
 	return r.req.URL.Query().Get("prev")
 }
 
+// AbsURL is the path on this server as an absolute URL, for links that are used outside the app. It is relative to
+// the configured base URL of the app, or to the host of the request if none is configured.
+func (r *RequestDetails) AbsURL(path string) string {
+	if r.baseURL != "" {
+		return strings.TrimSuffix(r.baseURL, "/") + path
+	}
+	scheme := r.req.Header.Get("X-Forwarded-Proto")
+	if scheme == "" && r.req.TLS != nil {
+		scheme = "https"
+	} else if scheme == "" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.req.Host, path)
+}
+
 type HtmlTemplateProvider struct {
 	templ.TemplateProvider
 }
@@ -41,11 +59,16 @@ func (p *HtmlTemplateProvider) ExecuteTemplate(w io.Writer, name string, data in
 }
 
 type View struct {
-	p *HtmlTemplateProvider
+	p       *HtmlTemplateProvider
+	baseURL string
+}
+
+func NewView(p templ.TemplateProvider, baseURL string) *View {
+	return &View{p: &HtmlTemplateProvider{TemplateProvider: p}, baseURL: baseURL}
 }
 
-func NewView(p templ.TemplateProvider) *View {
-	return &View{p: &HtmlTemplateProvider{TemplateProvider: p}}
+func (v *View) requestDetails(r *http.Request) *RequestDetails {
+	return &RequestDetails{req: r, baseURL: v.baseURL}
 }
 
 type ChoreListsView struct {
@@ -54,42 +77,104 @@ type ChoreListsView struct {
 }
 
 func (v *View) ChoreListsPage(w http.ResponseWriter, r *http.Request, d ChoreListsView) error {
-	d.RequestDetails = &RequestDetails{req: r}
+	d.RequestDetails = v.requestDetails(r)
 	return v.p.ExecuteTemplate(w, "chore_lists.page.gohtml", d)
 }
 
 func (v *View) ChoreListNewPage(w http.ResponseWriter, r *http.Request) error {
 	return v.p.ExecuteTemplate(w, "chore_list_edit.page.gohtml", ChoreListEditView{
-		RequestDetails: &RequestDetails{req: r},
+		RequestDetails: v.requestDetails(r),
 		List:           cdb.ChoreList{},
 	})
 }
 
 type ChoreListEditView struct {
 	*RequestDetails
-	List    cdb.ChoreList
-	Members []cdb.GetChoreListMembersRow
-	Invites []cdb.Invitation
+	List     cdb.ChoreList
+	Members  []cdb.GetChoreListMembersRow
+	Invites  []cdb.Invitation
+	Feed     *cdb.ChoreListFeed
+	Webhooks []Webhook
+}
+
+func (c ChoreListEditView) WebhookEvents() []string {
+	return WebhookEvents
 }
 
 func (c ChoreListEditView) IsEdit() bool {
 	return c.List.ID != ""
 }
 
+func (c ChoreListEditView) FeedURL() string {
+	if c.Feed == nil {
+		return ""
+	}
+	return c.AbsURL(FeedPath(c.Feed.Token))
+}
+
 func (v *View) ChoreListEditPage(w http.ResponseWriter, r *http.Request, d ChoreListEditView) error {
-	d.RequestDetails = &RequestDetails{req: r}
+	d.RequestDetails = v.requestDetails(r)
 	return v.p.ExecuteTemplate(w, "chore_list_edit.page.gohtml", d)
 }
 
+type ChoreListImportView struct {
+	*RequestDetails
+	List    cdb.ChoreList
+	Rows    []ImportRow
+	Content string
+}
+
+func (v ChoreListImportView) Errors() int {
+	n := 0
+	for _, row := range v.Rows {
+		if row.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (v *View) ChoreListImportPage(w http.ResponseWriter, r *http.Request, d ChoreListImportView) error {
+	d.RequestDetails = v.requestDetails(r)
+	return v.p.ExecuteTemplate(w, "chore_list_import.page.gohtml", d)
+}
+
+type WebhookView struct {
+	*RequestDetails
+	List       cdb.ChoreList
+	Webhook    Webhook
+	Deliveries []WebhookDelivery
+}
+
+func (v *View) WebhookPage(w http.ResponseWriter, r *http.Request, d WebhookView) error {
+	d.RequestDetails = v.requestDetails(r)
+	return v.p.ExecuteTemplate(w, "chore_list_webhook.page.gohtml", d)
+}
+
 type ChoreListView struct {
 	*RequestDetails
 	List    cdb.ChoreList
 	Weekday time.Weekday
 	Chores  *ListView
+	Members []cdb.GetChoreListMembersRow
+	Mine    bool
+}
+
+func (v ChoreListView) MemberName(userID string) string {
+	return memberName(v.Members, userID)
+}
+
+func memberName(members []cdb.GetChoreListMembersRow, userID string) string {
+	for _, m := range members {
+		if m.ID == userID {
+			return m.DisplayName
+		}
+	}
+	return ""
 }
 
 func (v *View) ChoreListPage(w http.ResponseWriter, r *http.Request, d ChoreListView) error {
-	d.RequestDetails = &RequestDetails{req: r}
+	d.RequestDetails = v.requestDetails(r)
 	return v.p.ExecuteTemplate(w, "chore_list.page.gohtml", d)
 }
 
@@ -99,10 +184,36 @@ type ChoreListChartView struct {
 }
 
 func (v *View) ChoreListChartPage(w http.ResponseWriter, r *http.Request, d ChoreListChartView) error {
-	d.RequestDetails = &RequestDetails{req: r}
+	d.RequestDetails = v.requestDetails(r)
 	return v.p.ExecuteTemplate(w, "chore_list_chart.page.gohtml", d)
 }
 
+type ChoreListLeaderboardView struct {
+	*RequestDetails
+	List        cdb.ChoreList
+	Leaderboard Leaderboard
+}
+
+// Windows are the preset windows and the requested one if it isn't a preset.
+func (v ChoreListLeaderboardView) Windows() []LeaderboardWindow {
+	for _, w := range LeaderboardWindows {
+		if w == v.Leaderboard.Window {
+			return LeaderboardWindows
+		}
+	}
+	return append(LeaderboardWindows[:len(LeaderboardWindows):len(LeaderboardWindows)], v.Leaderboard.Window)
+}
+
+// Percent formats a share as a whole percentage.
+func (v ChoreListLeaderboardView) Percent(share float64) string {
+	return fmt.Sprintf("%.0f%%", share*100)
+}
+
+func (v *View) ChoreListLeaderboardPage(w http.ResponseWriter, r *http.Request, d ChoreListLeaderboardView) error {
+	d.RequestDetails = v.requestDetails(r)
+	return v.p.ExecuteTemplate(w, "chore_list_leaderboard.page.gohtml", d)
+}
+
 type ChoreListDataViewCalendar struct {
 	Range string `json:"range"`
 }
@@ -112,8 +223,15 @@ type ChoreListDataViewSeries struct {
 	Value int64     `json:"value"`
 }
 
+// ChoreListDataViewCategory is a bar of the chart types that aren't calendars.
+type ChoreListDataViewCategory struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
 type ChoreListDataView struct {
-	Data []ChoreListDataViewSeries `json:"data"`
+	Data       []ChoreListDataViewSeries   `json:"data"`
+	Categories []ChoreListDataViewCategory `json:"categories,omitempty"`
 }
 
 func (v *View) ChoreListChartData(w http.ResponseWriter, r *http.Request, d *ChoreListDataView) error {
@@ -130,8 +248,26 @@ func (v *View) ChoreModal(w http.ResponseWriter, r *http.Request, d *Chore) erro
 
 type ChoreEditView struct {
 	*RequestDetails
-	Chore     Chore
-	ChoreType string
+	Chore      Chore
+	ChoreType  string
+	Members    []cdb.GetChoreListMembersRow
+	ListChores []Chore
+	Suggestion *IntervalSuggestion
+}
+
+// PrerequisiteOptions are the chores of the list that the chore can depend on.
+func (c ChoreEditView) PrerequisiteOptions() []Chore {
+	options := make([]Chore, 0, len(c.ListChores))
+	for _, o := range c.ListChores {
+		if o.ID != c.Chore.ID {
+			options = append(options, o)
+		}
+	}
+	return options
+}
+
+func (c ChoreEditView) IsPrerequisite(id string) bool {
+	return contains(c.Chore.Prerequisites, id)
 }
 
 func (c ChoreEditView) IsEdit() bool {
@@ -154,33 +290,107 @@ func (c ChoreEditView) IsDateRepeating() bool {
 	return c.ChoreType == ChoreTypeDateRepeating
 }
 
+// EffortValue defaults new chores to a single point.
+func (c ChoreEditView) EffortValue() int64 {
+	if !c.IsEdit() {
+		return 1
+	}
+	return c.Chore.Effort
+}
+
 func (c ChoreEditView) RepeatsValue() string {
-	if c.Chore.RepeatsLeft < 1 {
+	if c.Chore.RepeatsLeft < 1 && !(c.IsEdit() && c.Chore.IsFinished()) {
 		return ""
 	}
 	return fmt.Sprintf("%d", c.Chore.RepeatsLeft)
 }
 
 func (v *View) ChoreEditPage(w http.ResponseWriter, r *http.Request, d ChoreEditView) error {
-	d.RequestDetails = &RequestDetails{req: r}
+	d.RequestDetails = v.requestDetails(r)
 	return v.p.ExecuteTemplate(w, "chore_edit.page.gohtml", d)
 }
 
 func (v *View) ChoreCreatePage(w http.ResponseWriter, r *http.Request, d ChoreEditView) error {
-	d.RequestDetails = &RequestDetails{req: r}
+	d.RequestDetails = v.requestDetails(r)
 	return v.p.ExecuteTemplate(w, "chore_edit.page.gohtml", d)
 }
 
+type ChoreHistoryView struct {
+	*RequestDetails
+	Chore  Chore
+	Events []ChoreEvent
+}
+
+func (v *View) ChoreHistoryPage(w http.ResponseWriter, r *http.Request, d ChoreHistoryView) error {
+	d.RequestDetails = v.requestDetails(r)
+	return v.p.ExecuteTemplate(w, "chore_history.page.gohtml", d)
+}
+
+type ChoreInsightsView struct {
+	*RequestDetails
+	Chore     Chore
+	Adherence Adherence
+}
+
+// Days formats a number of days with a single decimal.
+func (v ChoreInsightsView) Days(days float64) string {
+	return fmt.Sprintf("%.1fd", days)
+}
+
+// Lateness describes the average lateness of the chore.
+func (v ChoreInsightsView) Lateness() string {
+	switch l := v.Adherence.AverageLateness; {
+	case l > 0:
+		return v.Days(l) + " late"
+	case l < 0:
+		return v.Days(-l) + " early"
+	default:
+		return "on time"
+	}
+}
+
+// Recent are the completions, the latest first.
+func (v ChoreInsightsView) Recent() []Completion {
+	recent := slices.Clone(v.Adherence.Completions)
+	slices.Reverse(recent)
+	return recent
+}
+
+func (v *View) ChoreInsightsPage(w http.ResponseWriter, r *http.Request, d ChoreInsightsView) error {
+	d.RequestDetails = v.requestDetails(r)
+	return v.p.ExecuteTemplate(w, "chore_insights.page.gohtml", d)
+}
+
 type SettingsView struct {
 	*RequestDetails
 	UserID         string
 	Usernames      []string
 	ChoreLists     []cdb.GetChoreListsByUserRow
 	CreatedInvites []cdb.GetInvitationsByCreatorRow
+	APITokens      []APIToken
+	CreatedToken   string
+	Feed           *cdb.UserFeed
+	Digest         UserDigest
+	PushKey        string
+	Devices        []PushSubscription
+}
+
+func (v SettingsView) FeedURL() string {
+	if v.Feed == nil {
+		return ""
+	}
+	return v.AbsURL(FeedPath(v.Feed.Token))
+}
+
+func (v SettingsView) AssignedFeedURL() string {
+	if v.Feed == nil {
+		return ""
+	}
+	return v.AbsURL(FeedPath(v.Feed.Token) + "?assigned=me")
 }
 
 func (v *View) SettingsPage(w http.ResponseWriter, r *http.Request, d SettingsView) error {
-	d.RequestDetails = &RequestDetails{req: r}
+	d.RequestDetails = v.requestDetails(r)
 	return v.p.ExecuteTemplate(w, "settings.page.gohtml", d)
 }
 
@@ -210,7 +420,7 @@ type InviteAcceptView struct {
 }
 
 func (v *View) InviteAcceptPage(w http.ResponseWriter, r *http.Request, d InviteAcceptView) error {
-	d.RequestDetails = &RequestDetails{req: r}
+	d.RequestDetails = v.requestDetails(r)
 	return v.p.ExecuteTemplate(w, "invite_accept.page.gohtml", d)
 }
 
@@ -218,14 +428,34 @@ func (v *View) LoginPage(w http.ResponseWriter, r *http.Request) error {
 	return v.p.ExecuteTemplate(w, "login.page.gohtml", nil)
 }
 
+// DigestEmail renders the HTML of a digest email.
+func (v *View) DigestEmail(w io.Writer, d *DigestView) error {
+	return v.p.ExecuteTemplate(w, "digest.email.gohtml", d)
+}
+
 type ChoreListIcsView struct {
 	ID     string
 	Name   string
 	Today  date.Date
 	Chores []Chore
+	// ListNames are the names of the chores' lists when the calendar combines several lists.
+	ListNames map[string]string
+	Now       time.Time
+	Options   IcsOptions
+}
+
+// Category is the name of the chore's list.
+func (v *ChoreListIcsView) Category(c Chore) string {
+	if name, ok := v.ListNames[c.ChoreListID]; ok {
+		return name
+	}
+	return v.Name
 }
 
 func (v *ChoreListIcsView) NextCompletionOf(c Chore) date.Date {
+	if c.IsFinished() {
+		return c.LastCompletion
+	}
 	if c.NextCompletion().After(v.Today) {
 		return c.NextCompletion()
 	}
@@ -235,5 +465,5 @@ func (v *ChoreListIcsView) NextCompletionOf(c Chore) date.Date {
 func (v *View) ChoreListIcs(w http.ResponseWriter, r *http.Request, d *ChoreListIcsView) error {
 	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("filename=%s-%s.ics", d.Name, d.ID))
-	return v.p.ExecuteTemplate(w, "calendar.goics", d)
+	return d.Write(w)
 }
@@ -0,0 +1,450 @@
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/cdb"
+	"github.com/SimonSchneider/chore-tracker/pkg/auth"
+	"github.com/SimonSchneider/chore-tracker/pkg/httpu"
+	"github.com/SimonSchneider/goslu/sid"
+	"github.com/SimonSchneider/goslu/sqlu"
+	"github.com/SimonSchneider/goslu/srvu"
+)
+
+// Webhook events a webhook can subscribe to.
+const (
+	WebhookCompleted     = "completed"
+	WebhookSnoozed       = "snoozed"
+	WebhookCreated       = "created"
+	WebhookBecameDue     = "became-due"
+	WebhookBecameOverdue = "became-overdue"
+)
+
+var WebhookEvents = []string{WebhookCompleted, WebhookSnoozed, WebhookCreated, WebhookBecameDue, WebhookBecameOverdue}
+
+// Statuses of a webhook delivery, pending deliveries are retried until they are delivered or have failed too often.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+const (
+	webhookSecretLength = 32
+	webhookLogSize      = 50
+)
+
+type Webhook struct {
+	ID          string
+	ChoreListID string
+	URL         string
+	Secret      string
+	Events      []string
+	CreatedAt   time.Time
+}
+
+func WebhookFromDb(row cdb.Webhook) Webhook {
+	return Webhook{
+		ID:          row.ID,
+		ChoreListID: row.ChoreListID,
+		URL:         row.Url,
+		Secret:      row.Secret,
+		Events:      strings.Split(row.Events, ","),
+		CreatedAt:   time.UnixMilli(row.CreatedAt),
+	}
+}
+
+func (w Webhook) Subscribes(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+type WebhookInput struct {
+	URL    string
+	Events []string
+}
+
+func (i *WebhookInput) FromForm(r *http.Request) error {
+	i.URL = strings.TrimSpace(r.FormValue("url"))
+	i.Events = r.Form["events"]
+	return nil
+}
+
+func (i *WebhookInput) Validate() error {
+	u, err := url.Parse(i.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook URL must be an absolute http or https URL: '%s'", i.URL)
+	}
+	if len(i.Events) == 0 {
+		return fmt.Errorf("webhook must subscribe to at least one event")
+	}
+	for _, e := range i.Events {
+		if !slices.Contains(WebhookEvents, e) {
+			return fmt.Errorf("illegal webhook event: %s", e)
+		}
+	}
+	return nil
+}
+
+// CreateWebhook registers a webhook on the list, its secret signs the payloads so the receiver can verify them.
+func CreateWebhook(ctx context.Context, db cdb.DBTX, userID, choreListID string, inp WebhookInput) (Webhook, error) {
+	if err := inp.Validate(); err != nil {
+		return Webhook{}, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	q := cdb.New(db)
+	if _, err := q.GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: choreListID, UserID: userID}); err != nil {
+		return Webhook{}, fmt.Errorf("getting chore list: %w", err)
+	}
+	secret, err := sid.NewString(webhookSecretLength)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("generating secret: %w", err)
+	}
+	var events []string
+	for _, e := range WebhookEvents {
+		if slices.Contains(inp.Events, e) {
+			events = append(events, e)
+		}
+	}
+	row, err := q.CreateWebhook(ctx, cdb.CreateWebhookParams{
+		ID:          NewId(),
+		ChoreListID: choreListID,
+		Url:         inp.URL,
+		Secret:      secret,
+		Events:      strings.Join(events, ","),
+		CreatedBy:   userID,
+		CreatedAt:   time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return Webhook{}, fmt.Errorf("creating webhook: %w", err)
+	}
+	return WebhookFromDb(row), nil
+}
+
+func GetWebhooks(ctx context.Context, db cdb.DBTX, choreListID string) ([]Webhook, error) {
+	rows, err := cdb.New(db).GetWebhooksByChoreList(ctx, choreListID)
+	if err != nil {
+		return nil, fmt.Errorf("getting webhooks: %w", err)
+	}
+	webhooks := make([]Webhook, len(rows))
+	for i, row := range rows {
+		webhooks[i] = WebhookFromDb(row)
+	}
+	return webhooks, nil
+}
+
+// WebhookPayload is the JSON body of a delivery. The ID is the same for every attempt of a delivery so receivers can
+// ignore retries they have already handled.
+type WebhookPayload struct {
+	ID          string    `json:"id"`
+	Event       string    `json:"event"`
+	OccurredAt  time.Time `json:"occurredAt"`
+	ChoreListID string    `json:"choreListID"`
+	UserID      string    `json:"userID,omitempty"`
+	Chore       APIChore  `json:"chore"`
+}
+
+// enqueueWebhooks queues a delivery of the event to the webhooks of the chore's list that subscribe to it. It runs in
+// the transaction of the change so only committed changes are delivered, the WebhookSender sends them afterward.
+func enqueueWebhooks(ctx context.Context, q *cdb.Queries, event, userID string, chore Chore) error {
+	return enqueueWebhookEvent(ctx, q, "", event, userID, chore)
+}
+
+// enqueueWebhookEvent queues the event like enqueueWebhooks, an event with an ID is only queued once per webhook.
+func enqueueWebhookEvent(ctx context.Context, q *cdb.Queries, eventID, event, userID string, chore Chore) error {
+	rows, err := q.GetWebhooksByChoreList(ctx, chore.ChoreListID)
+	if err != nil {
+		return fmt.Errorf("getting webhooks: %w", err)
+	}
+	now := time.Now()
+	for _, row := range rows {
+		if !WebhookFromDb(row).Subscribes(event) {
+			continue
+		}
+		id := NewId()
+		if eventID != "" {
+			id = eventID + "-" + row.ID
+		}
+		payload, err := json.Marshal(WebhookPayload{
+			ID:          id,
+			Event:       event,
+			OccurredAt:  now.UTC(),
+			ChoreListID: chore.ChoreListID,
+			UserID:      userID,
+			Chore:       NewAPIChore(chore),
+		})
+		if err != nil {
+			return fmt.Errorf("encoding payload: %w", err)
+		}
+		if err := q.CreateWebhookDelivery(ctx, cdb.CreateWebhookDeliveryParams{
+			ID:            id,
+			WebhookID:     row.ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        DeliveryPending,
+			NextAttemptAt: now.UnixMilli(),
+			CreatedAt:     now.UnixMilli(),
+		}); err != nil {
+			return fmt.Errorf("queueing delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// WebhookNotifier queues the became-due and became-overdue events of the Scheduler's transitions, once per transition.
+type WebhookNotifier struct {
+	DB *sql.DB
+}
+
+func (n *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, transitions []Transition) error {
+	tx, err := n.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+	q := cdb.New(tx)
+	for _, t := range transitions {
+		var event string
+		switch t.To {
+		case ChoreStateDue:
+			event = WebhookBecameDue
+		case ChoreStateOverdue:
+			event = WebhookBecameOverdue
+		default:
+			continue
+		}
+		if err := enqueueWebhookEvent(ctx, q, t.ID, event, "", t.Chore); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SignWebhook is the signature of a delivery sent at timestamp (unix seconds), the HMAC-SHA256 of "timestamp.body"
+// keyed with the webhook's secret. Receivers should compare it in constant time and reject old timestamps.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookSender delivers the queued webhooks. Failed attempts are retried after Backoff, doubling for every attempt,
+// until the delivery has been attempted MaxAttempts times.
+type WebhookSender struct {
+	DB          *sql.DB
+	Client      *http.Client
+	Now         func() time.Time
+	Backoff     time.Duration
+	MaxAttempts int64
+}
+
+// NewWebhookSender creates a sender whose client doesn't follow redirects, a delivery is only sent to the URL of its
+// webhook.
+func NewWebhookSender(db *sql.DB) *WebhookSender {
+	return &WebhookSender{
+		DB: db,
+		Client: &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		Now:         time.Now,
+		Backoff:     30 * time.Second,
+		MaxAttempts: 8,
+	}
+}
+
+// Deliver attempts every delivery that is due, then prunes the finished deliveries that are no longer in the log of
+// their webhook.
+func (s *WebhookSender) Deliver(ctx context.Context) error {
+	q := cdb.New(s.DB)
+	for {
+		now := s.Now()
+		rows, err := q.GetDueWebhookDeliveries(ctx, cdb.GetDueWebhookDeliveriesParams{NextAttemptAt: now.UnixMilli(), Limit: 100})
+		if err != nil {
+			return fmt.Errorf("getting deliveries: %w", err)
+		}
+		for _, d := range rows {
+			status, err := s.send(ctx, d, now)
+			if err := q.UpdateWebhookDelivery(ctx, s.attempted(d, now, status, err)); err != nil {
+				return fmt.Errorf("updating delivery %s: %w", d.ID, err)
+			}
+		}
+		if len(rows) < 100 {
+			break
+		}
+	}
+	if err := q.PruneWebhookDeliveries(ctx, webhookLogSize); err != nil {
+		return fmt.Errorf("pruning deliveries: %w", err)
+	}
+	return nil
+}
+
+func (s *WebhookSender) send(ctx context.Context, d cdb.GetDueWebhookDeliveriesRow, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Url, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "chore-tracker-webhook")
+	req.Header.Set("X-Chore-Event", d.Event)
+	req.Header.Set("X-Chore-Delivery", d.ID)
+	req.Header.Set("X-Chore-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Chore-Signature", SignWebhook(d.Secret, now.Unix(), []byte(d.Payload)))
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("unexpected response %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+func (s *WebhookSender) attempted(d cdb.GetDueWebhookDeliveriesRow, now time.Time, status int, err error) cdb.UpdateWebhookDeliveryParams {
+	p := cdb.UpdateWebhookDeliveryParams{
+		ID:             d.ID,
+		Status:         DeliveryDelivered,
+		Attempts:       d.Attempts + 1,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastAttemptAt:  sql.NullInt64{Int64: now.UnixMilli(), Valid: true},
+		ResponseStatus: sql.NullInt64{Int64: int64(status), Valid: status != 0},
+	}
+	if err == nil {
+		return p
+	}
+	p.Error = sqlu.NullString(err.Error())
+	if p.Attempts >= s.MaxAttempts {
+		p.Status = DeliveryFailed
+	} else {
+		p.Status = DeliveryPending
+		p.NextAttemptAt = now.Add(s.Backoff << (p.Attempts - 1)).UnixMilli()
+	}
+	return p
+}
+
+// Run delivers the queued webhooks every interval until the context is done.
+func (s *WebhookSender) Run(ctx context.Context, interval time.Duration) {
+	logger := srvu.GetLogger(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Deliver(ctx); err != nil && ctx.Err() == nil {
+			logger.Printf("delivering webhooks: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type WebhookDelivery struct {
+	ID             string
+	Event          string
+	Status         string
+	Attempts       int64
+	CreatedAt      time.Time
+	NextAttemptAt  time.Time
+	ResponseStatus int64
+	Error          string
+}
+
+func WebhookDeliveriesFromDb(rows []cdb.WebhookDelivery) []WebhookDelivery {
+	deliveries := make([]WebhookDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = WebhookDelivery{
+			ID:             row.ID,
+			Event:          row.Event,
+			Status:         row.Status,
+			Attempts:       row.Attempts,
+			CreatedAt:      time.UnixMilli(row.CreatedAt),
+			NextAttemptAt:  time.UnixMilli(row.NextAttemptAt),
+			ResponseStatus: row.ResponseStatus.Int64,
+			Error:          row.Error.String,
+		}
+	}
+	return deliveries
+}
+
+func (d WebhookDelivery) IsPending() bool {
+	return d.Status == DeliveryPending
+}
+
+func ChoreListWebhookCreateHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		choreListID := r.PathValue("choreListID")
+		var inp WebhookInput
+		if err := srvu.Decode(r, &inp, false); err != nil {
+			return srvu.Err(http.StatusBadRequest, err)
+		}
+		if _, err := CreateWebhook(ctx, db, userID, choreListID, inp); errors.Is(err, sql.ErrNoRows) {
+			return srvu.Err(http.StatusForbidden, err)
+		} else if err != nil {
+			return writeErr(err, "creating webhook")
+		}
+		httpu.RedirectToReferer(w, r, fmt.Sprintf("/chore-lists/%s/edit", choreListID))
+		return nil
+	})
+}
+
+func ChoreListWebhookDeleteHandler(db *sql.DB) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		choreListID := r.PathValue("choreListID")
+		q := cdb.New(db)
+		if _, err := q.GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: choreListID, UserID: userID}); err != nil {
+			return srvu.Err(http.StatusForbidden, err)
+		}
+		if n, err := q.DeleteWebhook(ctx, cdb.DeleteWebhookParams{ID: r.PathValue("webhookID"), ChoreListID: choreListID}); err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		} else if n == 0 {
+			return srvu.Err(http.StatusNotFound, fmt.Errorf("unknown webhook"))
+		}
+		httpu.RedirectToNext(w, r, fmt.Sprintf("/chore-lists/%s/edit", choreListID))
+		return nil
+	})
+}
+
+// ChoreListWebhookPage shows the webhook with its latest deliveries.
+func ChoreListWebhookPage(db *sql.DB, view *View) http.Handler {
+	return srvu.ErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := auth.MustGetSession(ctx).UserID
+		q := cdb.New(db)
+		list, err := q.GetChoreListByUser(ctx, cdb.GetChoreListByUserParams{ID: r.PathValue("choreListID"), UserID: userID})
+		if err != nil {
+			return srvu.Err(http.StatusForbidden, err)
+		}
+		row, err := q.GetWebhook(ctx, cdb.GetWebhookParams{ID: r.PathValue("webhookID"), ChoreListID: list.ID})
+		if err != nil {
+			return srvu.Err(http.StatusNotFound, err)
+		}
+		deliveries, err := q.GetWebhookDeliveries(ctx, cdb.GetWebhookDeliveriesParams{WebhookID: row.ID, Limit: webhookLogSize})
+		if err != nil {
+			return srvu.Err(http.StatusInternalServerError, err)
+		}
+		return view.WebhookPage(w, r, WebhookView{List: list, Webhook: WebhookFromDb(row), Deliveries: WebhookDeliveriesFromDb(deliveries)})
+	})
+}
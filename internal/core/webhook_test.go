@@ -0,0 +1,159 @@
+package core_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SimonSchneider/chore-tracker/internal/core"
+	"github.com/SimonSchneider/goslu/date"
+)
+
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	payloads []core.WebhookPayload
+	headers  []http.Header
+	bodies   [][]byte
+	paths    []string
+}
+
+func (h *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	body := Must(io.ReadAll(r.Body))
+	var p core.WebhookPayload
+	Panic(json.Unmarshal(body, &p))
+	h.payloads = append(h.payloads, p)
+	h.headers = append(h.headers, r.Header.Clone())
+	h.bodies = append(h.bodies, body)
+	h.paths = append(h.paths, r.URL.Path)
+	w.WriteHeader(h.status)
+}
+
+func TestWebhooks(t *testing.T) {
+	ctx, client, cancel := Setup()
+	defer cancel()
+	receiver := &webhookReceiver{status: http.StatusOK}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+	tok := Must(client.NewToken(ctx))
+	cl := Must(NewChoreList(ctx, client, tok, map[string]string{"name": "home"}))
+	webhooksPath := fmt.Sprintf("/chore-lists/%s/webhooks/", cl.List.ID)
+
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", webhooksPath, map[string]string{"url": srv.URL, "events": core.WebhookCompleted}).DoAndExp(http.StatusSeeOther))
+	all := Must(core.CreateWebhook(ctx, client.db, "test", cl.List.ID, core.WebhookInput{URL: srv.URL + "/all", Events: core.WebhookEvents}))
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", webhooksPath, map[string]string{"url": "ftp://example.com", "events": core.WebhookCompleted}).DoAndExp(http.StatusBadRequest); err != nil {
+		t.Fatalf("expected non http URLs to be rejected: %s", err)
+	}
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Form("POST", "/chore-lists/unknown/webhooks/", map[string]string{"url": srv.URL, "events": core.WebhookCompleted}).DoAndExp(http.StatusForbidden); err != nil {
+		t.Fatalf("expected webhooks on lists of others to be forbidden: %s", err)
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/edit", cl.List.ID)).DoAndExp(http.StatusOK))
+	if edit := GetTpl[core.ChoreListEditView](client.tmpl, "chore_list_edit.page.gohtml"); len(edit.Webhooks) != 2 {
+		t.Fatalf("expected both webhooks on the edit page, got %+v", edit.Webhooks)
+	}
+
+	today := date.Today()
+	chore := Must(NewChore(ctx, client, tok, map[string]string{
+		"name": "dishes", "choreType": core.ChoreTypeInterval, "choreListID": cl.List.ID, "interval": "1d",
+	}))
+	Panic(core.Complete(ctx, client.db, "test", chore.ID, today, 0))
+	Panic(core.Snooze(ctx, client.db, today.Add(date.Day), "test", chore.ID, date.Day, 0))
+
+	now := time.Now()
+	sender := core.NewWebhookSender(client.db)
+	sender.Now = func() time.Time { return now }
+	sender.Backoff = time.Minute
+	sender.MaxAttempts = 2
+	Panic(sender.Deliver(ctx))
+	if len(receiver.payloads) != 4 {
+		t.Fatalf("expected the subscribed events to be delivered, got %+v", receiver.payloads)
+	}
+	completed := *findInSlice(receiver.payloads, func(p core.WebhookPayload) bool { return p.Event == core.WebhookCompleted })
+	if completed.Chore.ID != chore.ID || completed.UserID != "test" || completed.ChoreListID != cl.List.ID || completed.Chore.LastCompletion == nil || *completed.Chore.LastCompletion != today {
+		t.Fatalf("unexpected completed payload %+v", completed)
+	}
+	for i, h := range receiver.headers {
+		ts := Must(strconv.ParseInt(h.Get("X-Chore-Timestamp"), 10, 64))
+		if sig := core.SignWebhook(all.Secret, ts, receiver.bodies[i]); h.Get("X-Chore-Event") != receiver.payloads[i].Event || h.Get("X-Chore-Signature") == "" {
+			t.Fatalf("unexpected headers %+v", h)
+		} else if receiver.payloads[i].Event != core.WebhookCompleted && h.Get("X-Chore-Signature") != sig {
+			t.Fatalf("expected the payload to be signed with the secret, got %s expected %s", h.Get("X-Chore-Signature"), sig)
+		}
+	}
+
+	receiver.status = http.StatusInternalServerError
+	scheduler := core.NewScheduler(client.db, &core.WebhookNotifier{DB: client.db})
+	Must(scheduler.Evaluate(ctx))
+	scheduler.Now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	Must(scheduler.Evaluate(ctx))
+	now = time.Now()
+	Panic(sender.Deliver(ctx))
+	Panic(sender.Deliver(ctx))
+	if len(receiver.payloads) != 5 || receiver.payloads[4].Event != core.WebhookBecameDue {
+		t.Fatalf("expected the due transition to be attempted once before the backoff, got %+v", receiver.payloads)
+	}
+	now = now.Add(time.Minute)
+	Panic(sender.Deliver(ctx))
+	now = now.Add(time.Hour)
+	Panic(sender.Deliver(ctx))
+	if len(receiver.payloads) != 6 || receiver.payloads[5].ID != receiver.payloads[4].ID {
+		t.Fatalf("expected the delivery to be retried once with the same id, got %+v", receiver.payloads)
+	}
+
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/webhooks/%s", cl.List.ID, all.ID)).DoAndExp(http.StatusOK))
+	log := GetTpl[core.WebhookView](client.tmpl, "chore_list_webhook.page.gohtml")
+	if len(log.Deliveries) != 4 || log.Deliveries[0].Status != core.DeliveryFailed || log.Deliveries[0].Attempts != 2 || log.Deliveries[0].ResponseStatus != http.StatusInternalServerError {
+		t.Fatalf("expected the failed delivery in the log, got %+v", log.Deliveries)
+	}
+	for _, d := range log.Deliveries[1:] {
+		if d.Status != core.DeliveryDelivered || d.Attempts != 1 {
+			t.Fatalf("expected the earlier deliveries to be delivered, got %+v", d)
+		}
+	}
+
+	redirect := httptest.NewServer(http.RedirectHandler(srv.URL+"/redirected", http.StatusTemporaryRedirect))
+	defer redirect.Close()
+	redirected := Must(core.CreateWebhook(ctx, client.db, "test", cl.List.ID, core.WebhookInput{URL: redirect.URL, Events: []string{core.WebhookCompleted}}))
+	receiver.status = http.StatusOK
+	for i := range 60 {
+		Panic(core.Complete(ctx, client.db, "test", chore.ID, today.Add(date.Duration(-i-1)*date.Day), 0))
+	}
+	now = time.Now().Add(time.Hour)
+	Panic(sender.Deliver(ctx))
+	for _, path := range receiver.paths {
+		if path == "/redirected" {
+			t.Fatalf("expected redirects not to be followed, got %+v", receiver.paths)
+		}
+	}
+	Must(NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/webhooks/%s", cl.List.ID, redirected.ID)).DoAndExp(http.StatusOK))
+	if log := GetTpl[core.WebhookView](client.tmpl, "chore_list_webhook.page.gohtml"); log.Deliveries[0].ResponseStatus != http.StatusTemporaryRedirect || log.Deliveries[0].Status != core.DeliveryPending {
+		t.Fatalf("expected the redirect to fail the delivery, got %+v", log.Deliveries[0])
+	}
+	var deliveries int
+	Panic(client.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM webhook_delivery WHERE webhook_id = ?", all.ID).Scan(&deliveries))
+	if deliveries != 50 {
+		t.Fatalf("expected the finished deliveries to be pruned to the log size, got %d", deliveries)
+	}
+
+	notifier := &core.WebhookNotifier{DB: client.db}
+	repeated := []core.Transition{{ID: "repeated", Chore: *chore, From: core.ChoreStateUpcoming, To: core.ChoreStateDue}}
+	Panic(notifier.Notify(ctx, repeated))
+	Panic(notifier.Notify(ctx, repeated))
+	Panic(client.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM webhook_delivery WHERE id LIKE 'repeated-%'").Scan(&deliveries))
+	if deliveries != 1 {
+		t.Fatalf("expected a repeated transition to be queued once, got %d", deliveries)
+	}
+
+	Must(NewChoreReq(ctx, client).Auth(tok).Form("POST", fmt.Sprintf("/chore-lists/%s/webhooks/%s/delete", cl.List.ID, all.ID), nil).DoAndExp(http.StatusSeeOther))
+	if _, err := NewChoreReq(ctx, client).Auth(tok).Get(fmt.Sprintf("/chore-lists/%s/webhooks/%s", cl.List.ID, all.ID)).DoAndExp(http.StatusNotFound); err != nil {
+		t.Fatalf("expected the deleted webhook to be gone: %s", err)
+	}
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scope restricts what a session authenticated with an API token may access. Sessions from cookies have no scope and
+// may access everything the user can.
+type Scope struct {
+	TokenID string
+	// AllLists allows all the user's lists, otherwise the token may only access ChoreListIDs. A token whose lists are
+	// all gone has no access.
+	AllLists     bool
+	ChoreListIDs []string
+	Write        bool
+}
+
+func (s *Scope) AllowsList(choreListID string) bool {
+	return s == nil || s.AllLists || slices.Contains(s.ChoreListIDs, choreListID)
+}
+
+// AllowsAllLists reports whether the scope isn't restricted to specific lists, which is required to e.g. create lists.
+func (s *Scope) AllowsAllLists() bool {
+	return s == nil || s.AllLists
+}
+
+func (s *Scope) AllowsWrite() bool {
+	return s == nil || s.Write
+}
+
+type APITokenStore interface {
+	VerifyAPIToken(ctx context.Context, token string, now time.Time) (Session, bool, error)
+}
+
+// BearerToken returns the token of the Authorization header, or "" if there is none.
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func (c *Config) verifyBearerToken(r *http.Request, now time.Time) (Session, bool, error) {
+	token := BearerToken(r)
+	if token == "" || c.APITokens == nil {
+		return Session{}, false, nil
+	}
+	return c.APITokens.VerifyAPIToken(r.Context(), token, now)
+}
@@ -19,6 +19,7 @@ type Session struct {
 	UserID    string
 	Token     string
 	ExpiresAt time.Time
+	Scope     *Scope
 }
 
 type SessionStore interface {
@@ -45,6 +46,7 @@ type Config struct {
 	SessionsPath                string
 	SessionCookie               CookieConfig
 	RefreshCookie               CookieConfig
+	APITokens                   APITokenStore
 }
 
 func (c *Config) SessionHandler() http.Handler {
@@ -200,6 +202,48 @@ func (c *Config) Middleware(allowUnauthenticated, followRedirect bool) srvu.Midd
 	}
 }
 
+// APIMiddleware authenticates requests with a bearer API token or the session cookie like Middleware, but responds
+// with 401 Unauthorized instead of redirecting to the login page. Read-only tokens are rejected for unsafe methods.
+func (c *Config) APIMiddleware() srvu.Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if existingSession, err := GetSession(r.Context()); err == nil && existingSession.UserID != "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+			now := time.Now()
+			if BearerToken(r) != "" {
+				session, ok, err := c.verifyBearerToken(r, now)
+				if err != nil {
+					srvu.GetLogger(r.Context()).Printf("failed to verify api token: %v", err)
+				}
+				if err != nil || !ok {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+				if !isSafeMethod(r.Method) && !session.Scope.AllowsWrite() {
+					http.Error(w, "forbidden: read-only token", http.StatusForbidden)
+					return
+				}
+				h.ServeHTTP(w, r.WithContext(withSession(r.Context(), session)))
+				return
+			}
+			session, refresh, err := c.SessionCookie.verifyToken(r, now)
+			if err != nil {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if refresh {
+				if err := c.SessionCookie.generateStoreAndSetSessionCookie(r.Context(), session.UserID, c.sessionCookiePath(), w); err != nil {
+					srvu.GetLogger(r.Context()).Printf("failed to refresh session cookie: %v", err)
+				}
+			}
+			h.ServeHTTP(w, r.WithContext(withSession(r.Context(), session)))
+		})
+	}
+}
+
 func (c *CookieConfig) generateStoreAndSetSessionCookie(ctx context.Context, userID, path string, w http.ResponseWriter) error {
 	session, err := generateSession(userID, c.Expire, c.TokenLength)
 	if err != nil {
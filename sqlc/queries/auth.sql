@@ -17,4 +17,51 @@ WHERE user_id = ?;
 -- name: GetTokensByUser :many
 SELECT *
 FROM tokens
-WHERE user_id = ?;
\ No newline at end of file
+WHERE user_id = ?;
+
+-- name: CreateAPIToken :one
+INSERT INTO api_token
+    (id, user_id, name, token_hash, write, all_lists, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: CreateAPITokenChoreList :exec
+INSERT INTO api_token_chore_list
+    (api_token_id, chore_list_id)
+VALUES (?, ?);
+
+-- name: GetAPITokenByHash :one
+SELECT *
+FROM api_token
+WHERE token_hash = ?;
+
+-- name: GetAPITokensByUser :many
+SELECT *
+FROM api_token
+WHERE user_id = ?
+ORDER BY created_at DESC, id;
+
+-- name: GetAPITokenChoreLists :many
+SELECT atcl.api_token_id, cl.id, cl.name
+FROM api_token_chore_list atcl
+         JOIN api_token at ON atcl.api_token_id = at.id
+         JOIN chore_list cl ON atcl.chore_list_id = cl.id
+WHERE at.user_id = ?
+ORDER BY cl.name, cl.id;
+
+-- name: GetAPITokenChoreListIDs :many
+SELECT chore_list_id
+FROM api_token_chore_list
+WHERE api_token_id = ?
+ORDER BY chore_list_id;
+
+-- name: TouchAPIToken :exec
+UPDATE api_token
+SET last_used_at = ?
+WHERE id = ?;
+
+-- name: DeleteAPIToken :execrows
+DELETE
+FROM api_token
+WHERE id = ?
+  AND user_id = ?;
+
@@ -0,0 +1,26 @@
+-- name: GetUserDigest :one
+SELECT *
+FROM user_digest
+WHERE user_id = ?;
+
+-- name: SetUserDigest :exec
+INSERT INTO user_digest
+    (user_id, enabled, email, send_at, timezone, updated_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET enabled    = excluded.enabled,
+                                    email      = excluded.email,
+                                    send_at    = excluded.send_at,
+                                    timezone   = excluded.timezone,
+                                    updated_at = excluded.updated_at;
+
+-- name: GetEnabledUserDigests :many
+SELECT ud.*, u.display_name
+FROM user_digest ud
+         JOIN user u ON ud.user_id = u.id
+WHERE ud.enabled = 1
+ORDER BY ud.user_id;
+
+-- name: SetUserDigestSent :exec
+UPDATE user_digest
+SET last_sent_on = ?
+WHERE user_id = ?;
@@ -0,0 +1,35 @@
+-- name: GetVAPIDKey :one
+SELECT *
+FROM vapid_key
+WHERE id = 1;
+
+-- name: CreateVAPIDKey :exec
+INSERT OR IGNORE INTO vapid_key
+    (id, private_key, created_at)
+VALUES (1, ?, ?);
+
+-- name: UpsertPushSubscription :exec
+INSERT INTO push_subscription
+    (id, user_id, endpoint, p256dh, auth, user_agent, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (endpoint) DO UPDATE SET user_id    = excluded.user_id,
+                                     p256dh     = excluded.p256dh,
+                                     auth       = excluded.auth,
+                                     user_agent = excluded.user_agent;
+
+-- name: GetPushSubscriptionsByUser :many
+SELECT *
+FROM push_subscription
+WHERE user_id = ?
+ORDER BY created_at, id;
+
+-- name: DeletePushSubscription :execrows
+DELETE
+FROM push_subscription
+WHERE id = ?
+  AND user_id = ?;
+
+-- name: DeletePushSubscriptionByEndpoint :exec
+DELETE
+FROM push_subscription
+WHERE endpoint = ?;
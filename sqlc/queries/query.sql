@@ -9,8 +9,8 @@ WHERE chore.id = ?
 -- name: CreateChore :one
 INSERT INTO chore
 (id, name, interval, created_at, last_completion, snoozed_for, repeats_left, chore_list_id, created_by, chore_type,
- link)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+ link, recurrence, assigned_to, assignment, auto_complete, effort)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: UpdateChore :one
 UPDATE chore
@@ -19,36 +19,46 @@ SET name            = ?,
     repeats_left    = ?,
     snoozed_for     = ?,
     last_completion = ?,
-    link            = ?
-WHERE id = ? RETURNING *;
+    link            = ?,
+    recurrence      = ?,
+    assigned_to     = ?,
+    assignment      = ?,
+    auto_complete   = ?,
+    effort          = ?,
+    version         = version + 1
+WHERE id = ?
+  AND version = ? RETURNING *;
 
 -- name: DeleteChore :exec
 DELETE
 FROM chore
 WHERE id = ?;
 
--- name: CompleteChore :exec
+-- name: CompleteChore :execrows
 UPDATE chore
 SET last_completion = ?,
-    repeats_left    = max(-1, repeats_left - 1),
-    snoozed_for     = 0
-WHERE id = ?;
+    repeats_left    = CASE WHEN repeats_left > 0 THEN repeats_left - 1 ELSE repeats_left END,
+    snoozed_for     = 0,
+    version         = version + 1
+WHERE id = ?
+  AND version = ?;
 
 -- name: CreateChoreEvent :exec
 INSERT INTO chore_event
-    (id, chore_id, event_type, created_by, occurred_at)
-VALUES (?, ?, ?, ?, ?);
+    (id, chore_id, event_type, created_by, occurred_at, duration, author_name, previous_completion)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
 
--- name: SnoozeChore :exec
+-- name: SnoozeChore :execrows
 UPDATE chore
-SET snoozed_for = ?
-WHERE id = ?;
+SET snoozed_for = ?,
+    version     = version + 1
+WHERE id = ?
+  AND version = ?;
 
 -- name: GetChoresByList :many
 SELECT *
 FROM chore
 WHERE chore_list_id = ?
-  AND NOT repeats_left = 0
 ORDER BY name, id;
 
 -- name: GetChoreListByUser :one
@@ -63,7 +73,7 @@ SELECT cl.*
 FROM chore_list cl
 WHERE cl.id = ?;
 
--- name: GetChoreListCalendarCompletionData :many
+-- name: GetChoreListCalendarEventData :many
 SELECT ce.occurred_at, COUNT(*) AS count
 FROM chore_event ce
     JOIN chore c
@@ -71,14 +81,52 @@ ON ce.chore_id = c.id
     JOIN chore_list_members clm ON c.chore_list_id = clm.chore_list_id
 WHERE clm.user_id = ?
   AND c.chore_list_id = ?
+  AND ce.event_type = ?
 GROUP BY 1
 ORDER BY 1;
 
+-- name: GetChoreListCompletionsByMember :many
+SELECT u.id, CAST(COALESCE(ce.author_name, u.display_name) AS TEXT) AS display_name, COUNT(*) AS count
+FROM chore_event ce
+    JOIN chore c
+ON ce.chore_id = c.id
+    JOIN user u ON ce.created_by = u.id
+    JOIN chore_list_members clm ON c.chore_list_id = clm.chore_list_id
+WHERE clm.user_id = ?
+  AND c.chore_list_id = ?
+  AND ce.event_type = 'complete'
+GROUP BY u.id, ce.author_name
+ORDER BY count DESC, display_name, u.id;
+
+-- name: GetChoreListEvents :many
+SELECT ce.*, CAST(COALESCE(ce.author_name, u.display_name) AS TEXT) AS created_by_name
+FROM chore_event ce
+    JOIN chore c
+ON ce.chore_id = c.id
+    JOIN user u ON ce.created_by = u.id
+    JOIN chore_list_members clm ON c.chore_list_id = clm.chore_list_id
+WHERE clm.user_id = ?
+  AND c.chore_list_id = ?
+ORDER BY ce.occurred_at, ce.rowid;
+
+-- name: GetChoreListLeaderboard :many
+SELECT u.id, u.display_name, CAST(TOTAL(c.effort) AS INTEGER) AS points, COUNT(c.id) AS completions
+FROM chore_list_members clm
+         JOIN user u ON u.id = clm.user_id
+         LEFT JOIN chore_event ce
+                   ON ce.created_by = u.id AND ce.event_type = 'complete' AND ce.author_name IS NULL AND
+                      ce.occurred_at >= ?
+         LEFT JOIN chore c ON ce.chore_id = c.id AND c.chore_list_id = clm.chore_list_id
+WHERE clm.chore_list_id = ?
+GROUP BY u.id, u.display_name
+ORDER BY points DESC, u.display_name, u.id;
+
 -- name: GetChoreListMembers :many
 SELECT u.id, u.display_name
 FROM user u
          JOIN chore_list_members clm ON u.id = clm.user_id
-WHERE clm.chore_list_id = ?;
+WHERE clm.chore_list_id = ?
+ORDER BY u.display_name, u.id;
 
 -- name: GetChoreListsByUser :many
 SELECT cl.*,
@@ -111,3 +159,173 @@ WHERE chore_list_id = ?
 INSERT INTO chore_list_members
     (chore_list_id, user_id)
 VALUES (?, ?);
+
+-- name: GetChoreEvents :many
+SELECT ce.*, CAST(COALESCE(ce.author_name, u.display_name) AS TEXT) AS created_by_name
+FROM chore_event ce
+         JOIN user u ON ce.created_by = u.id
+WHERE ce.chore_id = ?
+ORDER BY ce.occurred_at DESC, ce.id DESC;
+
+-- name: DeleteChoreCompletion :one
+DELETE
+FROM chore_event
+WHERE id = ?
+  AND chore_id = ?
+  AND event_type = 'complete' RETURNING *;
+
+-- name: GetLastChoreCompletion :one
+SELECT CAST(COALESCE(MAX(occurred_at), 0) AS INTEGER) AS last_completion
+FROM chore_event
+WHERE chore_id = ?
+  AND event_type = 'complete';
+
+-- name: UncompleteChore :exec
+UPDATE chore
+SET last_completion = ?,
+    repeats_left    = CASE WHEN repeats_left >= 0 THEN repeats_left + 1 ELSE repeats_left END,
+    version         = version + 1
+WHERE id = ?;
+
+-- name: HasChoreCompletionOn :one
+SELECT EXISTS(SELECT 1
+              FROM chore_event
+              WHERE chore_id = ?
+                AND event_type = 'complete'
+                AND occurred_at = ?) AS completed;
+
+-- name: AssignChore :exec
+UPDATE chore
+SET assigned_to = ?
+WHERE id = ?;
+
+-- name: GetChoreAssignmentCandidates :many
+SELECT clm.user_id, CAST(COALESCE(MAX(ce.occurred_at), 0) AS INTEGER) AS last_completion
+FROM chore_list_members clm
+         LEFT JOIN chore_event ce
+                   ON ce.created_by = clm.user_id AND ce.chore_id = ? AND ce.event_type = 'complete' AND ce.author_name IS NULL
+WHERE clm.chore_list_id = ?
+GROUP BY clm.user_id
+ORDER BY clm.user_id;
+
+-- name: UnassignChoreListMember :exec
+UPDATE chore
+SET assigned_to = NULL
+WHERE chore_list_id = ?
+  AND assigned_to = ?;
+
+-- name: GetChoreChecklist :many
+SELECT *
+FROM chore_checklist_item
+WHERE chore_id = ?
+ORDER BY position;
+
+-- name: GetChoreListChecklists :many
+SELECT cci.*
+FROM chore_checklist_item cci
+         JOIN chore c ON cci.chore_id = c.id
+WHERE c.chore_list_id = ?
+ORDER BY cci.chore_id, cci.position;
+
+-- name: DeleteChoreChecklist :exec
+DELETE
+FROM chore_checklist_item
+WHERE chore_id = ?;
+
+-- name: CreateChoreChecklistItem :exec
+INSERT INTO chore_checklist_item
+    (id, chore_id, position, name, checked, checked_by)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: CheckChoreChecklistItem :one
+UPDATE chore_checklist_item
+SET checked    = ?,
+    checked_by = ?
+WHERE id = ?
+  AND chore_id = ? RETURNING *;
+
+-- name: CountUncheckedChoreChecklistItems :one
+SELECT COUNT(*)
+FROM chore_checklist_item
+WHERE chore_id = ?
+  AND checked = 0;
+
+-- name: ResetChoreChecklist :exec
+UPDATE chore_checklist_item
+SET checked    = 0,
+    checked_by = NULL
+WHERE chore_id = ?;
+
+-- name: GetChoreListDependencies :many
+SELECT cd.*
+FROM chore_dependency cd
+         JOIN chore c ON cd.chore_id = c.id
+WHERE c.chore_list_id = ?
+ORDER BY cd.chore_id, cd.depends_on_id;
+
+-- name: GetChoreListLastCompletions :many
+SELECT ce.chore_id, CAST(MAX(ce.occurred_at) AS INTEGER) AS occurred_at
+FROM chore_event ce
+         JOIN chore c ON ce.chore_id = c.id
+WHERE c.chore_list_id = ?
+  AND ce.event_type = 'complete'
+GROUP BY ce.chore_id;
+
+-- name: DeleteChoreDependencies :exec
+DELETE
+FROM chore_dependency
+WHERE chore_id = ?;
+
+-- name: CreateChoreDependency :exec
+INSERT INTO chore_dependency
+    (chore_id, depends_on_id)
+VALUES (?, ?);
+
+-- name: UpsertChoreListFeed :one
+INSERT INTO chore_list_feed
+    (token, chore_list_id, user_id, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (chore_list_id, user_id) DO UPDATE SET token      = excluded.token,
+                                                   created_at = excluded.created_at
+RETURNING *;
+
+-- name: GetChoreListFeed :one
+SELECT *
+FROM chore_list_feed
+WHERE chore_list_id = ?
+  AND user_id = ?;
+
+-- name: GetChoreListFeedByToken :one
+SELECT clf.*
+FROM chore_list_feed clf
+         JOIN chore_list_members clm ON clf.chore_list_id = clm.chore_list_id AND clf.user_id = clm.user_id
+WHERE clf.token = ?;
+
+-- name: DeleteChoreListFeed :exec
+DELETE
+FROM chore_list_feed
+WHERE chore_list_id = ?
+  AND user_id = ?;
+
+-- name: UpsertUserFeed :one
+INSERT INTO user_feed
+    (token, user_id, created_at)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET token      = excluded.token,
+                                    created_at = excluded.created_at
+RETURNING *;
+
+-- name: GetUserFeed :one
+SELECT *
+FROM user_feed
+WHERE user_id = ?;
+
+-- name: GetUserFeedByToken :one
+SELECT *
+FROM user_feed
+WHERE token = ?;
+
+-- name: DeleteUserFeed :exec
+DELETE
+FROM user_feed
+WHERE user_id = ?;
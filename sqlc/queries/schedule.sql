@@ -0,0 +1,65 @@
+-- name: GetChoreLists :many
+SELECT *
+FROM chore_list
+ORDER BY id;
+
+-- name: UpdateChoreListTimezone :one
+UPDATE chore_list
+SET timezone   = ?,
+    updated_at = ?
+WHERE id = ?
+  AND id IN (SELECT chore_list_id FROM chore_list_members WHERE user_id = ?) RETURNING *;
+
+-- name: GetChoreStatesByList :many
+SELECT cs.*
+FROM chore_state cs
+         JOIN chore c ON cs.chore_id = c.id
+WHERE c.chore_list_id = ?;
+
+-- name: SetChoreState :exec
+INSERT INTO chore_state
+    (chore_id, state, due_on, evaluated_on)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (chore_id) DO UPDATE SET state        = excluded.state,
+                                     due_on       = excluded.due_on,
+                                     evaluated_on = excluded.evaluated_on;
+
+-- name: CreateChoreTransition :exec
+INSERT INTO chore_transition
+    (id, chore_id, chore_list_id, from_state, to_state, due_on, occurred_on, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetChoreTransitions :many
+SELECT *
+FROM chore_transition
+WHERE chore_id = ?
+ORDER BY occurred_on, created_at, id;
+
+-- name: CreatePendingChoreTransition :exec
+INSERT INTO chore_transition_pending
+    (transition_id, notifier, created_at)
+VALUES (?, ?, ?);
+
+-- name: GetPendingChoreTransitions :many
+SELECT ct.*
+FROM chore_transition_pending ctp
+         JOIN chore_transition ct ON ctp.transition_id = ct.id
+WHERE ctp.notifier = ?
+ORDER BY ct.created_at, ct.id;
+
+-- name: DeletePendingChoreTransitions :exec
+DELETE
+FROM chore_transition_pending
+WHERE notifier = ?
+  AND transition_id IN (sqlc.slice(transition_ids));
+
+-- name: DeleteExpiredPendingChoreTransitions :exec
+DELETE
+FROM chore_transition_pending
+WHERE created_at < ?;
+
+-- name: DeleteExpiredChoreTransitions :exec
+DELETE
+FROM chore_transition
+WHERE chore_transition.created_at < ?
+  AND NOT EXISTS (SELECT 1 FROM chore_transition_pending ctp WHERE ctp.transition_id = chore_transition.id);
@@ -0,0 +1,65 @@
+-- name: CreateWebhook :one
+INSERT INTO webhook
+    (id, chore_list_id, url, secret, events, created_by, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetWebhooksByChoreList :many
+SELECT *
+FROM webhook
+WHERE chore_list_id = ?
+ORDER BY created_at, id;
+
+-- name: GetWebhook :one
+SELECT *
+FROM webhook
+WHERE id = ?
+  AND chore_list_id = ?;
+
+-- name: DeleteWebhook :execrows
+DELETE
+FROM webhook
+WHERE id = ?
+  AND chore_list_id = ?;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_delivery
+    (id, webhook_id, event, payload, status, next_attempt_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: GetDueWebhookDeliveries :many
+SELECT wd.*, w.url, w.secret
+FROM webhook_delivery wd
+         JOIN webhook w ON wd.webhook_id = w.id
+WHERE wd.status = 'pending'
+  AND wd.next_attempt_at <= ?
+ORDER BY wd.next_attempt_at, wd.created_at
+LIMIT ?;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_delivery
+SET status          = ?,
+    attempts        = ?,
+    next_attempt_at = ?,
+    last_attempt_at = ?,
+    response_status = ?,
+    error           = ?
+WHERE id = ?;
+
+-- name: GetWebhookDeliveries :many
+SELECT *
+FROM webhook_delivery
+WHERE webhook_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ?;
+
+-- name: PruneWebhookDeliveries :exec
+DELETE
+FROM webhook_delivery
+WHERE status != 'pending'
+  AND (SELECT COUNT(*)
+       FROM webhook_delivery newer
+       WHERE newer.webhook_id = webhook_delivery.webhook_id
+         AND (newer.created_at > webhook_delivery.created_at
+           OR newer.created_at = webhook_delivery.created_at AND newer.id > webhook_delivery.id)) >= CAST(sqlc.arg(keep) AS INTEGER);
@@ -0,0 +1,3 @@
+-- migrate:up
+ALTER TABLE chore
+    ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- migrate:up
+ALTER TABLE chore_event ADD COLUMN duration INTEGER NOT NULL DEFAULT 0;
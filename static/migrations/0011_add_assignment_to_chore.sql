@@ -0,0 +1,3 @@
+-- migrate:up
+ALTER TABLE chore ADD COLUMN assigned_to TEXT REFERENCES user (id) ON DELETE SET NULL;
+ALTER TABLE chore ADD COLUMN assignment TEXT NOT NULL DEFAULT '';
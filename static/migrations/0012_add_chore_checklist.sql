@@ -0,0 +1,14 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS chore_checklist_item
+(
+    id         TEXT    NOT NULL PRIMARY KEY,
+    chore_id   TEXT    NOT NULL,
+    position   INTEGER NOT NULL,
+    name       TEXT    NOT NULL,
+    checked    INTEGER NOT NULL DEFAULT 0,
+    checked_by TEXT,
+    FOREIGN KEY (chore_id) REFERENCES chore (id) ON DELETE CASCADE,
+    FOREIGN KEY (checked_by) REFERENCES user (id) ON DELETE SET NULL
+);
+
+ALTER TABLE chore ADD COLUMN auto_complete INTEGER NOT NULL DEFAULT 0;
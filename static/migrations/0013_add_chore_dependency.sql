@@ -0,0 +1,9 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS chore_dependency
+(
+    chore_id      TEXT NOT NULL,
+    depends_on_id TEXT NOT NULL,
+    PRIMARY KEY (chore_id, depends_on_id),
+    FOREIGN KEY (chore_id) REFERENCES chore (id) ON DELETE CASCADE,
+    FOREIGN KEY (depends_on_id) REFERENCES chore (id) ON DELETE CASCADE
+);
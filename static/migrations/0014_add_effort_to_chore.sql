@@ -0,0 +1,2 @@
+-- migrate:up
+ALTER TABLE chore ADD COLUMN effort INTEGER NOT NULL DEFAULT 1;
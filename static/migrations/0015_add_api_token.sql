@@ -0,0 +1,22 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS api_token
+(
+    id           TEXT    NOT NULL PRIMARY KEY,
+    user_id      TEXT    NOT NULL,
+    name         TEXT    NOT NULL,
+    token_hash   TEXT    NOT NULL UNIQUE,
+    write        INTEGER NOT NULL DEFAULT 0,
+    all_lists    INTEGER NOT NULL DEFAULT 0,
+    created_at   INTEGER NOT NULL,
+    last_used_at INTEGER,
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS api_token_chore_list
+(
+    api_token_id  TEXT NOT NULL,
+    chore_list_id TEXT NOT NULL,
+    PRIMARY KEY (api_token_id, chore_list_id),
+    FOREIGN KEY (api_token_id) REFERENCES api_token (id) ON DELETE CASCADE,
+    FOREIGN KEY (chore_list_id) REFERENCES chore_list (id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS chore_list_feed
+(
+    token         TEXT    NOT NULL PRIMARY KEY,
+    chore_list_id TEXT    NOT NULL,
+    user_id       TEXT    NOT NULL,
+    created_at    INTEGER NOT NULL,
+    UNIQUE (chore_list_id, user_id),
+    FOREIGN KEY (chore_list_id) REFERENCES chore_list (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
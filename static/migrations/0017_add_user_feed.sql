@@ -0,0 +1,8 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS user_feed
+(
+    token      TEXT    NOT NULL PRIMARY KEY,
+    user_id    TEXT    NOT NULL UNIQUE,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,31 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS webhook
+(
+    id            TEXT    NOT NULL PRIMARY KEY,
+    chore_list_id TEXT    NOT NULL,
+    url           TEXT    NOT NULL,
+    secret        TEXT    NOT NULL,
+    events        TEXT    NOT NULL,
+    created_by    TEXT    NOT NULL,
+    created_at    INTEGER NOT NULL,
+    FOREIGN KEY (chore_list_id) REFERENCES chore_list (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery
+(
+    id              TEXT    NOT NULL PRIMARY KEY,
+    webhook_id      TEXT    NOT NULL,
+    event           TEXT    NOT NULL,
+    payload         TEXT    NOT NULL,
+    status          TEXT    NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    last_attempt_at INTEGER,
+    response_status INTEGER,
+    error           TEXT,
+    created_at      INTEGER NOT NULL,
+    FOREIGN KEY (webhook_id) REFERENCES webhook (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_pending ON webhook_delivery (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS webhook_delivery_webhook ON webhook_delivery (webhook_id, created_at);
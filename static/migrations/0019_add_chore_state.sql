@@ -0,0 +1,27 @@
+-- migrate:up
+ALTER TABLE chore_list ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+CREATE TABLE IF NOT EXISTS chore_state
+(
+    chore_id     TEXT    NOT NULL PRIMARY KEY,
+    state        TEXT    NOT NULL,
+    due_on       INTEGER NOT NULL,
+    evaluated_on INTEGER NOT NULL,
+    FOREIGN KEY (chore_id) REFERENCES chore (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS chore_transition
+(
+    id            TEXT    NOT NULL PRIMARY KEY,
+    chore_id      TEXT    NOT NULL,
+    chore_list_id TEXT    NOT NULL,
+    from_state    TEXT    NOT NULL,
+    to_state      TEXT    NOT NULL,
+    due_on        INTEGER NOT NULL,
+    occurred_on   INTEGER NOT NULL,
+    created_at    INTEGER NOT NULL,
+    FOREIGN KEY (chore_id) REFERENCES chore (id) ON DELETE CASCADE,
+    FOREIGN KEY (chore_list_id) REFERENCES chore_list (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS chore_transition_chore ON chore_transition (chore_id, occurred_on);
@@ -0,0 +1,12 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS user_digest
+(
+    user_id      TEXT    NOT NULL PRIMARY KEY,
+    enabled      INTEGER NOT NULL,
+    email        TEXT    NOT NULL,
+    send_at      INTEGER NOT NULL,
+    timezone     TEXT    NOT NULL,
+    last_sent_on INTEGER NOT NULL DEFAULT 0,
+    updated_at   INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,21 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS vapid_key
+(
+    id          INTEGER NOT NULL PRIMARY KEY CHECK (id = 1),
+    private_key TEXT    NOT NULL,
+    created_at  INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS push_subscription
+(
+    id         TEXT    NOT NULL PRIMARY KEY,
+    user_id    TEXT    NOT NULL,
+    endpoint   TEXT    NOT NULL UNIQUE,
+    p256dh     TEXT    NOT NULL,
+    auth       TEXT    NOT NULL,
+    user_agent TEXT    NOT NULL,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS push_subscription_user ON push_subscription (user_id, created_at);
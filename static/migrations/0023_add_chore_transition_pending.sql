@@ -0,0 +1,11 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS chore_transition_pending
+(
+    transition_id TEXT    NOT NULL,
+    notifier      TEXT    NOT NULL,
+    created_at    INTEGER NOT NULL,
+    PRIMARY KEY (transition_id, notifier),
+    FOREIGN KEY (transition_id) REFERENCES chore_transition (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS chore_transition_pending_notifier ON chore_transition_pending (notifier, created_at);
@@ -0,0 +1,3 @@
+-- migrate:up
+ALTER TABLE chore_event
+    ADD COLUMN previous_completion INTEGER;
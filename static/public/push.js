@@ -0,0 +1,52 @@
+function pushKeyBytes(key) {
+    const base64 = (key + '='.repeat((4 - key.length % 4) % 4)).replace(/-/g, '+').replace(/_/g, '/');
+    return Uint8Array.from(atob(base64), c => c.charCodeAt(0));
+}
+
+async function subscribePush(key) {
+    if (await Notification.requestPermission() !== 'granted') {
+        return;
+    }
+    const registration = await navigator.serviceWorker.ready;
+    let subscription = await registration.pushManager.getSubscription();
+    if (subscription && subscription.options.applicationServerKey) {
+        // a subscription of an earlier key can't receive our pushes
+        const current = new Uint8Array(subscription.options.applicationServerKey);
+        if (current.toString() !== pushKeyBytes(key).toString()) {
+            await subscription.unsubscribe();
+            subscription = null;
+        }
+    }
+    if (!subscription) {
+        subscription = await registration.pushManager.subscribe({
+            userVisibleOnly: true,
+            applicationServerKey: pushKeyBytes(key),
+        });
+    }
+    const res = await fetch('/settings/push/subscriptions/', {
+        method: 'POST',
+        headers: {'Content-Type': 'application/json'},
+        body: JSON.stringify(subscription),
+    });
+    if (!res.ok) {
+        throw new Error(`saving subscription: ${res.status}`);
+    }
+    window.location.reload();
+}
+
+document.addEventListener('DOMContentLoaded', async () => {
+    const button = document.getElementById('push-subscribe-button');
+    if (!button || !('serviceWorker' in navigator) || !('PushManager' in window) || !('Notification' in window)) {
+        return;
+    }
+    const registration = await navigator.serviceWorker.ready;
+    const subscription = await registration.pushManager.getSubscription();
+    const registered = [...document.querySelectorAll('[data-push-endpoint]')].map(e => e.dataset.pushEndpoint);
+    if (subscription && registered.includes(subscription.endpoint) && Notification.permission === 'granted') {
+        return;
+    }
+    button.hidden = false;
+    button.addEventListener('click', () => {
+        subscribePush(button.dataset.key).catch(err => console.error("Push subscription failed:", err));
+    });
+});
@@ -4,3 +4,23 @@ self.addEventListener("install", () => {
 
 self.addEventListener("fetch", () => {
 });
+
+self.addEventListener("push", (event) => {
+    const msg = event.data ? event.data.json() : {};
+    event.waitUntil(self.registration.showNotification(msg.title || "Chores", {
+        body: msg.body,
+        tag: msg.tag,
+        renotify: Boolean(msg.tag),
+        icon: "/static/public/favicon.webp",
+        data: {url: msg.url || "/"},
+    }));
+});
+
+self.addEventListener("notificationclick", (event) => {
+    event.notification.close();
+    const url = new URL(event.notification.data?.url || "/", self.location.origin).href;
+    event.waitUntil(self.clients.matchAll({type: "window", includeUncontrolled: true}).then((windows) => {
+        const open = windows.find((w) => w.url === url);
+        return open ? open.focus() : self.clients.openWindow(url);
+    }));
+});